@@ -8,6 +8,7 @@ import (
 	"fmt"
 	"io/ioutil"
 	"os"
+	"strconv"
 	"strings"
 
 	"k8s.io/klog/v2"
@@ -15,6 +16,8 @@ import (
 	clustercuratorv1 "github.com/stolostron/cluster-curator-controller/pkg/api/v1beta1"
 	"github.com/stolostron/cluster-curator-controller/pkg/controller/launcher"
 	"github.com/stolostron/cluster-curator-controller/pkg/jobs/ansible"
+	"github.com/stolostron/cluster-curator-controller/pkg/jobs/approval"
+	"github.com/stolostron/cluster-curator-controller/pkg/jobs/dryrun"
 	"github.com/stolostron/cluster-curator-controller/pkg/jobs/hive"
 	"github.com/stolostron/cluster-curator-controller/pkg/jobs/hypershift"
 	"github.com/stolostron/cluster-curator-controller/pkg/jobs/importer"
@@ -26,7 +29,7 @@ import (
 	clientv1 "sigs.k8s.io/controller-runtime/pkg/client"
 )
 
-const CuratorJob = "clustercurator-job"
+const CuratorJob = utils.CuratorJobCondition
 
 /* Uses the following environment variables:
  * ./curator applycloudprovider
@@ -70,7 +73,8 @@ func curatorRun(config *rest.Config, client clientv1.Client, clusterName string,
 	var cmdErrorMsg = errors.New("Invalid Parameter: \"" + os.Args[1] +
 		"\"\nCommand: ./curator [monitor-import|monitor|activate-and-monitor|applycloudprovider-aws|" +
 		"applycloudprovider-gcp|applycloudprovider-azure|upgrade-cluster|intermediate-upgrade-cluster|" +
-		"final-upgrade-cluster|monitor-upgrade|intermediate-monitor-upgrade|prehook-ansiblejob|posthook-ansiblejob|done]")
+		"final-upgrade-cluster|monitor-upgrade|intermediate-monitor-upgrade|scale-cluster|monitor-scale|" +
+		"prehook-ansiblejob|posthook-ansiblejob|wait-for-approval|dry-run|done]")
 
 	if len(os.Args) > 1 {
 		switch os.Args[1] {
@@ -78,7 +82,8 @@ func curatorRun(config *rest.Config, client clientv1.Client, clusterName string,
 			"applycloudprovider-gcp", "applycloudprovider-azure", "activate-and-monitor", "upgrade-cluster",
 			"intermediate-upgrade-cluster", "final-upgrade-cluster", "monitor-upgrade", "intermediate-monitor-upgrade",
 			"SKIP_ALL_TESTING", "prehook-ansiblejob", "posthook-ansiblejob", "done", "destroy-cluster", "monitor-destroy",
-			"detach-nowait", "delete-cluster-namespace":
+			"detach-nowait", "delete-cluster-namespace", "scale-cluster", "monitor-scale", "wait-for-approval",
+			"dry-run":
 		default:
 			utils.CheckError(cmdErrorMsg)
 		}
@@ -93,15 +98,15 @@ func curatorRun(config *rest.Config, client clientv1.Client, clusterName string,
 	// Gets the Cluster Configuration overrides
 	curator, err := utils.GetClusterCurator(client, clusterName, clusterNamespace)
 	var desiredCuration string
+	isDryRun := false
 	if curator != nil {
-		desiredCuration = curator.Spec.DesiredCuration
-		if curator.Operation != nil && curator.Operation.RetryPosthook != "" {
-			if curator.Operation.RetryPosthook == "installPosthook" {
-				desiredCuration = "install"
-			}
-			if curator.Operation.RetryPosthook == "upgradePosthook" {
-				desiredCuration = "upgrade"
-			}
+		isDryRun = utils.IsDryRun(curator)
+		desiredCuration = utils.GetEffectiveCuration(curator)
+		if desiredCuration == "installPosthook" {
+			desiredCuration = "install"
+		}
+		if desiredCuration == "upgradePosthook" {
+			desiredCuration = "upgrade"
 		}
 	}
 
@@ -132,11 +137,13 @@ func curatorRun(config *rest.Config, client clientv1.Client, clusterName string,
 		// This makes sure we set the curator-job condition to false when there is a failure
 		defer func() {
 			if r := recover(); r != nil {
-				message := curator.Spec.CuratingJob + " DesiredCuration: " + desiredCuration
-				if desiredCuration == "upgrade" {
-					message = message + " Version (" + utils.GetCurrentVersionInfo(curator) + ")"
+				// The onFailure hooks run before the curation is marked failed, as that removes the
+				// curator Job and its RBAC
+				if !isDryRun {
+					runFailureHooks(client, curator, jobChoice, fmt.Sprintf("%v", r))
 				}
-				message = message + " Failed - " + fmt.Sprintf("%v", r)
+				message := curator.Spec.CuratingJob + " DesiredCuration: " + desiredCuration +
+					" Failed - " + fmt.Sprintf("%v", r)
 				utils.CheckError(utils.RecordFailedCuratorStatusCondition(
 					client,
 					clusterName,
@@ -144,8 +151,12 @@ func curatorRun(config *rest.Config, client clientv1.Client, clusterName string,
 					CuratorJob,
 					v1.ConditionTrue,
 					message))
+				if desiredCuration == "upgrade" && !isDryRun {
+					utils.CheckError(utils.RecordLastUpgrade(
+						client, clusterName, clusterNamespace, clustercuratorv1.CurationPhaseFailed))
+				}
 				// Remove curatingJob and desiredCuration from curator resource for failed job
-				updateFailingClusterCurator(client, curator)
+				updateFailingClusterCurator(client, curator, isDryRun)
 				panic(r)
 			}
 		}()
@@ -480,6 +491,42 @@ func curatorRun(config *rest.Config, client clientv1.Client, clusterName string,
 		}
 	}
 
+	if jobChoice == "scale-cluster" || jobChoice == "monitor-scale" {
+		dynclient, dErr := utils.GetDynset(nil)
+		utils.CheckError(dErr)
+
+		clusterType, ctErr := utils.GetClusterType(client, dynclient, clusterName, clusterNamespace, false)
+		utils.CheckError(ctErr)
+
+		var scaleErr error
+		if clusterType == utils.StandaloneClusterType {
+			if jobChoice == "scale-cluster" {
+				scaleErr = hive.ScaleCluster(client, clusterName, curator)
+			} else {
+				scaleErr = hive.MonitorScaleStatus(client, clusterName, curator)
+			}
+		} else if clusterType == utils.HypershiftClusterType {
+			if jobChoice == "scale-cluster" {
+				scaleErr = hypershift.ScaleCluster(dynclient, clusterName, curator)
+			} else {
+				scaleErr = hypershift.MonitorScaleStatus(dynclient, client, clusterName, curator)
+			}
+		} else {
+			scaleErr = errors.New("Can not scale cluster " + clusterName + " of unknown type " + clusterType)
+		}
+		if scaleErr != nil {
+			utils.CheckError(utils.RecordFailedCuratorStatusCondition(
+				client,
+				clusterName,
+				clusterNamespace,
+				jobChoice,
+				v1.ConditionTrue,
+				scaleErr.Error()))
+			klog.Error(scaleErr.Error())
+			panic(scaleErr)
+		}
+	}
+
 	if jobChoice == "delete-cluster-namespace" {
 
 		if err := updateDeleteClusternamespace(client, curator); err != nil {
@@ -509,6 +556,49 @@ func curatorRun(config *rest.Config, client clientv1.Client, clusterName string,
 		}
 	}
 
+	// The approval is removed once it is seen, a restarted curator pod does not wait for it again
+	if jobChoice == launcher.WaitForApproval && utils.IsStepSucceeded(curator, jobChoice) {
+		klog.V(0).Info("The curation was approved before the curator pod was restarted ✓")
+	} else if jobChoice == launcher.WaitForApproval {
+		utils.CheckError(utils.RecordCurrentStatusCondition(
+			client,
+			clusterName,
+			clusterNamespace,
+			jobChoice,
+			v1.ConditionFalse,
+			"Waiting up to "+strconv.Itoa(approval.GetApprovalTimeout(curator))+
+				" minutes for operation.approve or the "+approval.ApprovedAnnotation+" annotation"))
+
+		if err = approval.WaitForApproval(client, clusterName, clusterNamespace); err != nil {
+			utils.CheckError(utils.RecordFailedCuratorStatusCondition(
+				client,
+				clusterName,
+				clusterNamespace,
+				jobChoice,
+				v1.ConditionTrue,
+				err.Error()))
+			klog.Error(err.Error())
+			panic(err)
+		}
+	}
+
+	if jobChoice == launcher.DryRun {
+		dynclient, dErr := utils.GetDynset(nil)
+		utils.CheckError(dErr)
+
+		if err = dryrun.Run(client, dynclient, curator); err != nil {
+			utils.CheckError(utils.RecordFailedCuratorStatusCondition(
+				client,
+				clusterName,
+				clusterNamespace,
+				jobChoice,
+				v1.ConditionTrue,
+				err.Error()))
+			klog.Error(err.Error())
+			panic(err)
+		}
+	}
+
 	// Override finished init container message with finished curator-job message
 	msg := "Completed executing init container"
 	condition := v1.ConditionTrue
@@ -518,12 +608,14 @@ func curatorRun(config *rest.Config, client clientv1.Client, clusterName string,
 		msg = curator.Spec.CuratingJob + " DesiredCuration: " + desiredCuration
 		condition = v1.ConditionTrue
 
-		if desiredCuration == "upgrade" {
-			msg = msg + " Version (" + utils.GetCurrentVersionInfo(curator) + ")"
-		}
+		// Remove DesireCuration, CuratingJob, Conditions from curator resource, the phase, timestamps
+		// and steps are kept as the record of the finished curation
+		updateDoneClusterCurator(client, curator, clusterName, isDryRun)
 
-		// Remove DesireCuration, CuratingJob, Status from curator resource
-		updateDoneClusterCurator(client, curator, clusterName)
+		if desiredCuration == "upgrade" && !isDryRun {
+			utils.CheckError(utils.RecordLastUpgrade(
+				client, clusterName, clusterNamespace, clustercuratorv1.CurationPhaseSucceeded))
+		}
 	}
 
 	// Used to signal end of job as well as end of init container
@@ -538,21 +630,43 @@ func curatorRun(config *rest.Config, client clientv1.Client, clusterName string,
 	klog.V(2).Info("Done!")
 }
 
-func updateDoneClusterCurator(client clientv1.Client, curator *clustercuratorv1.ClusterCurator, clusterName string) {
-	if curator.Spec.DesiredCuration == "upgrade" {
-		patch := []byte(`{"spec":{"curatorJob": null},"status": null, "operation": null}`)
+// runFailureHooks runs the onFailure hooks of the failed step, a failure or panic of the hooks is
+// only logged so the failed curation is still recorded
+func runFailureHooks(
+	client clientv1.Client, curator *clustercuratorv1.ClusterCurator, failedStep string, failureMessage string) {
+
+	defer func() {
+		if r := recover(); r != nil {
+			klog.Warningf("The onFailure hooks failed: %v", r)
+		}
+	}()
+	if err := ansible.RunFailureHooks(client, curator, failedStep, failureMessage); err != nil {
+		klog.Warningf("The onFailure hooks failed: %v", err)
+	}
+}
+
+// updateDoneClusterCurator keeps desiredCuration for an upgrade, utils.NeedToUpgrade decides when it
+// runs again. A dry run never upgrades, so desiredCuration is always removed.
+func updateDoneClusterCurator(
+	client clientv1.Client,
+	curator *clustercuratorv1.ClusterCurator,
+	clusterName string,
+	isDryRun bool) {
+
+	if curator.Spec.DesiredCuration == "upgrade" && !isDryRun {
+		patch := []byte(`{"spec":{"curatorJob": null},"status":{"conditions": null}, "operation": null}`)
 		err := client.Patch(context.Background(), curator, clientv1.RawPatch(types.MergePatchType, patch))
 		utils.CheckError(err)
 		return
 	}
 
-	patch := []byte(`{"spec":{"curatorJob": null, "desiredCuration": null},"status": null, "operation": null}`)
+	patch := []byte(`{"spec":{"curatorJob": null, "desiredCuration": null},"status":{"conditions": null}, "operation": null}`)
 	err := client.Patch(context.Background(), curator, clientv1.RawPatch(types.MergePatchType, patch))
 	utils.CheckError(err)
 }
 
-func updateFailingClusterCurator(client clientv1.Client, curator *clustercuratorv1.ClusterCurator) {
-	if curator.Spec.DesiredCuration == "upgrade" {
+func updateFailingClusterCurator(client clientv1.Client, curator *clustercuratorv1.ClusterCurator, isDryRun bool) {
+	if curator.Spec.DesiredCuration == "upgrade" && !isDryRun {
 		patch := []byte(`{"spec":{"curatorJob": null}, "operation": null}`)
 		err := client.Patch(context.Background(), curator, clientv1.RawPatch(types.MergePatchType, patch))
 		utils.CheckError(err)
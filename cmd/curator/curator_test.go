@@ -440,6 +440,109 @@ func TestUpgradDone(t *testing.T) {
 	).Build()
 
 	curatorRun(nil, client, ClusterName, ClusterName)
+
+	curator, err := utils.GetClusterCurator(client, ClusterName, ClusterName)
+	assert.Nil(t, err, "err nil, when ClusterCurator is retrieved")
+	assert.NotNil(t, curator.Status.LastUpgrade, "lastUpgrade is recorded")
+	assert.Equal(t, "4.11.4", curator.Status.LastUpgrade.DesiredUpdate)
+	assert.Equal(t, clustercuratorv1.CurationPhaseSucceeded, curator.Status.LastUpgrade.Result)
+}
+
+func TestInstallDoneKeepsCurationStatus(t *testing.T) {
+	defer func() {
+		if r := recover(); r != nil {
+			t.Fatalf("unexpected error %v", r)
+		}
+	}()
+
+	os.Args[1] = "done"
+
+	s := scheme.Scheme
+	s.AddKnownTypes(utils.CCGVR.GroupVersion(), &clustercuratorv1.ClusterCurator{})
+
+	client := clientfake.NewClientBuilder().WithScheme(s).WithRuntimeObjects(
+		&clustercuratorv1.ClusterCurator{
+			ObjectMeta: v1.ObjectMeta{
+				Name:      ClusterName,
+				Namespace: ClusterName,
+			},
+			Spec: clustercuratorv1.ClusterCuratorSpec{
+				DesiredCuration: "install",
+				CuratingJob:     "curator-job-12345",
+			},
+			Status: clustercuratorv1.ClusterCuratorStatus{
+				Conditions: []v1.Condition{{
+					Type:               "activate-and-monitor",
+					Status:             v1.ConditionTrue,
+					Reason:             utils.JobHasFinished,
+					Message:            "Completed executing init container",
+					LastTransitionTime: v1.Now(),
+				}},
+				Phase:        clustercuratorv1.CurationPhaseRunning,
+				CurationType: "install",
+				JobName:      "curator-job-12345",
+				Steps: []clustercuratorv1.CurationStep{
+					{Name: "activate-and-monitor", State: clustercuratorv1.CurationPhaseSucceeded},
+				},
+			},
+		},
+	).Build()
+
+	curatorRun(nil, client, ClusterName, ClusterName)
+
+	curator, err := utils.GetClusterCurator(client, ClusterName, ClusterName)
+	assert.Nil(t, err, "err nil, when ClusterCurator is retrieved")
+	assert.Equal(t, "", curator.Spec.DesiredCuration, "desiredCuration is cleared")
+	assert.Equal(t, 1, len(curator.Status.Conditions), "only the curator job condition is left")
+	assert.Equal(t, CuratorJob, curator.Status.Conditions[0].Type)
+	assert.Equal(t, clustercuratorv1.CurationPhaseSucceeded, curator.Status.Phase)
+	assert.NotNil(t, curator.Status.CompletionTime, "completion time is set")
+	assert.Equal(t, "curator-job-12345", curator.Status.JobName, "job name is kept")
+	assert.Equal(t, 1, len(curator.Status.Steps), "steps are kept")
+}
+
+func TestScaleFailed(t *testing.T) {
+	defer func() {
+		if r := recover(); r == nil {
+			t.Fatal("expected recover, but failed")
+		}
+	}()
+
+	os.Args[1] = "scale-cluster"
+
+	s := scheme.Scheme
+	s.AddKnownTypes(utils.CCGVR.GroupVersion(), &clustercuratorv1.ClusterCurator{})
+
+	client := clientfake.NewClientBuilder().WithScheme(s).WithRuntimeObjects(
+		&clustercuratorv1.ClusterCurator{
+			ObjectMeta: v1.ObjectMeta{
+				Name:      ClusterName,
+				Namespace: ClusterName,
+			},
+			Spec: clustercuratorv1.ClusterCuratorSpec{
+				DesiredCuration: "scale",
+				Scale: clustercuratorv1.ScaleHooks{
+					MachinePools: []clustercuratorv1.ScaleTarget{
+						{
+							Name:     ClusterName + "-worker",
+							Replicas: 3,
+						},
+					},
+				},
+			},
+		},
+	).Build()
+
+	curatorRun(nil, client, ClusterName, ClusterName)
+}
+
+func TestRunFailureHooksPanic(t *testing.T) {
+	curator := getClusterCurator()
+	curator.Spec.Install.OnFailure = []clustercuratorv1.Hook{{Name: "Open an incident"}}
+
+	t.Log("Without a client, the onFailure hooks panic")
+	assert.NotPanics(t, func() { runFailureHooks(nil, curator, "activate-and-monitor", "Timed out") },
+		"the panic of the onFailure hooks is recovered, so the failed curation is still recorded")
 }
 
 func TestHypershiftActivate(t *testing.T) {
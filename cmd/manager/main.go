@@ -17,9 +17,13 @@ import (
 
 	"github.com/stolostron/cluster-curator-controller/controllers"
 	clusteropenclustermanagementiov1beta1 "github.com/stolostron/cluster-curator-controller/pkg/api/v1beta1"
+	curatorwebhook "github.com/stolostron/cluster-curator-controller/pkg/controller/webhook"
 	"github.com/stolostron/cluster-curator-controller/pkg/jobs/utils"
+	managedclusteractionv1beta1 "github.com/stolostron/cluster-lifecycle-api/action/v1beta1"
 	managedclusterinfov1beta1 "github.com/stolostron/cluster-lifecycle-api/clusterinfo/v1beta1"
+	managedclusterviewv1beta1 "github.com/stolostron/cluster-lifecycle-api/view/v1beta1"
 	metricsserver "sigs.k8s.io/controller-runtime/pkg/metrics/server"
+	"sigs.k8s.io/controller-runtime/pkg/webhook"
 	// +kubebuilder:scaffold:imports
 )
 
@@ -33,6 +37,8 @@ func init() {
 
 	_ = clusteropenclustermanagementiov1beta1.AddToScheme(scheme)
 	_ = managedclusterinfov1beta1.AddToScheme(scheme)
+	_ = managedclusteractionv1beta1.AddToScheme(scheme)
+	_ = managedclusterviewv1beta1.AddToScheme(scheme)
 	// +kubebuilder:scaffold:scheme
 }
 
@@ -42,6 +48,9 @@ func main() {
 	var leaderElectionLeaseDuration time.Duration
 	var leaderElectionRenewDeadline time.Duration
 	var leaderElectionRetryPeriod time.Duration
+	var enableWebhook bool
+	var webhookPort int
+	var webhookCertDir string
 	flag.StringVar(&metricsAddr, "metrics-addr", ":8080", "The address the metric endpoint binds to.")
 	flag.BoolVar(&enableLeaderElection, "enable-leader-election", false,
 		"Enable leader election for controller manager. "+
@@ -71,6 +80,12 @@ func main() {
 		"The duration the clients should wait between attempting acquisition and renewal "+
 			"of a leadership. This is only applicable if leader election is enabled.",
 	)
+	flag.BoolVar(&enableWebhook, "enable-webhook", false,
+		"Serve the ClusterCurator validating admission webhook. "+
+			"A serving certificate must be present in the webhook-cert-dir.")
+	flag.IntVar(&webhookPort, "webhook-port", 9443, "The port the validating admission webhook binds to.")
+	flag.StringVar(&webhookCertDir, "webhook-cert-dir", "/tmp/k8s-webhook-server/serving-certs",
+		"The directory containing the tls.crt and tls.key of the validating admission webhook.")
 	flag.Parse()
 
 	ctrl.SetLogger(zap.New(zap.UseDevMode(true)))
@@ -82,10 +97,13 @@ func main() {
 
 	mgr, err := ctrl.NewManager(ctrl.GetConfigOrDie(), ctrl.Options{
 		Scheme: scheme,
-		// Port:               9443,
 		Metrics: metricsserver.Options{
 			BindAddress: metricsAddr,
 		},
+		WebhookServer: webhook.NewServer(webhook.Options{
+			Port:    webhookPort,
+			CertDir: webhookCertDir,
+		}),
 		LeaderElection:   enableLeaderElection,
 		LeaderElectionID: "d362c584.cluster.open-cluster-management.io",
 		LeaseDuration:    &leaderElectionLeaseDuration,
@@ -108,17 +126,27 @@ func main() {
 		klog.Warning("IMAGE_URI=" + imageURI + ", because environment variable was not set")
 	}
 
+	// Container hooks are disabled unless the images they may run are listed
+	hookImageAllowlist := os.Getenv(utils.HookImageAllowlistEnv)
+
 	if err = (&controllers.ClusterCuratorReconciler{
-		Client:    mgr.GetClient(),
-		APIReader: mgr.GetAPIReader(),
-		Kubeset:   kubeset,
-		Log:       ctrl.Log.WithName("controllers").WithName("ClusterCurator"),
-		Scheme:    mgr.GetScheme(),
-		ImageURI:  imageURI,
+		Client:             mgr.GetClient(),
+		APIReader:          mgr.GetAPIReader(),
+		Kubeset:            kubeset,
+		Log:                ctrl.Log.WithName("controllers").WithName("ClusterCurator"),
+		Scheme:             mgr.GetScheme(),
+		ImageURI:           imageURI,
+		HookImageAllowlist: hookImageAllowlist,
 	}).SetupWithManager(mgr); err != nil {
 		setupLog.Error(err, "unable to create controller", "controller", "ClusterCurator")
 		os.Exit(1)
 	}
+	if enableWebhook {
+		if err = curatorwebhook.SetupWebhookWithManager(mgr); err != nil {
+			setupLog.Error(err, "unable to create webhook", "webhook", "ClusterCurator")
+			os.Exit(1)
+		}
+	}
 	// +kubebuilder:scaffold:builder
 
 	setupLog.Info("starting manager")
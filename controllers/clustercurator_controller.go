@@ -17,6 +17,9 @@ import (
 
 	clustercuratorv1 "github.com/stolostron/cluster-curator-controller/pkg/api/v1beta1"
 	"github.com/stolostron/cluster-curator-controller/pkg/controller/launcher"
+	"github.com/stolostron/cluster-curator-controller/pkg/jobs/ansible"
+	"github.com/stolostron/cluster-curator-controller/pkg/jobs/dryrun"
+	"github.com/stolostron/cluster-curator-controller/pkg/jobs/hive"
 	"github.com/stolostron/cluster-curator-controller/pkg/jobs/rbac"
 	"github.com/stolostron/cluster-curator-controller/pkg/jobs/utils"
 	corev1 "k8s.io/api/core/v1"
@@ -40,6 +43,8 @@ type ClusterCuratorReconciler struct {
 	Log       logr.Logger
 	Scheme    *runtime.Scheme
 	ImageURI  string
+	// HookImageAllowlist is the comma-separated list of the images Container hooks may run
+	HookImageAllowlist string
 }
 
 // +kubebuilder:rbac:groups=cluster.open-cluster-management.io.cluster.open-cluster-management.io,resources=clustercurators,verbs=get;list;watch;create;update;patch;delete
@@ -65,10 +70,26 @@ func (r *ClusterCuratorReconciler) Reconcile(ctx context.Context, req ctrl.Reque
 		return ctrl.Result{}, err
 	}
 
+	if curator.Operation != nil && curator.Operation.Cancel {
+		return ctrl.Result{}, r.cancelCuration(ctx, curator)
+	}
+
 	log.V(3).Info("Reconcile: %v, DesiredCuration: %v, Previous CuratingJob: %v",
 		req.NamespacedName, curator.Spec.DesiredCuration, curator.Spec.CuratingJob)
 
 	isPosthookOnly := curator.Operation != nil && curator.Operation.RetryPosthook != ""
+	isResume := curator.Operation != nil && curator.Operation.ResumeFailed && curator.Spec.CuratingJob == ""
+
+	// Only a failed or cancelled curation with a step that did not succeed can be resumed, drop the
+	// request otherwise
+	if isResume && ((curator.Status.Phase != clustercuratorv1.CurationPhaseFailed &&
+		curator.Status.Phase != clustercuratorv1.CurationPhaseCancelled) || utils.GetResumeStep(&curator) == "") {
+		log.V(0).Info("No failed curation to resume, removing the resumeFailed operation")
+		curator.Operation = nil
+		return ctrl.Result{}, r.Update(ctx, &curator)
+	}
+	// An operation runs part of a curation whether or not desiredCuration is still set
+	isOperation := isPosthookOnly || isResume
 
 	// Independent of desiredCuration/curatingJob state below: for Hypershift curators,
 	// make sure the shared cluster-wide curator-crb ClusterRoleBinding isn't left
@@ -85,8 +106,19 @@ func (r *ClusterCuratorReconciler) Reconcile(ctx context.Context, req ctrl.Reque
 		}
 	}
 
+	// Between curations, remove the objects of the runs expired by the cleanupPolicy. The reconcile
+	// is requeued for the next run that expires by ttlMinutes.
+	idleResult := ctrl.Result{}
+	if curator.Spec.CuratingJob == "" {
+		requeueAfter, err := utils.CleanupCurationRuns(r.APIReader, r.Client, &curator)
+		if err != nil {
+			return ctrl.Result{}, err
+		}
+		idleResult.RequeueAfter = requeueAfter
+	}
+
 	// Curating work has already started OR no curation work supplied curator.Spec.CuratingJob != "" ||
-	if (curator.Spec.CuratingJob != "" || curator.Spec.DesiredCuration == "") && !isPosthookOnly {
+	if (curator.Spec.CuratingJob != "" || curator.Spec.DesiredCuration == "") && !isOperation {
 		log.V(3).Info("No curation to do for %v", req.NamespacedName)
 		// When both fields are empty the curation cycle has completed. Remove the
 		// cluster-installer ServiceAccount and its RoleBindings so the SA cannot be
@@ -101,17 +133,17 @@ func (r *ClusterCuratorReconciler) Reconcile(ctx context.Context, req ctrl.Reque
 				}
 			}
 		}
-		return ctrl.Result{}, nil
+		return idleResult, nil
 	}
 
-	// Override upgrade if there's an operation requested
-	if curator.Spec.DesiredCuration == "upgrade" && !isPosthookOnly {
+	// Override upgrade if there's an operation requested, a dry run does not upgrade so it always runs
+	if curator.Spec.DesiredCuration == "upgrade" && !isOperation && !utils.IsDryRun(&curator) {
 		needed, err := utils.NeedToUpgrade(r.APIReader, curator)
 		if err != nil {
 			return ctrl.Result{}, err
 		}
 		if !needed {
-			return ctrl.Result{}, nil
+			return idleResult, nil
 		}
 	}
 
@@ -150,8 +182,17 @@ func (r *ClusterCuratorReconciler) Reconcile(ctx context.Context, req ctrl.Reque
 		}
 	}
 
+	// The curator Job can not read secrets, so a dry run checks them before the Job is launched
+	if utils.IsDryRun(&curator) {
+		log.V(0).Info("Dry run of the " + curator.Spec.DesiredCuration + " curation")
+		err = utils.RecordDryRunResult(r.Client, curator.Name, curator.Namespace, dryrun.CheckSecrets(r.Kubeset, &curator))
+		if err := utils.LogError(err); err != nil {
+			return ctrl.Result{}, err
+		}
+	}
+
 	// Launch the curation job
-	jobLaunch := launcher.NewLauncher(r.Client, r.Kubeset, r.ImageURI, curator)
+	jobLaunch := launcher.NewLauncher(r.Client, r.Kubeset, r.ImageURI, r.HookImageAllowlist, curator)
 	if err := utils.LogError(jobLaunch.CreateJob()); err != nil {
 		return ctrl.Result{}, err
 	}
@@ -159,6 +200,44 @@ func (r *ClusterCuratorReconciler) Reconcile(ctx context.Context, req ctrl.Reque
 	return ctrl.Result{}, nil
 }
 
+// cancelCuration stops the running curation for operation.cancel. The curator Job is deleted first
+// so it cannot create new work, then the AnsibleJob and upgrade objects it left behind are removed
+// and the curation is recorded as Cancelled. Clearing spec.curatorJob lets the next reconcile run
+// the usual RBAC cleanup.
+func (r *ClusterCuratorReconciler) cancelCuration(ctx context.Context, curator clustercuratorv1.ClusterCurator) error {
+	log := r.Log.WithValues("clustercurator", curator.Namespace+"/"+curator.Name)
+
+	if curator.Spec.CuratingJob == "" {
+		log.V(0).Info("No curation running, removing the cancel operation")
+		curator.Operation = nil
+		return r.Update(ctx, &curator)
+	}
+
+	log.V(0).Info("Cancelling curator job " + curator.Spec.CuratingJob)
+	propagation := v1.DeletePropagationBackground
+	err := r.Kubeset.BatchV1().Jobs(curator.Namespace).Delete(
+		ctx, curator.Spec.CuratingJob, v1.DeleteOptions{PropagationPolicy: &propagation})
+	if err != nil && !k8serrors.IsNotFound(err) {
+		return err
+	}
+
+	if err := ansible.DeleteAnsibleJob(r.Client, &curator); err != nil {
+		return err
+	}
+
+	if curator.Name == curator.Namespace {
+		if err := hive.DeleteUpgradeResources(r.Client, curator.Name, &curator); err != nil {
+			return err
+		}
+	}
+
+	if err := utils.RecordCancelledCuration(r.Client, curator.Name, curator.Namespace); err != nil {
+		return err
+	}
+	log.V(0).Info("Cancelled curator job " + curator.Spec.CuratingJob + " ✓")
+	return nil
+}
+
 // isHostedCluster reports whether a hypershift.openshift.io HostedCluster
 // named curator.Name exists in curator.Namespace. This is the authoritative
 // signal that the curator targets a Hypershift cluster and therefore needs
@@ -204,6 +283,14 @@ func newClusterCuratorPredicate() predicate.Predicate {
 				if newClusterCurator.Spec.DesiredCuration == DeleteNamespace {
 					return true
 				}
+				if newClusterCurator.Operation != nil && newClusterCurator.Operation.Cancel &&
+					(oldClusterCurator.Operation == nil || !oldClusterCurator.Operation.Cancel) {
+					return true
+				}
+				if newClusterCurator.Operation != nil && newClusterCurator.Operation.ResumeFailed &&
+					(oldClusterCurator.Operation == nil || !oldClusterCurator.Operation.ResumeFailed) {
+					return true
+				}
 				if (newClusterCurator.Operation != nil && oldClusterCurator.Operation != nil) && (newClusterCurator.Operation.RetryPosthook == oldClusterCurator.Operation.RetryPosthook) {
 					return false
 				}
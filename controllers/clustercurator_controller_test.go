@@ -8,7 +8,9 @@ import (
 
 	"github.com/go-logr/logr"
 	"github.com/stretchr/testify/assert"
+	batchv1 "k8s.io/api/batch/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/client-go/kubernetes/fake"
@@ -19,6 +21,9 @@ import (
 
 	clustercuratorv1 "github.com/stolostron/cluster-curator-controller/pkg/api/v1beta1"
 	"github.com/stolostron/cluster-curator-controller/pkg/jobs/rbac"
+	"github.com/stolostron/cluster-curator-controller/pkg/jobs/utils"
+	managedclusteractionv1beta1 "github.com/stolostron/cluster-lifecycle-api/action/v1beta1"
+	managedclusterviewv1beta1 "github.com/stolostron/cluster-lifecycle-api/view/v1beta1"
 )
 
 const testClusterName = "hosted-cluster-1"
@@ -56,6 +61,9 @@ func getHypershiftUpgradeDoneCurator() *clustercuratorv1.ClusterCurator {
 func newTestReconciler(t *testing.T, objs ...client.Object) (*ClusterCuratorReconciler, *fake.Clientset) {
 	s := runtime.NewScheme()
 	assert.Nil(t, clustercuratorv1.AddToScheme(s))
+	assert.Nil(t, managedclusteractionv1beta1.AddToScheme(s))
+	assert.Nil(t, managedclusterviewv1beta1.AddToScheme(s))
+	assert.Nil(t, batchv1.AddToScheme(s))
 
 	builder := clientfake.NewClientBuilder().WithScheme(s)
 	for _, o := range objs {
@@ -215,3 +223,252 @@ func TestClusterCuratorPredicateDesiredCurationClearedWithStatusIsAllowedThrough
 	assert.True(t, allowed,
 		"reconcile should run when DesiredCuration/CuratingJob clear even though Status changed in the same patch")
 }
+
+func getCancelledCurator(desiredCuration string) *clustercuratorv1.ClusterCurator {
+	return &clustercuratorv1.ClusterCurator{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "my-cluster",
+			Namespace: "my-cluster",
+		},
+		Spec: clustercuratorv1.ClusterCuratorSpec{
+			DesiredCuration: desiredCuration,
+			CuratingJob:     "curator-job-abc",
+		},
+		Operation: &clustercuratorv1.Operation{Cancel: true},
+		Status: clustercuratorv1.ClusterCuratorStatus{
+			CurationType: desiredCuration,
+			Phase:        clustercuratorv1.CurationPhaseRunning,
+			Conditions: []metav1.Condition{
+				{
+					Type:               "current-ansiblejob",
+					Status:             metav1.ConditionFalse,
+					Reason:             "Job_has_finished",
+					Message:            "prehookjob-abc",
+					LastTransitionTime: metav1.Now(),
+				},
+			},
+			Steps: []clustercuratorv1.CurationStep{
+				{Name: "prehook-ansiblejob", State: clustercuratorv1.CurationPhaseRunning},
+				{Name: "done", State: clustercuratorv1.CurationPhasePending},
+			},
+		},
+	}
+}
+
+func TestReconcileCancelCuration(t *testing.T) {
+	curator := getCancelledCurator("upgrade")
+
+	ansibleJob := &unstructured.Unstructured{}
+	ansibleJob.SetAPIVersion("tower.ansible.com/v1alpha1")
+	ansibleJob.SetKind("AnsibleJob")
+	ansibleJob.SetNamespace("my-cluster")
+	ansibleJob.SetName("prehookjob-abc")
+
+	mcv := &managedclusterviewv1beta1.ManagedClusterView{
+		ObjectMeta: metav1.ObjectMeta{Name: "my-cluster", Namespace: "my-cluster"},
+	}
+	mca := &managedclusteractionv1beta1.ManagedClusterAction{
+		ObjectMeta: metav1.ObjectMeta{Name: "my-clusteradmack", Namespace: "my-cluster"},
+	}
+	runMCV := &managedclusterviewv1beta1.ManagedClusterView{
+		ObjectMeta: metav1.ObjectMeta{Name: "my-cluster-curator-job-abc", Namespace: "my-cluster"},
+	}
+
+	r, kubeset := newTestReconciler(t, curator, ansibleJob, mcv, mca, runMCV)
+	_, err := kubeset.BatchV1().Jobs("my-cluster").Create(context.TODO(), &batchv1.Job{
+		ObjectMeta: metav1.ObjectMeta{Name: "curator-job-abc", Namespace: "my-cluster"},
+	}, metav1.CreateOptions{})
+	assert.Nil(t, err, "err nil, when the curator job is created")
+
+	_, err = r.Reconcile(context.TODO(), ctrl.Request{
+		NamespacedName: types.NamespacedName{Name: "my-cluster", Namespace: "my-cluster"},
+	})
+	assert.Nil(t, err, "err nil on reconcile")
+
+	_, err = kubeset.BatchV1().Jobs("my-cluster").Get(context.TODO(), "curator-job-abc", metav1.GetOptions{})
+	assert.NotNil(t, err, "curator job is deleted")
+
+	err = r.Get(context.TODO(), client.ObjectKeyFromObject(ansibleJob), ansibleJob)
+	assert.NotNil(t, err, "in-flight AnsibleJob is deleted")
+	err = r.Get(context.TODO(), client.ObjectKeyFromObject(mcv), mcv)
+	assert.NotNil(t, err, "ManagedClusterView is deleted")
+	err = r.Get(context.TODO(), client.ObjectKeyFromObject(mca), mca)
+	assert.NotNil(t, err, "ManagedClusterAction is deleted")
+	err = r.Get(context.TODO(), client.ObjectKeyFromObject(runMCV), runMCV)
+	assert.NotNil(t, err, "ManagedClusterView of the curation run is deleted")
+
+	cancelled := &clustercuratorv1.ClusterCurator{}
+	assert.Nil(t, r.Get(context.TODO(), client.ObjectKeyFromObject(curator), cancelled))
+	assert.Equal(t, "", cancelled.Spec.CuratingJob, "curatorJob is removed")
+	assert.Equal(t, "upgrade", cancelled.Spec.DesiredCuration, "desiredCuration is kept for an upgrade")
+	assert.Nil(t, cancelled.Operation, "operation is removed")
+	assert.Equal(t, clustercuratorv1.CurationPhaseCancelled, cancelled.Status.Phase)
+	assert.Equal(t, clustercuratorv1.CurationPhaseCancelled, cancelled.Status.Steps[0].State)
+	assert.Equal(t, clustercuratorv1.CurationPhaseCancelled, cancelled.Status.LastUpgrade.Result)
+}
+
+func TestReconcileCancelWithoutCuration(t *testing.T) {
+	curator := getCancelledCurator("")
+	curator.Spec.CuratingJob = ""
+
+	r, _ := newTestReconciler(t, curator)
+	_, err := r.Reconcile(context.TODO(), ctrl.Request{
+		NamespacedName: types.NamespacedName{Name: "my-cluster", Namespace: "my-cluster"},
+	})
+	assert.Nil(t, err, "err nil on reconcile")
+
+	updated := &clustercuratorv1.ClusterCurator{}
+	assert.Nil(t, r.Get(context.TODO(), client.ObjectKeyFromObject(curator), updated))
+	assert.Nil(t, updated.Operation, "operation is removed")
+	assert.Equal(t, clustercuratorv1.CurationPhaseRunning, updated.Status.Phase, "status is not changed")
+}
+
+func TestReconcileCleanupPolicy(t *testing.T) {
+	retainRuns := 0
+	curator := &clustercuratorv1.ClusterCurator{
+		ObjectMeta: metav1.ObjectMeta{Name: "my-cluster", Namespace: "my-cluster", UID: "curator-uid"},
+		Spec: clustercuratorv1.ClusterCuratorSpec{
+			CleanupPolicy: &clustercuratorv1.CleanupPolicy{RetainRuns: &retainRuns},
+		},
+	}
+	mcv := &managedclusterviewv1beta1.ManagedClusterView{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "my-cluster-curator-job-abc",
+			Namespace: "my-cluster",
+			Labels:    map[string]string{utils.CurationRunLabel: "curator-job-abc"},
+			OwnerReferences: []metav1.OwnerReference{{
+				APIVersion: "cluster.open-cluster-management.io/v1beta1",
+				Kind:       "ClusterCurator",
+				Name:       "my-cluster",
+				UID:        "curator-uid",
+			}},
+		},
+	}
+
+	r, _ := newTestReconciler(t, curator, mcv)
+	_, err := r.Reconcile(context.TODO(), ctrl.Request{
+		NamespacedName: types.NamespacedName{Name: "my-cluster", Namespace: "my-cluster"},
+	})
+	assert.Nil(t, err, "err nil on reconcile")
+
+	err = r.Get(context.TODO(), client.ObjectKeyFromObject(mcv), mcv)
+	assert.NotNil(t, err, "the ManagedClusterView of the earlier run is removed")
+}
+
+// TestClusterCuratorPredicateCancelIsAllowedThrough verifies a cancel is not dropped by the
+// retryPosthook check when the curator already has an operation.
+func TestClusterCuratorPredicateCancelIsAllowedThrough(t *testing.T) {
+	pred := newClusterCuratorPredicate()
+
+	oldCurator := getHypershiftUpgradeDoneCurator()
+	oldCurator.Operation = &clustercuratorv1.Operation{RetryPosthook: "upgradePosthook"}
+	newCurator := oldCurator.DeepCopy()
+	newCurator.Operation.Cancel = true
+
+	allowed := pred.Update(event.UpdateEvent{ObjectOld: oldCurator, ObjectNew: newCurator})
+	assert.True(t, allowed, "reconcile should run when operation.cancel is set")
+}
+
+func TestReconcileResumeFailedWithoutFailure(t *testing.T) {
+	curator := getHypershiftUpgradeDoneCurator()
+	curator.Operation = &clustercuratorv1.Operation{ResumeFailed: true}
+	curator.Status.Phase = clustercuratorv1.CurationPhaseSucceeded
+
+	r, kubeset := newTestReconciler(t, curator)
+	_, err := r.Reconcile(context.TODO(), ctrl.Request{
+		NamespacedName: types.NamespacedName{Name: testClusterName, Namespace: testCuratorNamespace},
+	})
+	assert.Nil(t, err, "err nil on reconcile")
+
+	updated := &clustercuratorv1.ClusterCurator{}
+	assert.Nil(t, r.Get(context.TODO(), client.ObjectKeyFromObject(curator), updated))
+	assert.Nil(t, updated.Operation, "operation is removed")
+
+	jobs, err := kubeset.BatchV1().Jobs(testCuratorNamespace).List(context.TODO(), metav1.ListOptions{})
+	assert.Nil(t, err, "err nil, when jobs are listed")
+	assert.Equal(t, 0, len(jobs.Items), "no curator job is created")
+}
+
+func TestReconcileResumeFailedWithoutFailedStep(t *testing.T) {
+	curator := getCancelledCurator("")
+	curator.Spec.CuratingJob = ""
+	curator.Operation = &clustercuratorv1.Operation{ResumeFailed: true}
+	curator.Status.CurationType = "install"
+	curator.Status.Phase = clustercuratorv1.CurationPhaseFailed
+	curator.Status.Steps = []clustercuratorv1.CurationStep{
+		{Name: "activate-and-monitor", State: clustercuratorv1.CurationPhaseSucceeded},
+		{Name: "monitor-import", State: clustercuratorv1.CurationPhaseSucceeded},
+	}
+
+	r, kubeset := newTestReconciler(t, curator)
+	_, err := r.Reconcile(context.TODO(), ctrl.Request{
+		NamespacedName: types.NamespacedName{Name: "my-cluster", Namespace: "my-cluster"},
+	})
+	assert.Nil(t, err, "err nil on reconcile")
+
+	updated := &clustercuratorv1.ClusterCurator{}
+	assert.Nil(t, r.Get(context.TODO(), client.ObjectKeyFromObject(curator), updated))
+	assert.Nil(t, updated.Operation, "operation is removed")
+
+	jobs, err := kubeset.BatchV1().Jobs("my-cluster").List(context.TODO(), metav1.ListOptions{})
+	assert.Nil(t, err, "err nil, when jobs are listed")
+	assert.Equal(t, 0, len(jobs.Items), "no curator job is created when no step failed")
+}
+
+func TestReconcileResumeFailedInstall(t *testing.T) {
+	curator := getCancelledCurator("")
+	curator.Spec.CuratingJob = ""
+	curator.Operation = &clustercuratorv1.Operation{ResumeFailed: true}
+	curator.Status.CurationType = "install"
+	curator.Status.Phase = clustercuratorv1.CurationPhaseFailed
+	curator.Status.Steps = []clustercuratorv1.CurationStep{
+		{Name: "activate-and-monitor", State: clustercuratorv1.CurationPhaseSucceeded},
+		{Name: "monitor-import", State: clustercuratorv1.CurationPhaseFailed},
+	}
+
+	r, kubeset := newTestReconciler(t, curator)
+	_, err := r.Reconcile(context.TODO(), ctrl.Request{
+		NamespacedName: types.NamespacedName{Name: "my-cluster", Namespace: "my-cluster"},
+	})
+	assert.Nil(t, err, "err nil on reconcile")
+
+	jobs, err := kubeset.BatchV1().Jobs("my-cluster").List(context.TODO(), metav1.ListOptions{})
+	assert.Nil(t, err, "err nil, when jobs are listed")
+	assert.Equal(t, 1, len(jobs.Items), "a curator job is created even though desiredCuration is empty")
+	assert.Equal(t, "monitor-import", jobs.Items[0].Spec.Template.Spec.InitContainers[0].Name)
+}
+
+func TestClusterCuratorPredicateResumeFailedIsAllowedThrough(t *testing.T) {
+	pred := newClusterCuratorPredicate()
+
+	oldCurator := getHypershiftUpgradeDoneCurator()
+	oldCurator.Operation = &clustercuratorv1.Operation{}
+	newCurator := oldCurator.DeepCopy()
+	newCurator.Operation.ResumeFailed = true
+
+	allowed := pred.Update(event.UpdateEvent{ObjectOld: oldCurator, ObjectNew: newCurator})
+	assert.True(t, allowed, "reconcile should run when operation.resumeFailed is set")
+}
+
+func TestReconcileDryRunUpgrade(t *testing.T) {
+	curator := getHypershiftUpgradeDoneCurator()
+	curator.Spec.DryRun = true
+	curator.Spec.ProviderCredentialPath = testCuratorNamespace + "/provider-secret"
+
+	r, kubeset := newTestReconciler(t, curator)
+	_, err := r.Reconcile(context.TODO(), ctrl.Request{
+		NamespacedName: types.NamespacedName{Name: testClusterName, Namespace: testCuratorNamespace},
+	})
+	assert.Nil(t, err, "err nil on reconcile")
+
+	jobs, err := kubeset.BatchV1().Jobs(testCuratorNamespace).List(context.TODO(), metav1.ListOptions{})
+	assert.Nil(t, err, "err nil, when jobs are listed")
+	assert.Equal(t, 1, len(jobs.Items), "a dry run is launched even though the upgrade is done")
+	assert.Equal(t, "dry-run", jobs.Items[0].Spec.Template.Spec.InitContainers[0].Name)
+
+	updated := &clustercuratorv1.ClusterCurator{}
+	assert.Nil(t, r.Get(context.TODO(), client.ObjectKeyFromObject(curator), updated))
+	assert.NotNil(t, updated.Status.DryRun, "the secret checks are recorded")
+	assert.Equal(t, "providerCredential", updated.Status.DryRun.Checks[0].Name)
+	assert.False(t, updated.Status.DryRun.Checks[0].Passed, "the provider credential does not exist")
+}
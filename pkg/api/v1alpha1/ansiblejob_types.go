@@ -30,10 +30,18 @@ import (
 type AnsibleJobSpec struct {
 	// INSERT ADDITIONAL SPEC FIELDS - desired state of cluster
 	// Important: Run "make" to regenerate code after modifying this file
-	TowerAuthSecretName string          `json:"tower_auth_secret,omitempty"`
-	JobTemplateName     string          `json:"job_template_name,omitempty"`
-	Inventory           string          `json:"inventory,omitempty"`
-	ExtraVars           json.RawMessage `json:"extra_vars,omitempty"`
+	TowerAuthSecretName  string          `json:"tower_auth_secret,omitempty"`
+	JobTemplateName      string          `json:"job_template_name,omitempty"`
+	WorkflowTemplateName string          `json:"workflow_template_name,omitempty"`
+	Inventory            string          `json:"inventory,omitempty"`
+	ExtraVars            json.RawMessage `json:"extra_vars,omitempty"`
+	JobTags              string          `json:"job_tags,omitempty"`
+	SkipTags             string          `json:"skip_tags,omitempty"`
+	Limit                string          `json:"limit,omitempty"`
+	Verbosity            *int            `json:"verbosity,omitempty"`
+	JobTTL               int             `json:"job_ttl,omitempty"`
+	RunnerImage          string          `json:"runner_image,omitempty"`
+	RunnerVersion        string          `json:"runner_version,omitempty"`
 }
 
 type AnsibleJobResult struct {
@@ -107,6 +107,11 @@ func (in *AnsibleJobSpec) DeepCopyInto(out *AnsibleJobSpec) {
 		*out = make(json.RawMessage, len(*in))
 		copy(*out, *in)
 	}
+	if in.Verbosity != nil {
+		in, out := &in.Verbosity, &out.Verbosity
+		*out = new(int)
+		**out = **in
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AnsibleJobSpec.
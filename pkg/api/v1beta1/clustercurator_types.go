@@ -24,8 +24,9 @@ type ClusterCuratorSpec struct {
 	// An install curation runs these prehooks and posthooks.
 	Install Hooks `json:"install,omitempty"`
 
-	// A scale curation run these prehooks and posthooks.
-	Scale Hooks `json:"scale,omitempty"`
+	// A scale curation sets the replica counts of the listed MachinePools or NodePools
+	// and runs these prehooks and posthooks.
+	Scale ScaleHooks `json:"scale,omitempty"`
 
 	// A destroy curation runs these hooks.
 	// Standalone clusters only support the prehook.
@@ -42,17 +43,57 @@ type ClusterCuratorSpec struct {
 	// Kubernetes job resource created for curation of a cluster.
 	CuratingJob string `json:"curatorJob,omitempty"`
 
-	// Inventory values are supplied for use with the pre/post jobs.
+	// Inventory values are supplied for use with the pre/post jobs. A hook can override it with its
+	// own inventory.
 	Inventory string `json:"inventory,omitempty"`
+
+	// When true, the desired curation is only validated. The curator Job checks the secrets, the
+	// hooks and, for an upgrade, the upgrade version without changing the cluster, and records the
+	// findings in status.dryRun.
+	// +optional
+	DryRun bool `json:"dryRun,omitempty"`
+
+	// When true, a hook fails when a template in its extra_vars refers to a missing key. By default,
+	// a missing key renders as an empty string.
+	// +optional
+	StrictTemplates bool `json:"strictTemplates,omitempty"`
+
+	// When true, the last lines of the runner pod logs of a failed AnsibleJob are recorded in
+	// status.lastFailure. The logs can hold the extra_vars of the hook, including values read from
+	// Secrets, so they are left out by default.
+	// +optional
+	CaptureFailureLogs bool `json:"captureFailureLogs,omitempty"`
+
+	// CleanupPolicy defines when the controller removes the objects of earlier curation runs.
+	// +optional
+	CleanupPolicy *CleanupPolicy `json:"cleanupPolicy,omitempty"`
+}
+
+// CleanupPolicy defines when the AnsibleJobs, hook Jobs, ManagedClusterViews and
+// ManagedClusterActions of earlier curation runs are removed. A curation run is identified by the
+// curation-run label of its objects, the objects of the running curation are never removed.
+type CleanupPolicy struct {
+	// RetainRuns is the number of the most recent curation runs whose objects are kept.
+	// +optional
+	// +kubebuilder:validation:Minimum=0
+	RetainRuns *int `json:"retainRuns,omitempty"`
+
+	// TTLMinutes removes the objects of a curation run once the newest of them is older.
+	// +optional
+	// +kubebuilder:validation:Minimum=1
+	TTLMinutes int `json:"ttlMinutes,omitempty"`
 }
 
 type Hook struct {
-	// Name of the Ansible Template to run in the Ansible Tower as a job.
+	// Name of the Ansible Template to run in the Ansible Tower as a job. For a Container or Webhook
+	// hook, it names the hook.
 	// +kubebuilder:validation:Required
 	Name string `json:"name"`
 
 	// Type of the Hook. For Job type, Ansible job template is used.
 	// For Workflow type, Ansible workflow template is used.
+	// For Container type, a Kubernetes Job runs the image in the cluster namespace.
+	// For Webhook type, a JSON payload is posted to the URL of the webhookSecret.
 	// If omitted, it defaults to the Job type.
 	// +optional
 	// +kubebuilder:default=Job
@@ -63,6 +104,44 @@ type Hook struct {
 	// +kubebuilder:pruning:PreserveUnknownFields
 	ExtraVars *runtime.RawExtension `json:"extra_vars,omitempty"`
 
+	// ExtraVarsFrom adds the keys of ConfigMaps and Secrets in the ClusterCurator namespace to the
+	// extra_vars. A later entry overrides an earlier one, and extra_vars override them all.
+	// +optional
+	ExtraVarsFrom []ExtraVarsSource `json:"extraVarsFrom,omitempty"`
+
+	// Outputs lists the keys of the artifacts of the finished AnsibleJob, such as the set_stats data
+	// of the playbook, that are recorded in the status of the step. The later hooks of the curation
+	// receive them in extra_vars as hook_outputs.
+	// +optional
+	Outputs []string `json:"outputs,omitempty"`
+
+	// TowerAuthSecret overrides the towerAuthSecret of the hooks block for this hook, so that its
+	// AnsibleJob runs in another Ansible Tower.
+	// +optional
+	TowerAuthSecret string `json:"towerAuthSecret,omitempty"`
+
+	// Inventory overrides the inventory of the ClusterCurator for the AnsibleJob of this hook.
+	// +optional
+	Inventory string `json:"inventory,omitempty"`
+
+	// Image run by a Container hook. It must match an entry of the HOOK_IMAGE_ALLOWLIST of the
+	// controller.
+	// +optional
+	Image string `json:"image,omitempty"`
+
+	// Command of the Container hook, the entrypoint of the image is used when it is not set.
+	// +optional
+	Command []string `json:"command,omitempty"`
+
+	// Args of the Container hook.
+	// +optional
+	Args []string `json:"args,omitempty"`
+
+	// WebhookSecret is the name of the Secret in the cluster namespace of a Webhook hook. Its url key
+	// is the URL the payload is posted to, and its optional hmacKey key signs the payload.
+	// +optional
+	WebhookSecret string `json:"webhookSecret,omitempty"`
+
 	// A comma-separated list of tags to specify which sets
 	// of Ansible tasks in a job should be run.
 	// +optional
@@ -72,11 +151,91 @@ type Hook struct {
 	// of Ansible tasks in a job should not be run.
 	// +optional
 	SkipTags string `json:"skip_tags,omitempty"`
+
+	// Limit restricts the hosts of the inventory the Ansible job runs on. It is not used by a
+	// Workflow hook.
+	// +optional
+	Limit string `json:"limit,omitempty"`
+
+	// Verbosity of the Ansible job, from 0 (normal) to 5 (WinRM debug). It is not used by a Workflow
+	// hook. When it is not set, the verbosity of the job template is used.
+	// +optional
+	// +kubebuilder:validation:Minimum=0
+	// +kubebuilder:validation:Maximum=5
+	Verbosity *int `json:"verbosity,omitempty"`
+
+	// JobTTL defines how long the Kubernetes Job of the AnsibleJob is kept after it finished, in
+	// seconds.
+	// +optional
+	// +kubebuilder:validation:Minimum=0
+	JobTTL int `json:"job_ttl,omitempty"`
+
+	// RunnerImage is the image the AnsibleJob uses to launch the job in the Ansible Tower. It must
+	// match an entry of the HOOK_IMAGE_ALLOWLIST of the controller.
+	// +optional
+	RunnerImage string `json:"runner_image,omitempty"`
+
+	// RunnerVersion is the tag of the runner image.
+	// +optional
+	RunnerVersion string `json:"runner_version,omitempty"`
+
+	// TimeoutMinutes defines how long the curator Job waits for the AnsibleJob to finish, in minutes.
+	// An AnsibleJob that does not finish in time is deleted and counted as a failed attempt.
+	// If its value is less than or equal to zero, the curator Job waits until the AnsibleJob finishes.
+	// +optional
+	// +kubebuilder:validation:Minimum=0
+	TimeoutMinutes int `json:"timeoutMinutes,omitempty"`
+
+	// Retries is the number of times a failed or timed out AnsibleJob is created again before the
+	// hook fails. By default, the hook is not retried.
+	// +optional
+	// +kubebuilder:validation:Minimum=0
+	Retries int `json:"retries,omitempty"`
+
+	// RetryBackoff defines the time in seconds to wait before the AnsibleJob is created again. It
+	// is doubled after each retry. By default, it is 30 seconds.
+	// If its value is less than or equal to zero, the default is used.
+	// +optional
+	// +kubebuilder:validation:Minimum=0
+	RetryBackoff int `json:"retryBackoff,omitempty"`
+
+	// ContinueOnError lets the curation go on when the hook fails. The failure is recorded as a
+	// warning on the step, and the remaining hooks and steps still run.
+	// +optional
+	ContinueOnError bool `json:"continueOnError,omitempty"`
+
+	// ParallelGroup runs the hook at the same time as the hooks next to it in the list that have
+	// the same group name. The next hook outside of the group starts once the whole group finished.
+	// +optional
+	ParallelGroup string `json:"parallelGroup,omitempty"`
+}
+
+// ExtraVarsSource references a ConfigMap or a Secret whose keys are added to the extra_vars of a
+// hook. A value holding a JSON object or array is added decoded, any other value as a string.
+type ExtraVarsSource struct {
+	// ConfigMapRef names a ConfigMap in the ClusterCurator namespace.
+	// +optional
+	ConfigMapRef *ExtraVarsReference `json:"configMapRef,omitempty"`
+
+	// SecretRef names a Secret in the ClusterCurator namespace.
+	// +optional
+	SecretRef *ExtraVarsReference `json:"secretRef,omitempty"`
+}
+
+type ExtraVarsReference struct {
+	// Name of the ConfigMap or Secret.
+	// +kubebuilder:validation:Required
+	Name string `json:"name"`
+
+	// When true, the hook runs without these extra_vars when the ConfigMap or Secret does not exist.
+	// +optional
+	Optional bool `json:"optional,omitempty"`
 }
 
 type Hooks struct {
 
 	// TowerAuthSecret is an Ansible secret used in the template to provide authentication to an Ansbile tower.
+	// A hook can override it with its own towerAuthSecret.
 	// +kubebuilder:validation:Required
 	TowerAuthSecret string `json:"towerAuthSecret,omitempty"`
 
@@ -86,6 +245,29 @@ type Hooks struct {
 	// Jobs to run after the cluster import.
 	Posthook []Hook `json:"posthook,omitempty"`
 
+	// Jobs to run when a step of the curation fails. The failed step and its error message are
+	// passed in extra_vars as failed_step and failure_message. A hook without timeoutMinutes
+	// times out after 30 minutes.
+	// +optional
+	OnFailure []Hook `json:"onFailure,omitempty"`
+
+	// ParallelFailurePolicy defines what happens when a hook of a parallel group fails. With
+	// FailFast, the step fails without waiting for the rest of the group. With WaitForAll, the rest
+	// of the group finishes first. By default, it is FailFast.
+	// +optional
+	ParallelFailurePolicy ParallelFailurePolicy `json:"parallelFailurePolicy,omitempty"`
+
+	// When true, the curator Job waits before the prehooks until the curation is approved with
+	// operation.approve or the cluster.open-cluster-management.io/curation-approved annotation.
+	// +optional
+	ApprovalRequired bool `json:"approvalRequired,omitempty"`
+
+	// ApprovalTimeout defines how long the curator Job waits for the approval, in minutes.
+	// By default, it is 60 minutes.
+	// If its value is less than or equal to zero, the default is used.
+	// +optional
+	ApprovalTimeout int `json:"approvalTimeout,omitempty"`
+
 	// When provided, this is a Job specification and overrides the default flow.
 	// +kubebuilder:pruning:PreserveUnknownFields
 	OverrideJob *runtime.RawExtension `json:"overrideJob,omitempty"`
@@ -102,6 +284,7 @@ type Hooks struct {
 type UpgradeHooks struct {
 
 	// TowerAuthSecret is an Ansible secret used in the template to provide authentication to an Ansbile tower.
+	// A hook can override it with its own towerAuthSecret.
 	// +kubebuilder:validation:Required
 	TowerAuthSecret string `json:"towerAuthSecret,omitempty"`
 
@@ -152,6 +335,29 @@ type UpgradeHooks struct {
 	// Jobs to run after the cluster upgrade.
 	Posthook []Hook `json:"posthook,omitempty"`
 
+	// Jobs to run when a step of the upgrade fails. The failed step and its error message are
+	// passed in extra_vars as failed_step and failure_message. A hook without timeoutMinutes
+	// times out after 30 minutes.
+	// +optional
+	OnFailure []Hook `json:"onFailure,omitempty"`
+
+	// ParallelFailurePolicy defines what happens when a hook of a parallel group fails. With
+	// FailFast, the step fails without waiting for the rest of the group. With WaitForAll, the rest
+	// of the group finishes first. By default, it is FailFast.
+	// +optional
+	ParallelFailurePolicy ParallelFailurePolicy `json:"parallelFailurePolicy,omitempty"`
+
+	// When true, the curator Job waits before the prehooks until the upgrade is approved with
+	// operation.approve or the cluster.open-cluster-management.io/curation-approved annotation.
+	// +optional
+	ApprovalRequired bool `json:"approvalRequired,omitempty"`
+
+	// ApprovalTimeout defines how long the curator Job waits for the approval, in minutes.
+	// By default, it is 60 minutes.
+	// If its value is less than or equal to zero, the default is used.
+	// +optional
+	ApprovalTimeout int `json:"approvalTimeout,omitempty"`
+
 	// When provided, this is a Job specification and overrides the default flow.
 	// +kubebuilder:pruning:PreserveUnknownFields
 	OverrideJob *runtime.RawExtension `json:"overrideJob,omitempty"`
@@ -164,15 +370,333 @@ type UpgradeHooks struct {
 	MonitorTimeout int `json:"monitorTimeout,omitempty"`
 }
 
+type ScaleHooks struct {
+
+	// TowerAuthSecret is an Ansible secret used in the template to provide authentication to an Ansbile tower.
+	// A hook can override it with its own towerAuthSecret.
+	// +kubebuilder:validation:Required
+	TowerAuthSecret string `json:"towerAuthSecret,omitempty"`
+
+	// MachinePools lists the Hive MachinePools to scale for a standalone cluster.
+	// The name is the MachinePool resource name in the cluster namespace, for example "my-cluster-worker".
+	// This field is ignored for hosted clusters.
+	// +optional
+	MachinePools []ScaleTarget `json:"machinePools,omitempty"`
+
+	// NodePools lists the NodePools to scale for a hosted cluster.
+	// Each NodePool must belong to the HostedCluster being curated.
+	// This field is ignored for standalone clusters.
+	// +optional
+	NodePools []ScaleTarget `json:"nodePools,omitempty"`
+
+	// Jobs to run before the cluster is scaled.
+	Prehook []Hook `json:"prehook,omitempty"`
+
+	// Jobs to run after the cluster is scaled.
+	Posthook []Hook `json:"posthook,omitempty"`
+
+	// Jobs to run when a step of the scale fails. The failed step and its error message are
+	// passed in extra_vars as failed_step and failure_message. A hook without timeoutMinutes
+	// times out after 30 minutes.
+	// +optional
+	OnFailure []Hook `json:"onFailure,omitempty"`
+
+	// ParallelFailurePolicy defines what happens when a hook of a parallel group fails. With
+	// FailFast, the step fails without waiting for the rest of the group. With WaitForAll, the rest
+	// of the group finishes first. By default, it is FailFast.
+	// +optional
+	ParallelFailurePolicy ParallelFailurePolicy `json:"parallelFailurePolicy,omitempty"`
+
+	// When true, the curator Job waits before the prehooks until the scale is approved with
+	// operation.approve or the cluster.open-cluster-management.io/curation-approved annotation.
+	// +optional
+	ApprovalRequired bool `json:"approvalRequired,omitempty"`
+
+	// ApprovalTimeout defines how long the curator Job waits for the approval, in minutes.
+	// By default, it is 60 minutes.
+	// If its value is less than or equal to zero, the default is used.
+	// +optional
+	ApprovalTimeout int `json:"approvalTimeout,omitempty"`
+
+	// When provided, this is a Job specification and overrides the default flow.
+	// +kubebuilder:pruning:PreserveUnknownFields
+	OverrideJob *runtime.RawExtension `json:"overrideJob,omitempty"`
+
+	// JobMonitorTimeout defines the timeout for finding a job and defines time in minutes.
+	// If the job is found, the curator controller waits until the job becomes active.
+	// By default, it is 5 minutes.
+	// If its value is less than or equal to zero, the default is used.
+	// +optional
+	// +kubebuilder:default=5
+	JobMonitorTimeout int `json:"jobMonitorTimeout,omitempty"`
+
+	// MonitorTimeout defines the monitor process timeout, and defines time in minutes.
+	// By default, it is 30 minutes.
+	// If its value is less than or equal to zero, the default value is used.
+	// +optional
+	// +kubebuilder:default=30
+	MonitorTimeout int `json:"monitorTimeout,omitempty"`
+}
+
+// ScaleTarget sets the replica count of a single MachinePool or NodePool.
+type ScaleTarget struct {
+	// Name of the MachinePool or NodePool.
+	// +kubebuilder:validation:Required
+	Name string `json:"name"`
+
+	// Replicas is the desired number of worker nodes in the pool.
+	// +kubebuilder:validation:Minimum=0
+	Replicas int32 `json:"replicas"`
+}
+
 // ClusterCuratorStatus defines the observed state of ClusterCurator work.
 type ClusterCuratorStatus struct {
 	// Track the conditions for each step in the desired curation that is being
 	// executed as a job.
 	Conditions []metav1.Condition `json:"conditions,omitempty"`
+
+	// Phase of the most recent curation job.
+	// +optional
+	Phase CurationPhase `json:"phase,omitempty"`
+
+	// The curation run by the most recent job, such as 'install', 'upgrade' or 'destroy'. A retried
+	// posthook is recorded as 'installPosthook' or 'upgradePosthook'.
+	// +optional
+	CurationType string `json:"curationType,omitempty"`
+
+	// Name of the curator Job running, or that last ran, the curation.
+	// +optional
+	JobName string `json:"jobName,omitempty"`
+
+	// Time the curator Job was created.
+	// +optional
+	StartTime *metav1.Time `json:"startTime,omitempty"`
+
+	// Time the curation succeeded or failed.
+	// +optional
+	CompletionTime *metav1.Time `json:"completionTime,omitempty"`
+
+	// The steps of the curation, in the order the curator Job runs them.
+	// +optional
+	Steps []CurationStep `json:"steps,omitempty"`
+
+	// The upgrade settings and result of the most recent upgrade curation.
+	// +optional
+	LastUpgrade *UpgradeRecord `json:"lastUpgrade,omitempty"`
+
+	// The findings of the most recent dry run.
+	// +optional
+	DryRun *DryRunResult `json:"dryRun,omitempty"`
+
+	// The details of the most recent failed AnsibleJob, as found on the hub.
+	// +optional
+	LastFailure *AnsibleJobFailure `json:"lastFailure,omitempty"`
+}
+
+// AnsibleJobFailure records why an AnsibleJob of a hook failed.
+type AnsibleJobFailure struct {
+	// Name of the step that ran the hook.
+	// +optional
+	Step string `json:"step,omitempty"`
+
+	// Name of the Ansible template of the hook.
+	// +optional
+	Hook string `json:"hook,omitempty"`
+
+	// Attempt number of the hook, the first attempt is 1.
+	// +optional
+	Attempt int `json:"attempt,omitempty"`
+
+	// Name of the AnsibleJob that failed.
+	// +optional
+	AnsibleJob string `json:"ansibleJob,omitempty"`
+
+	// Namespaced name of the Kubernetes Job the AnsibleJob ran the Tower job from.
+	// +optional
+	K8sJob string `json:"k8sJob,omitempty"`
+
+	// URL of the job in the Ansible Tower.
+	// +optional
+	URL string `json:"url,omitempty"`
+
+	// Why the AnsibleJob failed.
+	// +optional
+	Message string `json:"message,omitempty"`
+
+	// The number of ok tasks reported in the ansibleResult of the AnsibleJob.
+	// +optional
+	Ok int64 `json:"ok,omitempty"`
+
+	// The number of changed tasks reported in the ansibleResult of the AnsibleJob.
+	// +optional
+	Changed int64 `json:"changed,omitempty"`
+
+	// The number of failed tasks reported in the ansibleResult of the AnsibleJob.
+	// +optional
+	Failures int64 `json:"failures,omitempty"`
+
+	// The last lines of the logs of the runner pod of the Kubernetes Job, when spec.captureFailureLogs
+	// is true.
+	// +optional
+	Logs string `json:"logs,omitempty"`
+
+	// Time the failure was recorded.
+	// +optional
+	Timestamp *metav1.Time `json:"timestamp,omitempty"`
+}
+
+// DryRunResult records the findings of a dry run.
+type DryRunResult struct {
+	// The curation that was validated.
+	// +optional
+	Curation string `json:"curation,omitempty"`
+
+	// The release image an upgrade would use. It is empty when the managed cluster resolves the
+	// image from the version.
+	// +optional
+	ReleaseImage string `json:"releaseImage,omitempty"`
+
+	// The checks that were run.
+	// +optional
+	Checks []DryRunCheck `json:"checks,omitempty"`
+
+	// Time the dry run finished.
+	// +optional
+	Timestamp *metav1.Time `json:"timestamp,omitempty"`
+}
+
+// DryRunCheck is the result of a single dry-run check.
+type DryRunCheck struct {
+	// Name of the check, such as 'providerCredential', 'towerAuthSecret', 'hooks' or 'upgradeVersion'.
+	Name string `json:"name"`
+
+	// Whether the check passed.
+	Passed bool `json:"passed"`
+
+	// What was found.
+	// +optional
+	Message string `json:"message,omitempty"`
+}
+
+// UpgradeRecord records the upgrade settings a finished upgrade curation ran with.
+type UpgradeRecord struct {
+	// The desiredUpdate of the upgrade.
+	// +optional
+	DesiredUpdate string `json:"desiredUpdate,omitempty"`
+
+	// The channel of the upgrade.
+	// +optional
+	Channel string `json:"channel,omitempty"`
+
+	// The upstream of the upgrade.
+	// +optional
+	Upstream string `json:"upstream,omitempty"`
+
+	// The upgradeType of the upgrade, for HostedClusters.
+	// +optional
+	UpgradeType UpgradeType `json:"upgradeType,omitempty"`
+
+	// The nodePoolNames of the upgrade, for HostedClusters.
+	// +optional
+	NodePoolNames []string `json:"nodePoolNames,omitempty"`
+
+	// Result of the upgrade, 'Succeeded', 'Failed' or 'Cancelled'.
+	// +optional
+	Result CurationPhase `json:"result,omitempty"`
+
+	// Time the upgrade finished.
+	// +optional
+	Timestamp *metav1.Time `json:"timestamp,omitempty"`
+}
+
+// CurationStep records the progress of a single step of the curator Job.
+type CurationStep struct {
+	// Name of the step, this matches the curator subcommand and its condition type.
+	Name string `json:"name"`
+
+	// State of the step.
+	// +optional
+	State CurationPhase `json:"state,omitempty"`
+
+	// Time the step started running.
+	// +optional
+	StartTime *metav1.Time `json:"startTime,omitempty"`
+
+	// Time the step succeeded or failed.
+	// +optional
+	CompletionTime *metav1.Time `json:"completionTime,omitempty"`
+
+	// Why the step failed.
+	// +optional
+	FailureReason string `json:"failureReason,omitempty"`
+
+	// The attempts of the Ansible hooks run by the step.
+	// +optional
+	HookAttempts []HookAttempt `json:"hookAttempts,omitempty"`
+
+	// Failures of the hooks with continueOnError set, these did not fail the step.
+	// +optional
+	Warnings []string `json:"warnings,omitempty"`
+
+	// The outputs recorded by the hooks of the step, a later output overrides an earlier one with
+	// the same key.
+	// +optional
+	// +kubebuilder:pruning:PreserveUnknownFields
+	Outputs *runtime.RawExtension `json:"outputs,omitempty"`
+}
+
+// HookAttempt records one AnsibleJob created for an Ansible hook.
+type HookAttempt struct {
+	// Name of the Ansible template of the hook.
+	Hook string `json:"hook"`
+
+	// Attempt number, the first attempt is 1.
+	Attempt int `json:"attempt"`
+
+	// Name of the AnsibleJob created for the attempt.
+	// +optional
+	AnsibleJob string `json:"ansibleJob,omitempty"`
+
+	// Name of the Kubernetes Job created for the attempt of a Container hook.
+	// +optional
+	Job string `json:"job,omitempty"`
+
+	// URL of the job in the Ansible Tower, or the status URL returned by a Webhook hook.
+	// +optional
+	URL string `json:"url,omitempty"`
+
+	// Result of the attempt.
+	// +optional
+	Result CurationPhase `json:"result,omitempty"`
+
+	// Why the attempt failed.
+	// +optional
+	Message string `json:"message,omitempty"`
 }
 
-// HookType indicates the type for the hook. It can be 'Job' or 'Workflow'
-// +kubebuilder:validation:Enum=Job;Workflow
+// CurationPhase is the state of a curation or of one of its steps.
+// +kubebuilder:validation:Enum=Pending;Running;Succeeded;Failed;Cancelled
+type CurationPhase string
+
+const (
+	// CurationPhasePending, the curator Job was created but has not started yet
+	CurationPhasePending CurationPhase = "Pending"
+
+	// CurationPhaseRunning, the curator Job is running
+	CurationPhaseRunning CurationPhase = "Running"
+
+	// CurationPhaseSucceeded, the curation completed
+	CurationPhaseSucceeded CurationPhase = "Succeeded"
+
+	// CurationPhaseFailed, the curation failed
+	CurationPhaseFailed CurationPhase = "Failed"
+
+	// CurationPhaseCancelled, the curation was cancelled with operation.cancel
+	CurationPhaseCancelled CurationPhase = "Cancelled"
+)
+
+// HookType indicates the type for the hook. It can be 'Job', 'Workflow', 'Container' or 'Webhook'
+// +kubebuilder:validation:Enum=Job;Workflow;Container;Webhook
 type HookType string
 
 const (
@@ -181,6 +705,25 @@ const (
 
 	// HookTypeWorkflow, the hook is an Ansible Workflow template
 	HookTypeWorkflow HookType = "Workflow"
+
+	// HookTypeContainer, the hook is a Kubernetes Job running an allowlisted image
+	HookTypeContainer HookType = "Container"
+
+	// HookTypeWebhook, the hook is an HTTP POST to the URL of a Secret
+	HookTypeWebhook HookType = "Webhook"
+)
+
+// ParallelFailurePolicy indicates how a parallel group of hooks handles a failed hook. It can be
+// 'FailFast' or 'WaitForAll'
+// +kubebuilder:validation:Enum=FailFast;WaitForAll
+type ParallelFailurePolicy string
+
+const (
+	// ParallelFailurePolicyFailFast, the step fails as soon as a hook of the group fails
+	ParallelFailurePolicyFailFast ParallelFailurePolicy = "FailFast"
+
+	// ParallelFailurePolicyWaitForAll, the step fails once every hook of the group finished
+	ParallelFailurePolicyWaitForAll ParallelFailurePolicy = "WaitForAll"
 )
 
 // UpgradeType indicates which components to upgrade for HostedCluster deployments.
@@ -202,6 +745,20 @@ type Operation struct {
 	// Option for retrying a failed posthook job. The supported options are 'installPosthook' or 'upgradePosthook'.
 	// +kubebuilder:validation:Enum={installPosthook,upgradePosthook}
 	RetryPosthook string `json:"retryPosthook,omitempty"`
+
+	// Resume a failed or cancelled curation. The curator Job skips the steps that already succeeded
+	// and starts from the first step in status.steps that did not.
+	// +optional
+	ResumeFailed bool `json:"resumeFailed,omitempty"`
+
+	// Cancel the running curation. The curator Job and its in-flight AnsibleJob are deleted and the
+	// curation is recorded as Cancelled.
+	// +optional
+	Cancel bool `json:"cancel,omitempty"`
+
+	// Approve the curation waiting for approval before its prehooks, see approvalRequired.
+	// +optional
+	Approve bool `json:"approve,omitempty"`
 }
 
 // ClusterCurator is the custom resource for the clustercurators API.
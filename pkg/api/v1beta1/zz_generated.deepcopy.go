@@ -26,6 +26,45 @@ import (
 	"k8s.io/apimachinery/pkg/runtime"
 )
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AnsibleJobFailure) DeepCopyInto(out *AnsibleJobFailure) {
+	*out = *in
+	if in.Timestamp != nil {
+		in, out := &in.Timestamp, &out.Timestamp
+		*out = (*in).DeepCopy()
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AnsibleJobFailure.
+func (in *AnsibleJobFailure) DeepCopy() *AnsibleJobFailure {
+	if in == nil {
+		return nil
+	}
+	out := new(AnsibleJobFailure)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CleanupPolicy) DeepCopyInto(out *CleanupPolicy) {
+	*out = *in
+	if in.RetainRuns != nil {
+		in, out := &in.RetainRuns, &out.RetainRuns
+		*out = new(int)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CleanupPolicy.
+func (in *CleanupPolicy) DeepCopy() *CleanupPolicy {
+	if in == nil {
+		return nil
+	}
+	out := new(CleanupPolicy)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ClusterCurator) DeepCopyInto(out *ClusterCurator) {
 	*out = *in
@@ -97,6 +136,11 @@ func (in *ClusterCuratorSpec) DeepCopyInto(out *ClusterCuratorSpec) {
 	in.Scale.DeepCopyInto(&out.Scale)
 	in.Destroy.DeepCopyInto(&out.Destroy)
 	in.Upgrade.DeepCopyInto(&out.Upgrade)
+	if in.CleanupPolicy != nil {
+		in, out := &in.CleanupPolicy, &out.CleanupPolicy
+		*out = new(CleanupPolicy)
+		(*in).DeepCopyInto(*out)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ClusterCuratorSpec.
@@ -119,6 +163,36 @@ func (in *ClusterCuratorStatus) DeepCopyInto(out *ClusterCuratorStatus) {
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
 	}
+	if in.StartTime != nil {
+		in, out := &in.StartTime, &out.StartTime
+		*out = (*in).DeepCopy()
+	}
+	if in.CompletionTime != nil {
+		in, out := &in.CompletionTime, &out.CompletionTime
+		*out = (*in).DeepCopy()
+	}
+	if in.Steps != nil {
+		in, out := &in.Steps, &out.Steps
+		*out = make([]CurationStep, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.LastUpgrade != nil {
+		in, out := &in.LastUpgrade, &out.LastUpgrade
+		*out = new(UpgradeRecord)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.DryRun != nil {
+		in, out := &in.DryRun, &out.DryRun
+		*out = new(DryRunResult)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.LastFailure != nil {
+		in, out := &in.LastFailure, &out.LastFailure
+		*out = new(AnsibleJobFailure)
+		(*in).DeepCopyInto(*out)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ClusterCuratorStatus.
@@ -131,6 +205,123 @@ func (in *ClusterCuratorStatus) DeepCopy() *ClusterCuratorStatus {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CurationStep) DeepCopyInto(out *CurationStep) {
+	*out = *in
+	if in.StartTime != nil {
+		in, out := &in.StartTime, &out.StartTime
+		*out = (*in).DeepCopy()
+	}
+	if in.CompletionTime != nil {
+		in, out := &in.CompletionTime, &out.CompletionTime
+		*out = (*in).DeepCopy()
+	}
+	if in.HookAttempts != nil {
+		in, out := &in.HookAttempts, &out.HookAttempts
+		*out = make([]HookAttempt, len(*in))
+		copy(*out, *in)
+	}
+	if in.Warnings != nil {
+		in, out := &in.Warnings, &out.Warnings
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Outputs != nil {
+		in, out := &in.Outputs, &out.Outputs
+		*out = new(runtime.RawExtension)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CurationStep.
+func (in *CurationStep) DeepCopy() *CurationStep {
+	if in == nil {
+		return nil
+	}
+	out := new(CurationStep)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *DryRunCheck) DeepCopyInto(out *DryRunCheck) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DryRunCheck.
+func (in *DryRunCheck) DeepCopy() *DryRunCheck {
+	if in == nil {
+		return nil
+	}
+	out := new(DryRunCheck)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *DryRunResult) DeepCopyInto(out *DryRunResult) {
+	*out = *in
+	if in.Checks != nil {
+		in, out := &in.Checks, &out.Checks
+		*out = make([]DryRunCheck, len(*in))
+		copy(*out, *in)
+	}
+	if in.Timestamp != nil {
+		in, out := &in.Timestamp, &out.Timestamp
+		*out = (*in).DeepCopy()
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DryRunResult.
+func (in *DryRunResult) DeepCopy() *DryRunResult {
+	if in == nil {
+		return nil
+	}
+	out := new(DryRunResult)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ExtraVarsReference) DeepCopyInto(out *ExtraVarsReference) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ExtraVarsReference.
+func (in *ExtraVarsReference) DeepCopy() *ExtraVarsReference {
+	if in == nil {
+		return nil
+	}
+	out := new(ExtraVarsReference)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ExtraVarsSource) DeepCopyInto(out *ExtraVarsSource) {
+	*out = *in
+	if in.ConfigMapRef != nil {
+		in, out := &in.ConfigMapRef, &out.ConfigMapRef
+		*out = new(ExtraVarsReference)
+		**out = **in
+	}
+	if in.SecretRef != nil {
+		in, out := &in.SecretRef, &out.SecretRef
+		*out = new(ExtraVarsReference)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ExtraVarsSource.
+func (in *ExtraVarsSource) DeepCopy() *ExtraVarsSource {
+	if in == nil {
+		return nil
+	}
+	out := new(ExtraVarsSource)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *Hook) DeepCopyInto(out *Hook) {
 	*out = *in
@@ -139,6 +330,33 @@ func (in *Hook) DeepCopyInto(out *Hook) {
 		*out = new(runtime.RawExtension)
 		(*in).DeepCopyInto(*out)
 	}
+	if in.ExtraVarsFrom != nil {
+		in, out := &in.ExtraVarsFrom, &out.ExtraVarsFrom
+		*out = make([]ExtraVarsSource, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Outputs != nil {
+		in, out := &in.Outputs, &out.Outputs
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Command != nil {
+		in, out := &in.Command, &out.Command
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Args != nil {
+		in, out := &in.Args, &out.Args
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Verbosity != nil {
+		in, out := &in.Verbosity, &out.Verbosity
+		*out = new(int)
+		**out = **in
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Hook.
@@ -151,6 +369,21 @@ func (in *Hook) DeepCopy() *Hook {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *HookAttempt) DeepCopyInto(out *HookAttempt) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new HookAttempt.
+func (in *HookAttempt) DeepCopy() *HookAttempt {
+	if in == nil {
+		return nil
+	}
+	out := new(HookAttempt)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *Hooks) DeepCopyInto(out *Hooks) {
 	*out = *in
@@ -168,6 +401,13 @@ func (in *Hooks) DeepCopyInto(out *Hooks) {
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
 	}
+	if in.OnFailure != nil {
+		in, out := &in.OnFailure, &out.OnFailure
+		*out = make([]Hook, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 	if in.OverrideJob != nil {
 		in, out := &in.OverrideJob, &out.OverrideJob
 		*out = new(runtime.RawExtension)
@@ -200,6 +440,72 @@ func (in *Operation) DeepCopy() *Operation {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ScaleHooks) DeepCopyInto(out *ScaleHooks) {
+	*out = *in
+	if in.MachinePools != nil {
+		in, out := &in.MachinePools, &out.MachinePools
+		*out = make([]ScaleTarget, len(*in))
+		copy(*out, *in)
+	}
+	if in.NodePools != nil {
+		in, out := &in.NodePools, &out.NodePools
+		*out = make([]ScaleTarget, len(*in))
+		copy(*out, *in)
+	}
+	if in.Prehook != nil {
+		in, out := &in.Prehook, &out.Prehook
+		*out = make([]Hook, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Posthook != nil {
+		in, out := &in.Posthook, &out.Posthook
+		*out = make([]Hook, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.OnFailure != nil {
+		in, out := &in.OnFailure, &out.OnFailure
+		*out = make([]Hook, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.OverrideJob != nil {
+		in, out := &in.OverrideJob, &out.OverrideJob
+		*out = new(runtime.RawExtension)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ScaleHooks.
+func (in *ScaleHooks) DeepCopy() *ScaleHooks {
+	if in == nil {
+		return nil
+	}
+	out := new(ScaleHooks)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ScaleTarget) DeepCopyInto(out *ScaleTarget) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ScaleTarget.
+func (in *ScaleTarget) DeepCopy() *ScaleTarget {
+	if in == nil {
+		return nil
+	}
+	out := new(ScaleTarget)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *UpgradeHooks) DeepCopyInto(out *UpgradeHooks) {
 	*out = *in
@@ -217,6 +523,13 @@ func (in *UpgradeHooks) DeepCopyInto(out *UpgradeHooks) {
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
 	}
+	if in.OnFailure != nil {
+		in, out := &in.OnFailure, &out.OnFailure
+		*out = make([]Hook, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 	if in.OverrideJob != nil {
 		in, out := &in.OverrideJob, &out.OverrideJob
 		*out = new(runtime.RawExtension)
@@ -233,3 +546,27 @@ func (in *UpgradeHooks) DeepCopy() *UpgradeHooks {
 	in.DeepCopyInto(out)
 	return out
 }
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *UpgradeRecord) DeepCopyInto(out *UpgradeRecord) {
+	*out = *in
+	if in.NodePoolNames != nil {
+		in, out := &in.NodePoolNames, &out.NodePoolNames
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Timestamp != nil {
+		in, out := &in.Timestamp, &out.Timestamp
+		*out = (*in).DeepCopy()
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new UpgradeRecord.
+func (in *UpgradeRecord) DeepCopy() *UpgradeRecord {
+	if in == nil {
+		return nil
+	}
+	out := new(UpgradeRecord)
+	in.DeepCopyInto(out)
+	return out
+}
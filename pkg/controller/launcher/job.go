@@ -7,11 +7,13 @@ import (
 	"errors"
 
 	clustercuratorv1 "github.com/stolostron/cluster-curator-controller/pkg/api/v1beta1"
+	"github.com/stolostron/cluster-curator-controller/pkg/jobs/approval"
 	"github.com/stolostron/cluster-curator-controller/pkg/jobs/utils"
 	batchv1 "k8s.io/api/batch/v1"
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/resource"
 	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/klog/v2"
 	"sigs.k8s.io/controller-runtime/pkg/client"
@@ -32,31 +34,115 @@ const InterMonUpgrade = "intermediate-monitor-upgrade"
 const FinalUpgradeCluster = "final-upgrade-cluster"
 const FinalMonUpgrade = "final-monitor-upgrade"
 
+const ScaleCluster = "scale-cluster"
+const MonScale = "monitor-scale"
+
 const DeleteClusterDeployment = "destroy-cluster"
 const MonitorDestroy = "monitor-destroy"
 const DeleteClusterNamespace = "delete-cluster-namespace"
 
+const DryRun = "dry-run"
+const WaitForApproval = "wait-for-approval"
+
+// CuratorJobBackoffLimit is how many times a curator pod that failed without a failed step is
+// started again, a pod that was evicted or lost with its node does not count
+const CuratorJobBackoffLimit = 2
+
 type Launcher struct {
-	client         client.Client
-	kubeset        kubernetes.Interface
-	imageURI       string
-	clusterCurator clustercuratorv1.ClusterCurator
+	client             client.Client
+	kubeset            kubernetes.Interface
+	imageURI           string
+	hookImageAllowlist string
+	clusterCurator     clustercuratorv1.ClusterCurator
 }
 
 func NewLauncher(
 	client client.Client,
 	kubeset kubernetes.Interface,
 	imageURI string,
+	hookImageAllowlist string,
 	clusterCurator clustercuratorv1.ClusterCurator) *Launcher {
 
 	return &Launcher{
-		client:         client,
-		kubeset:        kubeset,
-		imageURI:       imageURI,
-		clusterCurator: clusterCurator,
+		client:             client,
+		kubeset:            kubeset,
+		imageURI:           imageURI,
+		hookImageAllowlist: hookImageAllowlist,
+		clusterCurator:     clusterCurator,
+	}
+}
+
+// setHookImageAllowlist passes the images Container hooks may run to every curator container, as
+// onFailure hooks run from the step that failed
+func setHookImageAllowlist(job *batchv1.Job, hookImageAllowlist string) {
+	if hookImageAllowlist == "" {
+		return
+	}
+	podSpec := &job.Spec.Template.Spec
+	for _, containers := range [][]corev1.Container{podSpec.InitContainers, podSpec.Containers} {
+		for i := range containers {
+			if len(containers[i].Command) > 0 && containers[i].Command[0] == CurCmd {
+				containers[i].Env = append(containers[i].Env, corev1.EnvVar{
+					Name:  utils.HookImageAllowlistEnv,
+					Value: hookImageAllowlist,
+				})
+			}
+		}
 	}
 }
 
+// getBackoffLimit returns the backoffLimit of a curator Job
+func getBackoffLimit() *int32 {
+	backoffLimit := int32(CuratorJobBackoffLimit)
+	return &backoffLimit
+}
+
+// getPodFailurePolicy returns the podFailurePolicy of a curator Job. A pod that is evicted, or lost
+// with its node, is started again and resumes the hooks it created, while a step that fails the
+// pod fails the Job, so it is not run again.
+func getPodFailurePolicy() *batchv1.PodFailurePolicy {
+	return &batchv1.PodFailurePolicy{
+		Rules: []batchv1.PodFailurePolicyRule{
+			batchv1.PodFailurePolicyRule{
+				Action: batchv1.PodFailurePolicyActionIgnore,
+				OnPodConditions: []batchv1.PodFailurePolicyOnPodConditionsPattern{
+					batchv1.PodFailurePolicyOnPodConditionsPattern{
+						Type:   corev1.DisruptionTarget,
+						Status: corev1.ConditionTrue,
+					},
+				},
+			},
+			batchv1.PodFailurePolicyRule{
+				Action: batchv1.PodFailurePolicyActionFailJob,
+				OnExitCodes: &batchv1.PodFailurePolicyOnExitCodesRequirement{
+					Operator: batchv1.PodFailurePolicyOnExitCodesOpNotIn,
+					Values:   []int32{0},
+				},
+			},
+		},
+	}
+}
+
+// getOverrideJob returns the overrideJob of the hooks that match the effective
+// curation, or nil when that curation has no override. A posthook retry uses the
+// override of the curation it belongs to.
+func getOverrideJob(curator clustercuratorv1.ClusterCurator) *runtime.RawExtension {
+	if utils.IsDryRun(&curator) {
+		return nil
+	}
+	switch utils.GetEffectiveCuration(&curator) {
+	case "install", "installPosthook":
+		return curator.Spec.Install.OverrideJob
+	case "upgrade", "upgradePosthook":
+		return curator.Spec.Upgrade.OverrideJob
+	case "destroy":
+		return curator.Spec.Destroy.OverrideJob
+	case "scale":
+		return curator.Spec.Scale.OverrideJob
+	}
+	return nil
+}
+
 func getBatchJob(
 	clusterName string,
 	clusterNamespace string,
@@ -65,9 +151,10 @@ func getBatchJob(
 
 	var ttlf int32 = 3600
 
-	desiredCuration := curator.Spec.DesiredCuration
-	if curator.Operation != nil && curator.Operation.RetryPosthook != "" {
-		desiredCuration = curator.Operation.RetryPosthook
+	desiredCuration := utils.GetEffectiveCuration(&curator)
+
+	if utils.IsDryRun(&curator) {
+		return getDryRunJob(clusterName, clusterNamespace, imageURI, curator)
 	}
 
 	isPrehook := false
@@ -106,7 +193,8 @@ func getBatchJob(
 				},
 			},
 			Spec: batchv1.JobSpec{
-				BackoffLimit:            new(int32),
+				BackoffLimit:            getBackoffLimit(),
+				PodFailurePolicy:        getPodFailurePolicy(),
 				TTLSecondsAfterFinished: &ttlf,
 				Template: corev1.PodTemplateSpec{
 					Spec: corev1.PodSpec{
@@ -225,7 +313,8 @@ func getBatchJob(
 				Annotations: annotations,
 			},
 			Spec: batchv1.JobSpec{
-				BackoffLimit:            new(int32),
+				BackoffLimit:            getBackoffLimit(),
+				PodFailurePolicy:        getPodFailurePolicy(),
 				TTLSecondsAfterFinished: &ttlf,
 				Template: corev1.PodTemplateSpec{
 					Spec: corev1.PodSpec{
@@ -243,6 +332,61 @@ func getBatchJob(
 				},
 			},
 		}
+	case "scale":
+		if curator.Spec.Scale.Prehook != nil {
+			isPrehook = true
+		}
+		if curator.Spec.Scale.Posthook != nil {
+			isPosthook = true
+		}
+		newJob = &batchv1.Job{
+			ObjectMeta: v1.ObjectMeta{
+				GenerateName: "curator-job-",
+				Namespace:    clusterNamespace,
+				Labels: map[string]string{
+					"open-cluster-management": "curator-job",
+				},
+				Annotations: map[string]string{
+					ScaleCluster: "Set the replicas of the MachinePools or NodePools",
+					MonScale:     "Monitor the MachinePools or NodePools until they are scaled",
+					DoneDoneDone: "Cluster Curator job has completed",
+				},
+			},
+			Spec: batchv1.JobSpec{
+				BackoffLimit:            getBackoffLimit(),
+				PodFailurePolicy:        getPodFailurePolicy(),
+				TTLSecondsAfterFinished: &ttlf,
+				Template: corev1.PodTemplateSpec{
+					Spec: corev1.PodSpec{
+						ServiceAccountName: "cluster-installer",
+						RestartPolicy:      corev1.RestartPolicyNever,
+						InitContainers: []corev1.Container{
+							corev1.Container{
+								Name:            ScaleCluster,
+								Image:           imageURI,
+								Command:         []string{CurCmd, ScaleCluster, clusterName},
+								ImagePullPolicy: corev1.PullAlways,
+								Resources:       resourceSettings,
+							},
+							corev1.Container{
+								Name:            MonScale,
+								Image:           imageURI,
+								Command:         []string{CurCmd, MonScale, clusterName},
+								ImagePullPolicy: corev1.PullAlways,
+								Resources:       resourceSettings,
+							},
+						},
+						Containers: []corev1.Container{
+							corev1.Container{
+								Name:    DoneDoneDone,
+								Image:   imageURI,
+								Command: []string{CurCmd, DoneDoneDone, clusterName},
+							},
+						},
+					},
+				},
+			},
+		}
 	case "destroy":
 		if curator.Spec.Destroy.Prehook != nil {
 			isPrehook = true
@@ -264,7 +408,8 @@ func getBatchJob(
 				},
 			},
 			Spec: batchv1.JobSpec{
-				BackoffLimit:            new(int32),
+				BackoffLimit:            getBackoffLimit(),
+				PodFailurePolicy:        getPodFailurePolicy(),
 				TTLSecondsAfterFinished: &ttlf,
 				Template: corev1.PodTemplateSpec{
 					Spec: corev1.PodSpec{
@@ -314,7 +459,8 @@ func getBatchJob(
 				},
 			},
 			Spec: batchv1.JobSpec{
-				BackoffLimit:            new(int32),
+				BackoffLimit:            getBackoffLimit(),
+				PodFailurePolicy:        getPodFailurePolicy(),
 				TTLSecondsAfterFinished: &ttlf,
 				Template: corev1.PodTemplateSpec{
 					Spec: corev1.PodSpec{
@@ -375,9 +521,139 @@ func getBatchJob(
 			Resources: resourceSettings,
 		})
 	}
+	// The approval is added last, so the Job waits for it before the prehooks run
+	addApprovalStep(newJob, clusterName, imageURI, curator, resourceSettings)
+	newJob.Spec.Template.Labels = curator.Labels
+	resumeFromFailedStep(newJob, curator)
+	return newJob
+
+}
+
+// addApprovalStep prepends the wait-for-approval init container to the Job when the curation
+// requires an approval, so nothing runs before the curation is approved
+func addApprovalStep(
+	job *batchv1.Job,
+	clusterName string,
+	imageURI string,
+	curator clustercuratorv1.ClusterCurator,
+	resourceSettings corev1.ResourceRequirements) {
+
+	desiredCuration := utils.GetEffectiveCuration(&curator)
+	if !approval.IsApprovalRequired(&curator, desiredCuration) {
+		return
+	}
+	annotations := job.GetAnnotations()
+	if annotations == nil {
+		annotations = map[string]string{}
+	}
+	annotations[WaitForApproval] = "Wait for the " + desiredCuration + " to be approved"
+	job.SetAnnotations(annotations)
+
+	initContainers := []corev1.Container{
+		corev1.Container{
+			Name:            WaitForApproval,
+			Image:           imageURI,
+			Command:         []string{CurCmd, WaitForApproval, clusterName},
+			ImagePullPolicy: corev1.PullAlways,
+			Resources:       resourceSettings,
+		},
+	}
+	job.Spec.Template.Spec.InitContainers = append(initContainers, job.Spec.Template.Spec.InitContainers...)
+}
+
+// getDryRunJob returns a curator Job that only checks the desired curation, the hooks and the
+// upgrade are not run
+func getDryRunJob(
+	clusterName string,
+	clusterNamespace string,
+	imageURI string,
+	curator clustercuratorv1.ClusterCurator) *batchv1.Job {
+
+	var ttlf int32 = 3600
+
+	newJob := &batchv1.Job{
+		ObjectMeta: v1.ObjectMeta{
+			GenerateName: "curator-job-",
+			Namespace:    clusterNamespace,
+			Labels: map[string]string{
+				"open-cluster-management": "curator-job",
+			},
+			Annotations: map[string]string{
+				DryRun:       "Dry run of the " + utils.GetEffectiveCuration(&curator) + " curation",
+				DoneDoneDone: "Cluster Curator job has completed",
+			},
+		},
+		Spec: batchv1.JobSpec{
+			BackoffLimit:            new(int32),
+			TTLSecondsAfterFinished: &ttlf,
+			Template: corev1.PodTemplateSpec{
+				Spec: corev1.PodSpec{
+					ServiceAccountName: "cluster-installer",
+					RestartPolicy:      corev1.RestartPolicyNever,
+					InitContainers: []corev1.Container{
+						corev1.Container{
+							Name:            DryRun,
+							Image:           imageURI,
+							Command:         []string{CurCmd, DryRun, clusterName},
+							ImagePullPolicy: corev1.PullAlways,
+							Resources: corev1.ResourceRequirements{
+								Requests: corev1.ResourceList{
+									corev1.ResourceCPU:    resource.MustParse("0.3m"),
+									corev1.ResourceMemory: resource.MustParse("30Mi"),
+								},
+								Limits: corev1.ResourceList{
+									corev1.ResourceCPU:    resource.MustParse("2m"),
+									corev1.ResourceMemory: resource.MustParse("45Mi"),
+								},
+							},
+						},
+					},
+					Containers: []corev1.Container{
+						corev1.Container{
+							Name:    DoneDoneDone,
+							Image:   imageURI,
+							Command: []string{CurCmd, DoneDoneDone, clusterName},
+						},
+					},
+				},
+			},
+		},
+	}
 	newJob.Spec.Template.Labels = curator.Labels
 	return newJob
+}
+
+// resumeFromFailedStep removes the init containers that ran before the step a resumed curation
+// starts from, the steps come from the status of the failed curator Job
+func resumeFromFailedStep(job *batchv1.Job, curator clustercuratorv1.ClusterCurator) {
+	if curator.Operation == nil || !curator.Operation.ResumeFailed || curator.Operation.RetryPosthook != "" {
+		return
+	}
+
+	// Without a failed step, nothing is skipped rather than marking the curation done
+	resumeStep := utils.GetResumeStep(&curator)
+	if resumeStep == "" {
+		klog.Warning(" No failed step to resume from, running every step")
+		return
+	}
+
+	initContainers := job.Spec.Template.Spec.InitContainers
+	steps := getCurationSteps(job)
+
+	start := -1
+	for i, step := range steps {
+		if step == resumeStep {
+			start = i
+			break
+		}
+	}
+	if start == -1 {
+		klog.Warningf(" Step %v is not part of the curator job, running every step", resumeStep)
+		return
+	}
 
+	klog.V(0).Infof(" Resuming the curation from step %q, skipping %v steps", resumeStep, start)
+	job.Spec.Template.Spec.InitContainers = initContainers[start:]
 }
 
 // sanitizeOverrideJob hardens a CR-author-supplied overrideJob so the
@@ -430,6 +706,52 @@ func sanitizeOverrideJob(job *batchv1.Job, imageURI string) error {
 	return nil
 }
 
+// getCurationSteps lists the init containers of the curator Job by the curator subcommand they
+// run, as that is the condition type each one records
+func getCurationSteps(job *batchv1.Job) []string {
+	steps := []string{}
+	for _, c := range job.Spec.Template.Spec.InitContainers {
+		if len(c.Command) > 1 && c.Command[0] == CurCmd {
+			steps = append(steps, c.Command[1])
+		} else {
+			steps = append(steps, c.Name)
+		}
+	}
+	return steps
+}
+
+// parseOverrideJob unmarshals an overrideJob and sanitizes it to run with the curator image
+func parseOverrideJob(overrideJob *runtime.RawExtension, imageURI string) (*batchv1.Job, error) {
+	newJob := &batchv1.Job{}
+
+	if err := json.Unmarshal(overrideJob.Raw, &newJob); err != nil {
+		klog.Warningf("overrideJob:\n---\n%v---", string(overrideJob.Raw))
+		return nil, err
+	}
+
+	klog.V(2).Info(" Basic sanity check for override job")
+	if len(newJob.Spec.Template.Spec.InitContainers) == 0 &&
+		len(newJob.Spec.Template.Spec.Containers) == 0 {
+
+		klog.Warning(newJob)
+		return nil, errors.New("Did not find any InitContainers or Containers defined")
+	}
+
+	if err := sanitizeOverrideJob(newJob, imageURI); err != nil {
+		return nil, err
+	}
+	return newJob, nil
+}
+
+// ValidateOverrideJob returns the error CreateJob would return for the overrideJob
+func ValidateOverrideJob(overrideJob *runtime.RawExtension) error {
+	if overrideJob == nil {
+		return nil
+	}
+	_, err := parseOverrideJob(overrideJob, utils.DefaultImageURI)
+	return err
+}
+
 func (I *Launcher) CreateJob() error {
 	kubeset := I.kubeset
 	clusterName := I.clusterCurator.Name
@@ -440,34 +762,25 @@ func (I *Launcher) CreateJob() error {
 	// Allow us to override the job in the Cluster Curator
 	klog.V(0).Info("Creating Curator job curator-job in namespace " + clusterNamespace)
 	var err error
-	if I.clusterCurator.Spec.Install.OverrideJob != nil {
-		klog.V(0).Info(" Overriding the Curator job with overrideJob from the " + clusterName + " ClusterCurator resource")
-		newJob = &batchv1.Job{}
+	if overrideJob := getOverrideJob(I.clusterCurator); overrideJob != nil {
+		klog.V(0).Info(" Overriding the Curator job with the " + utils.GetEffectiveCuration(&I.clusterCurator) +
+			" overrideJob from the " + clusterName + " ClusterCurator resource")
 
-		err = json.Unmarshal(I.clusterCurator.Spec.Install.OverrideJob.Raw, &newJob)
+		newJob, err = parseOverrideJob(overrideJob, I.imageURI)
 		if err != nil {
-			klog.Warningf("overrideJob:\n---\n%v---", string(I.clusterCurator.Spec.Install.OverrideJob.Raw))
-			return err
-		}
-
-		klog.V(2).Info(" Basic sanity check for override job")
-		if len(newJob.Spec.Template.Spec.InitContainers) == 0 &&
-			len(newJob.Spec.Template.Spec.Containers) == 0 {
-
-			klog.Warning(newJob)
-			return errors.New("Did not find any InitContainers or Containers defined")
-		}
-
-		if err := sanitizeOverrideJob(newJob, I.imageURI); err != nil {
 			klog.Warningf(" Rejected overrideJob from %v ClusterCurator: %v", clusterName, err)
 			return err
 		}
+		addApprovalStep(newJob, clusterName, I.imageURI, I.clusterCurator, corev1.ResourceRequirements{})
+		resumeFromFailedStep(newJob, I.clusterCurator)
 	}
 	if err == nil {
+		setHookImageAllowlist(newJob, I.hookImageAllowlist)
 		curatorJob, err := kubeset.BatchV1().Jobs(clusterNamespace).Create(context.TODO(), newJob, v1.CreateOptions{})
 		if err == nil {
 			klog.V(0).Infof(" Created Curator job  ✓ (%v)", curatorJob.Name)
-			err = utils.RecordCuratorJobName(I.client, clusterName, clusterNamespace, curatorJob.Name)
+			err = utils.RecordCuratorJobStarted(I.client, clusterName, clusterNamespace, curatorJob.Name,
+				utils.GetEffectiveCuration(&I.clusterCurator), getCurationSteps(curatorJob))
 			if err != nil {
 				return err
 			}
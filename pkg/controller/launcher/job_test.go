@@ -8,6 +8,7 @@ import (
 	"testing"
 
 	clustercuratorv1 "github.com/stolostron/cluster-curator-controller/pkg/api/v1beta1"
+	"github.com/stolostron/cluster-curator-controller/pkg/jobs/utils"
 	"github.com/stretchr/testify/assert"
 	"gopkg.in/yaml.v3"
 	batchv1 "k8s.io/api/batch/v1"
@@ -300,7 +301,7 @@ func TestCreateLauncher(t *testing.T) {
 	client := clientfake.NewClientBuilder().WithScheme(s).WithRuntimeObjects(clusterCurator).Build()
 	kubeset := fake.NewSimpleClientset()
 
-	testLauncher := NewLauncher(client, kubeset, imageURI, *clusterCurator)
+	testLauncher := NewLauncher(client, kubeset, imageURI, "", *clusterCurator)
 
 	assert.NotNil(t, testLauncher, "launcher is not nil")
 
@@ -328,6 +329,137 @@ func TestCreateLauncher(t *testing.T) {
 			job.Spec.Template.Spec.InitContainers[0].Env[0].Value)
 	}
 
+	cc, err := utils.GetClusterCurator(client, clusterName, clusterName)
+	assert.Nil(t, err, "err is nil, when ClusterCurator is retrieved")
+	assert.Equal(t, clustercuratorv1.CurationPhasePending, cc.Status.Phase, "curation is pending")
+	assert.Equal(t, "install", cc.Status.CurationType)
+	assert.NotNil(t, cc.Status.StartTime, "start time is set")
+	assert.Equal(t, len(job.Spec.Template.Spec.InitContainers), len(cc.Status.Steps), "a step per init container")
+	assert.Equal(t, PreAJob, cc.Status.Steps[0].Name)
+	assert.Equal(t, clustercuratorv1.CurationPhasePending, cc.Status.Steps[0].State)
+}
+
+func TestCreateLauncherHookImageAllowlist(t *testing.T) {
+
+	clusterCurator := &clustercuratorv1.ClusterCurator{
+		ObjectMeta: v1.ObjectMeta{Name: clusterName, Namespace: clusterName},
+		Spec: clustercuratorv1.ClusterCuratorSpec{
+			DesiredCuration: "install",
+			Install: clustercuratorv1.Hooks{
+				Prehook: []clustercuratorv1.Hook{{Name: "prehook job"}},
+			},
+		},
+	}
+
+	s.AddKnownTypes(clustercuratorv1.SchemeBuilder.GroupVersion, &clustercuratorv1.ClusterCurator{})
+	client := clientfake.NewClientBuilder().WithScheme(s).WithRuntimeObjects(clusterCurator).Build()
+	kubeset := fake.NewSimpleClientset()
+
+	testLauncher := NewLauncher(client, kubeset, imageURI, "quay.io/my-org/", *clusterCurator)
+	assert.Nil(t, testLauncher.CreateJob(), "err is nil, when the Job is created")
+
+	job, err := kubeset.BatchV1().Jobs(clusterName).Get(context.TODO(), "", v1.GetOptions{})
+	assert.Nil(t, err, "err is nil, when the Job is found")
+
+	for _, container := range append(job.Spec.Template.Spec.InitContainers, job.Spec.Template.Spec.Containers...) {
+		if container.Command[0] != CurCmd {
+			continue
+		}
+		assert.Contains(t, container.Env,
+			corev1.EnvVar{Name: utils.HookImageAllowlistEnv, Value: "quay.io/my-org/"},
+			"the allowlist is passed to "+container.Name)
+	}
+}
+
+func getFailedInstallCurator() clustercuratorv1.ClusterCurator {
+	return clustercuratorv1.ClusterCurator{
+		ObjectMeta: v1.ObjectMeta{Name: clusterName, Namespace: clusterName},
+		Spec: clustercuratorv1.ClusterCuratorSpec{
+			Install: clustercuratorv1.Hooks{
+				Prehook:  []clustercuratorv1.Hook{{Name: "prehook job"}},
+				Posthook: []clustercuratorv1.Hook{{Name: "posthook job"}},
+			},
+		},
+		Operation: &clustercuratorv1.Operation{ResumeFailed: true},
+		Status: clustercuratorv1.ClusterCuratorStatus{
+			Phase:        clustercuratorv1.CurationPhaseFailed,
+			CurationType: "install",
+			Steps: []clustercuratorv1.CurationStep{
+				{Name: PreAJob, State: clustercuratorv1.CurationPhaseSucceeded},
+				{Name: ActivateAndMonitor, State: clustercuratorv1.CurationPhaseSucceeded},
+				{Name: MonImport, State: clustercuratorv1.CurationPhaseFailed},
+				{Name: PostAJob, State: clustercuratorv1.CurationPhasePending},
+			},
+		},
+	}
+}
+
+func TestGetBatchJobResumeFailed(t *testing.T) {
+	clusterCurator := getFailedInstallCurator()
+
+	batchJobObj := getBatchJob(clusterName, clusterName, imageURI, clusterCurator)
+
+	t.Log("The Job starts from the failed step")
+	assert.Equal(t, []string{MonImport, PostAJob}, getCurationSteps(batchJobObj))
+	assert.Equal(t, DoneDoneDone, batchJobObj.Spec.Template.Spec.Containers[0].Name)
+
+	t.Log("Every step runs when no step failed, the curation is not marked done without running")
+	for i := range clusterCurator.Status.Steps {
+		clusterCurator.Status.Steps[i].State = clustercuratorv1.CurationPhaseSucceeded
+	}
+	batchJobObj = getBatchJob(clusterName, clusterName, imageURI, clusterCurator)
+	assert.Equal(t, numInitContainers, len(batchJobObj.Spec.Template.Spec.InitContainers))
+
+	t.Log("Every step runs when the failed step is not part of the Job")
+	clusterCurator.Status.Steps = []clustercuratorv1.CurationStep{
+		{Name: "unknown-step", State: clustercuratorv1.CurationPhaseFailed},
+	}
+	batchJobObj = getBatchJob(clusterName, clusterName, imageURI, clusterCurator)
+	assert.Equal(t, numInitContainers, len(batchJobObj.Spec.Template.Spec.InitContainers))
+}
+
+func TestCreateLauncherResumeFailed(t *testing.T) {
+	clusterCurator := getFailedInstallCurator()
+
+	s.AddKnownTypes(clustercuratorv1.SchemeBuilder.GroupVersion, &clustercuratorv1.ClusterCurator{})
+	client := clientfake.NewClientBuilder().WithScheme(s).WithRuntimeObjects(&clusterCurator).Build()
+	kubeset := fake.NewSimpleClientset()
+
+	assert.Nil(t, NewLauncher(client, kubeset, imageURI, "", clusterCurator).CreateJob(), "error is nil")
+
+	job, err := kubeset.BatchV1().Jobs(clusterName).Get(context.TODO(), "", v1.GetOptions{})
+	assert.Nil(t, err, "err is nil, when the Job is created")
+	assert.Equal(t, MonImport, job.Spec.Template.Spec.InitContainers[0].Name)
+
+	cc, err := utils.GetClusterCurator(client, clusterName, clusterName)
+	assert.Nil(t, err, "err is nil, when ClusterCurator is retrieved")
+	assert.Equal(t, "install", cc.Status.CurationType)
+	assert.Equal(t, 4, len(cc.Status.Steps), "the skipped steps are kept")
+	assert.Equal(t, clustercuratorv1.CurationPhaseSucceeded, cc.Status.Steps[1].State)
+	assert.Equal(t, clustercuratorv1.CurationPhasePending, cc.Status.Steps[2].State)
+}
+
+func TestGetCurationSteps(t *testing.T) {
+	clusterCurator := &clustercuratorv1.ClusterCurator{
+		ObjectMeta: v1.ObjectMeta{Name: clusterName, Namespace: clusterName},
+		Spec: clustercuratorv1.ClusterCuratorSpec{
+			DesiredCuration: "upgrade",
+			Upgrade: clustercuratorv1.UpgradeHooks{
+				IntermediateUpdate: "4.14.10",
+				DesiredUpdate:      "4.15.1",
+			},
+		},
+	}
+
+	batchJobObj := getBatchJob(clusterName, clusterName, imageURI, *clusterCurator)
+
+	// The final monitor container runs the monitor-upgrade subcommand, which names its condition
+	assert.Equal(t, []string{InterUpgradeCluster, InterMonUpgrade, FinalUpgradeCluster, MonUpgrade},
+		getCurationSteps(batchJobObj))
+
+	batchJobObj.Spec.Template.Spec.InitContainers = append(batchJobObj.Spec.Template.Spec.InitContainers,
+		corev1.Container{Name: "custom", Command: []string{"/bin/sh"}})
+	assert.Equal(t, "custom", getCurationSteps(batchJobObj)[4], "non curator containers use their name")
 }
 
 // Test launcher with a bad clusterCurator no InitContainers
@@ -335,6 +467,7 @@ func TestCreateLauncherBadClusterCurator(t *testing.T) {
 
 	clusterCurator := &clustercuratorv1.ClusterCurator{
 		ObjectMeta: v1.ObjectMeta{Name: clusterName, Namespace: clusterName},
+		Spec:       clustercuratorv1.ClusterCuratorSpec{DesiredCuration: "install"},
 	}
 	overrideJob, _ := json.Marshal(&batchv1.Job{ObjectMeta: v1.ObjectMeta{
 		Name:      "myjob",
@@ -348,7 +481,7 @@ func TestCreateLauncherBadClusterCurator(t *testing.T) {
 	client := clientfake.NewClientBuilder().WithScheme(s).WithRuntimeObjects(clusterCurator).Build()
 	kubeset := fake.NewSimpleClientset()
 
-	testLauncher := NewLauncher(client, kubeset, imageURI, *clusterCurator)
+	testLauncher := NewLauncher(client, kubeset, imageURI, "", *clusterCurator)
 
 	assert.NotNil(t, testLauncher, "launcher is not nil")
 
@@ -377,6 +510,7 @@ func TestCreateLauncherOverrideJob(t *testing.T) {
 		ObjectMeta: v1.ObjectMeta{Name: clusterName, Namespace: clusterName},
 		Spec: clustercuratorv1.ClusterCuratorSpec{
 			ProviderCredentialPath: "default/provider-secret",
+			DesiredCuration:        "install",
 			Install: clustercuratorv1.Hooks{
 				OverrideJob: &runtime.RawExtension{
 					Raw: stringData,
@@ -389,7 +523,7 @@ func TestCreateLauncherOverrideJob(t *testing.T) {
 	client := clientfake.NewClientBuilder().WithScheme(s).WithRuntimeObjects(clusterCurator).Build()
 	kubeset := fake.NewSimpleClientset()
 
-	testLauncher := NewLauncher(client, kubeset, imageURI, *clusterCurator)
+	testLauncher := NewLauncher(client, kubeset, imageURI, "", *clusterCurator)
 
 	assert.NotNil(t, testLauncher, "launcher is not nil")
 
@@ -424,7 +558,8 @@ func TestCreateLauncherOverrideJobSanitized(t *testing.T) {
 	clusterCurator := &clustercuratorv1.ClusterCurator{
 		ObjectMeta: v1.ObjectMeta{Name: clusterName, Namespace: clusterName},
 		Spec: clustercuratorv1.ClusterCuratorSpec{
-			Install: clustercuratorv1.Hooks{OverrideJob: &runtime.RawExtension{Raw: raw}},
+			DesiredCuration: "install",
+			Install:         clustercuratorv1.Hooks{OverrideJob: &runtime.RawExtension{Raw: raw}},
 		},
 	}
 
@@ -432,7 +567,7 @@ func TestCreateLauncherOverrideJobSanitized(t *testing.T) {
 	client := clientfake.NewClientBuilder().WithScheme(s).WithRuntimeObjects(clusterCurator).Build()
 	kubeset := fake.NewSimpleClientset()
 
-	assert.Nil(t, NewLauncher(client, kubeset, imageURI, *clusterCurator).CreateJob(),
+	assert.Nil(t, NewLauncher(client, kubeset, imageURI, "", *clusterCurator).CreateJob(),
 		"sanitized overrideJob is created")
 
 	jobs, _ := kubeset.BatchV1().Jobs(clusterName).List(context.TODO(), v1.ListOptions{})
@@ -449,16 +584,125 @@ func TestCreateLauncherOverrideJobSanitized(t *testing.T) {
 	hostile.Spec.Template.Spec.Containers[0].Command = []string{"/bin/sh", "-c", "id"}
 	raw, _ = json.Marshal(hostile)
 	clusterCurator.Spec.Install.OverrideJob.Raw = raw
-	assert.NotNil(t, NewLauncher(client, kubeset, imageURI, *clusterCurator).CreateJob(),
+	assert.NotNil(t, NewLauncher(client, kubeset, imageURI, "", *clusterCurator).CreateJob(),
 		"non-curator command rejected")
 }
 
+func TestGetOverrideJob(t *testing.T) {
+	installJob := &runtime.RawExtension{Raw: []byte(`{"metadata":{"name":"install"}}`)}
+	upgradeJob := &runtime.RawExtension{Raw: []byte(`{"metadata":{"name":"upgrade"}}`)}
+	destroyJob := &runtime.RawExtension{Raw: []byte(`{"metadata":{"name":"destroy"}}`)}
+	scaleJob := &runtime.RawExtension{Raw: []byte(`{"metadata":{"name":"scale"}}`)}
+
+	testcases := []struct {
+		name            string
+		desiredCuration string
+		retryPosthook   string
+		expected        *runtime.RawExtension
+	}{
+		{name: "install", desiredCuration: "install", expected: installJob},
+		{name: "upgrade", desiredCuration: "upgrade", expected: upgradeJob},
+		{name: "destroy", desiredCuration: "destroy", expected: destroyJob},
+		{name: "scale", desiredCuration: "scale", expected: scaleJob},
+		{name: "installPosthook", retryPosthook: "installPosthook", expected: installJob},
+		{name: "upgradePosthook", desiredCuration: "upgrade", retryPosthook: "upgradePosthook", expected: upgradeJob},
+		{name: "no curation", expected: nil},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			clusterCurator := clustercuratorv1.ClusterCurator{
+				Spec: clustercuratorv1.ClusterCuratorSpec{
+					DesiredCuration: tc.desiredCuration,
+					Install:         clustercuratorv1.Hooks{OverrideJob: installJob},
+					Upgrade:         clustercuratorv1.UpgradeHooks{OverrideJob: upgradeJob},
+					Destroy:         clustercuratorv1.Hooks{OverrideJob: destroyJob},
+					Scale:           clustercuratorv1.ScaleHooks{OverrideJob: scaleJob},
+				},
+			}
+			if tc.retryPosthook != "" {
+				clusterCurator.Operation = &clustercuratorv1.Operation{RetryPosthook: tc.retryPosthook}
+			}
+			assert.Equal(t, tc.expected, getOverrideJob(clusterCurator))
+		})
+	}
+}
+
+// The install overrideJob must not be applied to an upgrade, and the upgrade
+// overrideJob is sanitized like the install one.
+func TestCreateLauncherUpgradeOverrideJob(t *testing.T) {
+	installRaw, _ := json.Marshal(&batchv1.Job{ObjectMeta: v1.ObjectMeta{Name: "install-job", Namespace: clusterName}})
+	upgradeRaw, _ := json.Marshal(&batchv1.Job{
+		ObjectMeta: v1.ObjectMeta{Name: "upgrade-job", Namespace: clusterName},
+		Spec: batchv1.JobSpec{Template: corev1.PodTemplateSpec{Spec: corev1.PodSpec{
+			ServiceAccountName: "system-admin",
+			Containers: []corev1.Container{{
+				Name:    DoneDoneDone,
+				Image:   "evil.example.com/exfil:latest",
+				Command: []string{CurCmd, DoneDoneDone},
+			}},
+		}}},
+	})
+	clusterCurator := &clustercuratorv1.ClusterCurator{
+		ObjectMeta: v1.ObjectMeta{Name: clusterName, Namespace: clusterName},
+		Spec: clustercuratorv1.ClusterCuratorSpec{
+			DesiredCuration: "upgrade",
+			Install:         clustercuratorv1.Hooks{OverrideJob: &runtime.RawExtension{Raw: installRaw}},
+			Upgrade: clustercuratorv1.UpgradeHooks{
+				DesiredUpdate: "4.14.16",
+				OverrideJob:   &runtime.RawExtension{Raw: upgradeRaw},
+			},
+		},
+	}
+
+	s.AddKnownTypes(clustercuratorv1.SchemeBuilder.GroupVersion, &clustercuratorv1.ClusterCurator{})
+	client := clientfake.NewClientBuilder().WithScheme(s).WithRuntimeObjects(clusterCurator).Build()
+	kubeset := fake.NewSimpleClientset()
+
+	assert.Nil(t, NewLauncher(client, kubeset, imageURI, "", *clusterCurator).CreateJob(),
+		"upgrade overrideJob is created")
+
+	job, err := kubeset.BatchV1().Jobs(clusterName).Get(context.TODO(), "upgrade-job", v1.GetOptions{})
+	assert.Nil(t, err, "the upgrade overrideJob was used")
+	assert.Equal(t, "cluster-installer", job.Spec.Template.Spec.ServiceAccountName, "SA pinned to cluster-installer")
+	assert.Equal(t, imageURI, job.Spec.Template.Spec.Containers[0].Image, "container image pinned to controller imageURI")
+
+	_, err = kubeset.BatchV1().Jobs(clusterName).Get(context.TODO(), "install-job", v1.GetOptions{})
+	assert.NotNil(t, err, "the install overrideJob was not used")
+}
+
+// Without a destroy overrideJob, a destroy uses the built-in flow even when an
+// install overrideJob is present.
+func TestCreateLauncherDestroyIgnoresInstallOverrideJob(t *testing.T) {
+	installRaw, _ := json.Marshal(&batchv1.Job{ObjectMeta: v1.ObjectMeta{Name: "install-job", Namespace: clusterName}})
+	clusterCurator := &clustercuratorv1.ClusterCurator{
+		ObjectMeta: v1.ObjectMeta{Name: clusterName, Namespace: clusterName},
+		Spec: clustercuratorv1.ClusterCuratorSpec{
+			DesiredCuration: "destroy",
+			Install:         clustercuratorv1.Hooks{OverrideJob: &runtime.RawExtension{Raw: installRaw}},
+		},
+	}
+
+	s.AddKnownTypes(clustercuratorv1.SchemeBuilder.GroupVersion, &clustercuratorv1.ClusterCurator{})
+	client := clientfake.NewClientBuilder().WithScheme(s).WithRuntimeObjects(clusterCurator).Build()
+	kubeset := fake.NewSimpleClientset()
+
+	assert.Nil(t, NewLauncher(client, kubeset, imageURI, "", *clusterCurator).CreateJob(),
+		"destroy job is created")
+
+	jobs, _ := kubeset.BatchV1().Jobs(clusterName).List(context.TODO(), v1.ListOptions{})
+	assert.Equal(t, 1, len(jobs.Items), "exactly one Job created")
+	assert.Equal(t, DeleteClusterDeployment, jobs.Items[0].Spec.Template.Spec.InitContainers[0].Name,
+		"built-in destroy flow is used")
+}
+
 // Test launcher with an Invalid overrideJob
 func TestCreateLauncherInvalidOverrideJob(t *testing.T) {
 	clusterCurator := &clustercuratorv1.ClusterCurator{
 		ObjectMeta: v1.ObjectMeta{Name: clusterName, Namespace: clusterName},
 		Spec: clustercuratorv1.ClusterCuratorSpec{
 			ProviderCredentialPath: "default/provider-secret",
+			DesiredCuration:        "install",
 			Install: clustercuratorv1.Hooks{
 				OverrideJob: &runtime.RawExtension{
 					Raw: []byte("Not a valid job.batchv1: specification!!"),
@@ -471,7 +715,7 @@ func TestCreateLauncherInvalidOverrideJob(t *testing.T) {
 	client := clientfake.NewClientBuilder().WithScheme(s).WithRuntimeObjects(clusterCurator).Build()
 	kubeset := fake.NewSimpleClientset()
 
-	testLauncher := NewLauncher(client, kubeset, imageURI, *clusterCurator)
+	testLauncher := NewLauncher(client, kubeset, imageURI, "", *clusterCurator)
 
 	assert.NotNil(t, testLauncher, "launcher is not nil")
 
@@ -533,3 +777,165 @@ func TestGetBatchJobEUSUpgrade(t *testing.T) {
 		t.Fatalf("The ClusterCurator job was not corrctly populated, missing final-monitor-upgrade initContainer")
 	}
 }
+
+func TestGetBatchJobScale(t *testing.T) {
+	clusterCurator := clustercuratorv1.ClusterCurator{
+		ObjectMeta: v1.ObjectMeta{
+			Name:      clusterName,
+			Namespace: clusterName,
+		},
+		Spec: clustercuratorv1.ClusterCuratorSpec{
+			DesiredCuration: "scale",
+			Scale: clustercuratorv1.ScaleHooks{
+				MachinePools: []clustercuratorv1.ScaleTarget{
+					{
+						Name:     clusterName + "-worker",
+						Replicas: 5,
+					},
+				},
+				Prehook: []clustercuratorv1.Hook{
+					{
+						Name: "prehook job",
+					},
+				},
+				Posthook: []clustercuratorv1.Hook{
+					{
+						Name: "posthook job",
+					},
+				},
+			},
+		},
+	}
+
+	batchJobObj := getBatchJob(clusterName, clusterName, imageURI, clusterCurator)
+
+	t.Log("Test count initContainers in job")
+	initContainers := batchJobObj.Spec.Template.Spec.InitContainers
+	assert.Equal(t, numInitContainers, len(initContainers), "prehook, scale, monitor and posthook initContainers")
+
+	t.Log("Validate initContainers")
+	assert.Equal(t, PreAJob, initContainers[0].Name)
+	assert.Equal(t, ScaleCluster, initContainers[1].Name)
+	assert.Equal(t, []string{CurCmd, ScaleCluster, clusterName}, initContainers[1].Command)
+	assert.Equal(t, MonScale, initContainers[2].Name)
+	assert.Equal(t, PostAJob, initContainers[3].Name)
+	assert.Equal(t, DoneDoneDone, batchJobObj.Spec.Template.Spec.Containers[0].Name)
+	assert.Contains(t, batchJobObj.Annotations, ScaleCluster)
+}
+
+func TestGetBatchJobDryRun(t *testing.T) {
+	clusterCurator := clustercuratorv1.ClusterCurator{
+		ObjectMeta: v1.ObjectMeta{Name: clusterName, Namespace: clusterName},
+		Spec: clustercuratorv1.ClusterCuratorSpec{
+			DesiredCuration: "upgrade",
+			DryRun:          true,
+			Upgrade: clustercuratorv1.UpgradeHooks{
+				DesiredUpdate: "4.14.16",
+				Prehook:       []clustercuratorv1.Hook{{Name: "prehook job"}},
+				OverrideJob:   &runtime.RawExtension{Raw: []byte(`{"metadata":{"name":"upgrade"}}`)},
+			},
+		},
+	}
+
+	batchJobObj := getBatchJob(clusterName, clusterName, imageURI, clusterCurator)
+
+	t.Log("The dry run Job does not run the hooks or the upgrade")
+	assert.Equal(t, []string{DryRun}, getCurationSteps(batchJobObj))
+	assert.Equal(t, DoneDoneDone, batchJobObj.Spec.Template.Spec.Containers[0].Name)
+	assert.Nil(t, getOverrideJob(clusterCurator), "the overrideJob is not used for a dry run")
+
+	t.Log("A posthook retry is not a dry run")
+	clusterCurator.Operation = &clustercuratorv1.Operation{RetryPosthook: "upgradePosthook"}
+	batchJobObj = getBatchJob(clusterName, clusterName, imageURI, clusterCurator)
+	assert.NotContains(t, getCurationSteps(batchJobObj), DryRun)
+}
+
+func TestGetBatchJobRestartsDisruptedPods(t *testing.T) {
+	clusterCurator := clustercuratorv1.ClusterCurator{
+		ObjectMeta: v1.ObjectMeta{Name: clusterName, Namespace: clusterName},
+		Spec:       clustercuratorv1.ClusterCuratorSpec{DesiredCuration: "install"},
+	}
+
+	for _, desiredCuration := range []string{"install", "upgrade", "scale", "destroy"} {
+		clusterCurator.Spec.DesiredCuration = desiredCuration
+		batchJobObj := getBatchJob(clusterName, clusterName, imageURI, clusterCurator)
+
+		assert.Equal(t, int32(CuratorJobBackoffLimit), *batchJobObj.Spec.BackoffLimit,
+			"a failed curator pod is started again: "+desiredCuration)
+		rules := batchJobObj.Spec.PodFailurePolicy.Rules
+		assert.Equal(t, 2, len(rules))
+		t.Log("An evicted pod does not count as a failure")
+		assert.Equal(t, batchv1.PodFailurePolicyActionIgnore, rules[0].Action)
+		assert.Equal(t, corev1.DisruptionTarget, rules[0].OnPodConditions[0].Type)
+		t.Log("A failed step fails the Job")
+		assert.Equal(t, batchv1.PodFailurePolicyActionFailJob, rules[1].Action)
+		assert.Equal(t, []int32{0}, rules[1].OnExitCodes.Values)
+	}
+}
+
+func TestGetBatchJobApprovalRequired(t *testing.T) {
+	clusterCurator := clustercuratorv1.ClusterCurator{
+		ObjectMeta: v1.ObjectMeta{Name: clusterName, Namespace: clusterName},
+		Spec: clustercuratorv1.ClusterCuratorSpec{
+			DesiredCuration: "destroy",
+			Destroy: clustercuratorv1.Hooks{
+				Prehook:          []clustercuratorv1.Hook{{Name: "prehook job"}},
+				ApprovalRequired: true,
+			},
+		},
+	}
+
+	batchJobObj := getBatchJob(clusterName, clusterName, imageURI, clusterCurator)
+
+	t.Log("The Job waits for the approval before the prehooks")
+	assert.Equal(t, []string{WaitForApproval, PreAJob, DeleteClusterDeployment, MonitorDestroy},
+		getCurationSteps(batchJobObj))
+	assert.Contains(t, batchJobObj.Annotations, WaitForApproval)
+
+	t.Log("The Job starts with the wait without prehooks")
+	clusterCurator.Spec.Destroy.Prehook = nil
+	batchJobObj = getBatchJob(clusterName, clusterName, imageURI, clusterCurator)
+	assert.Equal(t, []string{WaitForApproval, DeleteClusterDeployment, MonitorDestroy}, getCurationSteps(batchJobObj))
+
+	t.Log("A scale waits for the approval too")
+	clusterCurator.Spec.DesiredCuration = "scale"
+	clusterCurator.Spec.Scale = clustercuratorv1.ScaleHooks{
+		Prehook:          []clustercuratorv1.Hook{{Name: "prehook job"}},
+		ApprovalRequired: true,
+	}
+	batchJobObj = getBatchJob(clusterName, clusterName, imageURI, clusterCurator)
+	assert.Equal(t, []string{WaitForApproval, PreAJob, ScaleCluster, MonScale}, getCurationSteps(batchJobObj))
+}
+
+func TestCreateLauncherOverrideJobApprovalRequired(t *testing.T) {
+	overrideJob, _ := json.Marshal(&batchv1.Job{
+		ObjectMeta: v1.ObjectMeta{GenerateName: "curator-job-", Namespace: clusterName},
+		Spec: batchv1.JobSpec{Template: corev1.PodTemplateSpec{Spec: corev1.PodSpec{
+			InitContainers: []corev1.Container{{Name: PreAJob, Command: []string{CurCmd, PreAJob}}},
+			Containers:     []corev1.Container{{Name: DoneDoneDone, Command: []string{CurCmd, DoneDoneDone}}},
+		}}},
+	})
+	clusterCurator := &clustercuratorv1.ClusterCurator{
+		ObjectMeta: v1.ObjectMeta{Name: clusterName, Namespace: clusterName},
+		Spec: clustercuratorv1.ClusterCuratorSpec{
+			DesiredCuration: "install",
+			Install: clustercuratorv1.Hooks{
+				OverrideJob:      &runtime.RawExtension{Raw: overrideJob},
+				ApprovalRequired: true,
+			},
+		},
+	}
+
+	s.AddKnownTypes(clustercuratorv1.SchemeBuilder.GroupVersion, &clustercuratorv1.ClusterCurator{})
+	client := clientfake.NewClientBuilder().WithScheme(s).WithRuntimeObjects(clusterCurator).Build()
+	kubeset := fake.NewSimpleClientset()
+
+	assert.Nil(t, NewLauncher(client, kubeset, imageURI, "", *clusterCurator).CreateJob(),
+		"overrideJob is created")
+
+	jobs, _ := kubeset.BatchV1().Jobs(clusterName).List(context.TODO(), v1.ListOptions{})
+	assert.Equal(t, 1, len(jobs.Items), "exactly one Job created")
+	t.Log("The overrideJob waits for the approval before its own steps")
+	assert.Equal(t, []string{WaitForApproval, PreAJob}, getCurationSteps(&jobs.Items[0]))
+	assert.Contains(t, jobs.Items[0].Annotations, WaitForApproval)
+}
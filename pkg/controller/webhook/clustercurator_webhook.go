@@ -0,0 +1,183 @@
+// Copyright Contributors to the Open Cluster Management project.
+package webhook
+
+import (
+	"context"
+	"reflect"
+
+	clustercuratorv1 "github.com/stolostron/cluster-curator-controller/pkg/api/v1beta1"
+	"github.com/stolostron/cluster-curator-controller/pkg/controller/launcher"
+	"github.com/stolostron/cluster-curator-controller/pkg/jobs/hive"
+	"github.com/stolostron/cluster-curator-controller/pkg/jobs/utils"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	"k8s.io/klog/v2"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+)
+
+// +kubebuilder:webhook:path=/validate-cluster-open-cluster-management-io-v1beta1-clustercurator,mutating=false,failurePolicy=fail,sideEffects=None,groups=cluster.open-cluster-management.io,resources=clustercurators,verbs=create;update,versions=v1beta1,name=vclustercurator.cluster.open-cluster-management.io,admissionReviewVersions=v1
+
+// ClusterCuratorValidator rejects ClusterCurator specs that would otherwise only fail once the
+// curator Job runs
+type ClusterCuratorValidator struct{}
+
+func SetupWebhookWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewWebhookManagedBy(mgr, &clustercuratorv1.ClusterCurator{}).
+		WithValidator(&ClusterCuratorValidator{}).
+		Complete()
+}
+
+func (v *ClusterCuratorValidator) ValidateCreate(
+	ctx context.Context, curator *clustercuratorv1.ClusterCurator) (admission.Warnings, error) {
+
+	return nil, validateClusterCurator(curator, nil)
+}
+
+// ValidateUpdate only checks the fields that changed. The curator Job updates the resource while
+// it runs, and must not be blocked by a spec that was accepted before this webhook was deployed.
+// The matchConditions of the webhook skip the updates that leave the spec as it is and the
+// updates of the curator Job, so those are not blocked while the webhook is unavailable.
+func (v *ClusterCuratorValidator) ValidateUpdate(
+	ctx context.Context, oldCurator, newCurator *clustercuratorv1.ClusterCurator) (admission.Warnings, error) {
+
+	return nil, validateClusterCurator(newCurator, oldCurator)
+}
+
+func (v *ClusterCuratorValidator) ValidateDelete(
+	ctx context.Context, curator *clustercuratorv1.ClusterCurator) (admission.Warnings, error) {
+
+	return nil, nil
+}
+
+func validateClusterCurator(curator, oldCurator *clustercuratorv1.ClusterCurator) error {
+	var allErrs field.ErrorList
+	specPath := field.NewPath("spec")
+
+	// changed is true on create, or when the field differs from the old resource on update
+	changed := func(get func(spec *clustercuratorv1.ClusterCuratorSpec) interface{}) bool {
+		return oldCurator == nil || !reflect.DeepEqual(get(&oldCurator.Spec), get(&curator.Spec))
+	}
+
+	if curator.Spec.ProviderCredentialPath != "" && changed(func(spec *clustercuratorv1.ClusterCuratorSpec) interface{} {
+		return spec.ProviderCredentialPath
+	}) {
+		if _, _, err := utils.PathSplitterFromEnv(curator.Spec.ProviderCredentialPath); err != nil {
+			allErrs = append(allErrs, field.Invalid(specPath.Child("providerCredentialPath"),
+				curator.Spec.ProviderCredentialPath, err.Error()))
+		}
+	}
+
+	upgradePath := specPath.Child("upgrade")
+	if changed(func(spec *clustercuratorv1.ClusterCuratorSpec) interface{} {
+		return []string{spec.Upgrade.IntermediateUpdate, spec.Upgrade.DesiredUpdate}
+	}) {
+		if err := hive.ValidateEUSUpgradeSpec(curator); err != nil {
+			allErrs = append(allErrs, field.Invalid(upgradePath.Child("intermediateUpdate"),
+				curator.Spec.Upgrade.IntermediateUpdate, err.Error()))
+		}
+	}
+
+	if len(curator.Spec.Upgrade.NodePoolNames) > 0 &&
+		curator.Spec.Upgrade.UpgradeType == clustercuratorv1.UpgradeTypeControlPlane &&
+		changed(func(spec *clustercuratorv1.ClusterCuratorSpec) interface{} {
+			return []interface{}{spec.Upgrade.NodePoolNames, spec.Upgrade.UpgradeType}
+		}) {
+		allErrs = append(allErrs, field.Invalid(upgradePath.Child("nodePoolNames"),
+			curator.Spec.Upgrade.NodePoolNames,
+			"nodePoolNames can not be used with upgradeType ControlPlane, only the control plane is upgraded"))
+	}
+
+	overrideJobs := []struct {
+		path *field.Path
+		get  func(spec *clustercuratorv1.ClusterCuratorSpec) *runtime.RawExtension
+	}{
+		{specPath.Child("install", "overrideJob"),
+			func(spec *clustercuratorv1.ClusterCuratorSpec) *runtime.RawExtension { return spec.Install.OverrideJob }},
+		{specPath.Child("upgrade", "overrideJob"),
+			func(spec *clustercuratorv1.ClusterCuratorSpec) *runtime.RawExtension { return spec.Upgrade.OverrideJob }},
+		{specPath.Child("destroy", "overrideJob"),
+			func(spec *clustercuratorv1.ClusterCuratorSpec) *runtime.RawExtension { return spec.Destroy.OverrideJob }},
+		{specPath.Child("scale", "overrideJob"),
+			func(spec *clustercuratorv1.ClusterCuratorSpec) *runtime.RawExtension { return spec.Scale.OverrideJob }},
+	}
+	for _, overrideJob := range overrideJobs {
+		get := overrideJob.get
+		if !changed(func(spec *clustercuratorv1.ClusterCuratorSpec) interface{} { return get(spec) }) {
+			continue
+		}
+		if err := launcher.ValidateOverrideJob(get(&curator.Spec)); err != nil {
+			allErrs = append(allErrs, field.Invalid(overrideJob.path, field.OmitValueType{}, err.Error()))
+		}
+	}
+
+	allErrs = append(allErrs, validateHookImages(curator, changed)...)
+
+	if len(allErrs) == 0 {
+		return nil
+	}
+
+	klog.V(2).Infof("Rejected ClusterCurator %v/%v: %v", curator.Namespace, curator.Name, allErrs.ToAggregate())
+	return apierrors.NewInvalid(clustercuratorv1.GroupVersion.WithKind("ClusterCurator").GroupKind(),
+		curator.Name, allErrs)
+}
+
+// validateHookImages rejects the Container hook images and AnsibleJob runner images that the
+// HOOK_IMAGE_ALLOWLIST of the controller does not allow. The curator checks them again before it
+// runs a hook, but the cluster-installer ServiceAccount it runs as can create any Job.
+func validateHookImages(
+	curator *clustercuratorv1.ClusterCurator,
+	changed func(get func(spec *clustercuratorv1.ClusterCuratorSpec) interface{}) bool) field.ErrorList {
+
+	var allErrs field.ErrorList
+	specPath := field.NewPath("spec")
+	hookLists := []struct {
+		path *field.Path
+		get  func(spec *clustercuratorv1.ClusterCuratorSpec) []clustercuratorv1.Hook
+	}{
+		{specPath.Child("install", "prehook"),
+			func(spec *clustercuratorv1.ClusterCuratorSpec) []clustercuratorv1.Hook { return spec.Install.Prehook }},
+		{specPath.Child("install", "posthook"),
+			func(spec *clustercuratorv1.ClusterCuratorSpec) []clustercuratorv1.Hook { return spec.Install.Posthook }},
+		{specPath.Child("install", "onFailure"),
+			func(spec *clustercuratorv1.ClusterCuratorSpec) []clustercuratorv1.Hook { return spec.Install.OnFailure }},
+		{specPath.Child("upgrade", "prehook"),
+			func(spec *clustercuratorv1.ClusterCuratorSpec) []clustercuratorv1.Hook { return spec.Upgrade.Prehook }},
+		{specPath.Child("upgrade", "posthook"),
+			func(spec *clustercuratorv1.ClusterCuratorSpec) []clustercuratorv1.Hook { return spec.Upgrade.Posthook }},
+		{specPath.Child("upgrade", "onFailure"),
+			func(spec *clustercuratorv1.ClusterCuratorSpec) []clustercuratorv1.Hook { return spec.Upgrade.OnFailure }},
+		{specPath.Child("destroy", "prehook"),
+			func(spec *clustercuratorv1.ClusterCuratorSpec) []clustercuratorv1.Hook { return spec.Destroy.Prehook }},
+		{specPath.Child("destroy", "posthook"),
+			func(spec *clustercuratorv1.ClusterCuratorSpec) []clustercuratorv1.Hook { return spec.Destroy.Posthook }},
+		{specPath.Child("destroy", "onFailure"),
+			func(spec *clustercuratorv1.ClusterCuratorSpec) []clustercuratorv1.Hook { return spec.Destroy.OnFailure }},
+		{specPath.Child("scale", "prehook"),
+			func(spec *clustercuratorv1.ClusterCuratorSpec) []clustercuratorv1.Hook { return spec.Scale.Prehook }},
+		{specPath.Child("scale", "posthook"),
+			func(spec *clustercuratorv1.ClusterCuratorSpec) []clustercuratorv1.Hook { return spec.Scale.Posthook }},
+		{specPath.Child("scale", "onFailure"),
+			func(spec *clustercuratorv1.ClusterCuratorSpec) []clustercuratorv1.Hook { return spec.Scale.OnFailure }},
+	}
+
+	allowlist := utils.GetHookImageAllowlist()
+	for _, hookList := range hookLists {
+		get := hookList.get
+		if !changed(func(spec *clustercuratorv1.ClusterCuratorSpec) interface{} { return get(spec) }) {
+			continue
+		}
+		for i, hook := range get(&curator.Spec) {
+			if hook.Type == clustercuratorv1.HookTypeContainer && !utils.IsHookImageAllowed(hook.Image, allowlist) {
+				allErrs = append(allErrs, field.Forbidden(hookList.path.Index(i).Child("image"),
+					"image "+hook.Image+" is not allowed by the "+utils.HookImageAllowlistEnv+" of the controller"))
+			}
+			if hook.RunnerImage != "" && !utils.IsHookImageAllowed(hook.RunnerImage, allowlist) {
+				allErrs = append(allErrs, field.Forbidden(hookList.path.Index(i).Child("runner_image"),
+					"image "+hook.RunnerImage+" is not allowed by the "+utils.HookImageAllowlistEnv+" of the controller"))
+			}
+		}
+	}
+	return allErrs
+}
@@ -0,0 +1,250 @@
+// Copyright Contributors to the Open Cluster Management project.
+package webhook
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"testing"
+
+	clustercuratorv1 "github.com/stolostron/cluster-curator-controller/pkg/api/v1beta1"
+	"github.com/stolostron/cluster-curator-controller/pkg/jobs/utils"
+	"github.com/stretchr/testify/assert"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+const clusterName = "my-cluster"
+
+func getOverrideJob(command []string) *runtime.RawExtension {
+	raw, _ := json.Marshal(&batchv1.Job{
+		ObjectMeta: v1.ObjectMeta{Name: "my-job", Namespace: clusterName},
+		Spec: batchv1.JobSpec{Template: corev1.PodTemplateSpec{Spec: corev1.PodSpec{
+			Containers: []corev1.Container{{Name: "my-container", Command: command}},
+		}}},
+	})
+	return &runtime.RawExtension{Raw: raw}
+}
+
+func TestValidateCreate(t *testing.T) {
+	testcases := []struct {
+		name      string
+		spec      clustercuratorv1.ClusterCuratorSpec
+		expectErr string
+	}{
+		{
+			name: "valid install",
+			spec: clustercuratorv1.ClusterCuratorSpec{
+				DesiredCuration:        "install",
+				ProviderCredentialPath: "default/provider-secret",
+				Install:                clustercuratorv1.Hooks{OverrideJob: getOverrideJob([]string{"./curator", "done"})},
+			},
+		},
+		{
+			name: "malformed providerCredentialPath",
+			spec: clustercuratorv1.ClusterCuratorSpec{
+				DesiredCuration:        "install",
+				ProviderCredentialPath: "provider-secret",
+			},
+			expectErr: "spec.providerCredentialPath",
+		},
+		{
+			name: "valid EUS upgrade",
+			spec: clustercuratorv1.ClusterCuratorSpec{
+				DesiredCuration: "upgrade",
+				Upgrade: clustercuratorv1.UpgradeHooks{
+					IntermediateUpdate: "4.13.37",
+					DesiredUpdate:      "4.14.16",
+				},
+			},
+		},
+		{
+			name: "EUS upgrade skips a minor version",
+			spec: clustercuratorv1.ClusterCuratorSpec{
+				DesiredCuration: "upgrade",
+				Upgrade: clustercuratorv1.UpgradeHooks{
+					IntermediateUpdate: "4.13.37",
+					DesiredUpdate:      "4.15.1",
+				},
+			},
+			expectErr: "Minor version EUS to EUS upgrade must be continuous",
+		},
+		{
+			name: "EUS upgrade desiredUpdate not greater",
+			spec: clustercuratorv1.ClusterCuratorSpec{
+				DesiredCuration: "upgrade",
+				Upgrade: clustercuratorv1.UpgradeHooks{
+					IntermediateUpdate: "4.14.16",
+					DesiredUpdate:      "4.13.37",
+				},
+			},
+			expectErr: "must be greater than IntermediateUpdate",
+		},
+		{
+			name: "EUS upgrade without desiredUpdate",
+			spec: clustercuratorv1.ClusterCuratorSpec{
+				DesiredCuration: "upgrade",
+				Upgrade:         clustercuratorv1.UpgradeHooks{IntermediateUpdate: "4.13.37"},
+			},
+			expectErr: "DesiredUpdate is required",
+		},
+		{
+			name: "nodePoolNames with ControlPlane upgrade",
+			spec: clustercuratorv1.ClusterCuratorSpec{
+				DesiredCuration: "upgrade",
+				Upgrade: clustercuratorv1.UpgradeHooks{
+					DesiredUpdate: "4.14.16",
+					UpgradeType:   clustercuratorv1.UpgradeTypeControlPlane,
+					NodePoolNames: []string{"nodepool-1"},
+				},
+			},
+			expectErr: "spec.upgrade.nodePoolNames",
+		},
+		{
+			name: "nodePoolNames with NodePools upgrade",
+			spec: clustercuratorv1.ClusterCuratorSpec{
+				DesiredCuration: "upgrade",
+				Upgrade: clustercuratorv1.UpgradeHooks{
+					DesiredUpdate: "4.14.16",
+					UpgradeType:   clustercuratorv1.UpgradeTypeNodePools,
+					NodePoolNames: []string{"nodepool-1"},
+				},
+			},
+		},
+		{
+			name: "overrideJob runs a non curator command",
+			spec: clustercuratorv1.ClusterCuratorSpec{
+				DesiredCuration: "destroy",
+				Destroy:         clustercuratorv1.Hooks{OverrideJob: getOverrideJob([]string{"/bin/sh", "-c", "env"})},
+			},
+			expectErr: "spec.destroy.overrideJob",
+		},
+		{
+			name: "overrideJob container without a command",
+			spec: clustercuratorv1.ClusterCuratorSpec{
+				Scale: clustercuratorv1.ScaleHooks{OverrideJob: getOverrideJob(nil)},
+			},
+			expectErr: "must invoke ./curator",
+		},
+		{
+			name: "overrideJob is not a Job",
+			spec: clustercuratorv1.ClusterCuratorSpec{
+				Upgrade: clustercuratorv1.UpgradeHooks{OverrideJob: &runtime.RawExtension{Raw: []byte(`"a string"`)}},
+			},
+			expectErr: "spec.upgrade.overrideJob",
+		},
+	}
+
+	validator := &ClusterCuratorValidator{}
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			curator := &clustercuratorv1.ClusterCurator{
+				ObjectMeta: v1.ObjectMeta{Name: clusterName, Namespace: clusterName},
+				Spec:       tc.spec,
+			}
+
+			_, err := validator.ValidateCreate(context.TODO(), curator)
+			if tc.expectErr == "" {
+				assert.Nil(t, err, "err nil, when the ClusterCurator is valid")
+				return
+			}
+			assert.NotNil(t, err, "err not nil, when the ClusterCurator is invalid")
+			assert.True(t, apierrors.IsInvalid(err), "err is an Invalid status error")
+			assert.Contains(t, err.Error(), tc.expectErr)
+		})
+	}
+}
+
+func TestValidateUpdateOnlyChangedFields(t *testing.T) {
+	oldCurator := &clustercuratorv1.ClusterCurator{
+		ObjectMeta: v1.ObjectMeta{Name: clusterName, Namespace: clusterName},
+		Spec: clustercuratorv1.ClusterCuratorSpec{
+			DesiredCuration:        "install",
+			CuratingJob:            "curator-job-12345",
+			ProviderCredentialPath: "provider-secret",
+		},
+	}
+
+	validator := &ClusterCuratorValidator{}
+
+	t.Log("An invalid field that is not changed does not block the curator Job")
+	newCurator := oldCurator.DeepCopy()
+	newCurator.Spec.CuratingJob = ""
+	newCurator.Spec.DesiredCuration = ""
+	_, err := validator.ValidateUpdate(context.TODO(), oldCurator, newCurator)
+	assert.Nil(t, err, "err nil, when the invalid field is unchanged")
+
+	t.Log("A changed field is validated")
+	newCurator.Spec.ProviderCredentialPath = "default/"
+	_, err = validator.ValidateUpdate(context.TODO(), oldCurator, newCurator)
+	assert.NotNil(t, err, "err not nil, when the changed field is invalid")
+	assert.Contains(t, err.Error(), "spec.providerCredentialPath")
+
+	t.Log("An overrideJob is validated when it changes")
+	newCurator = oldCurator.DeepCopy()
+	newCurator.Spec.Install.OverrideJob = getOverrideJob([]string{"/bin/sh"})
+	_, err = validator.ValidateUpdate(context.TODO(), oldCurator, newCurator)
+	assert.NotNil(t, err, "err not nil, when the overrideJob is rejected")
+	assert.Contains(t, err.Error(), "spec.install.overrideJob")
+}
+
+func TestValidateHookImages(t *testing.T) {
+	os.Setenv(utils.HookImageAllowlistEnv, "quay.io/my-org/")
+	defer os.Unsetenv(utils.HookImageAllowlistEnv)
+
+	curator := &clustercuratorv1.ClusterCurator{
+		ObjectMeta: v1.ObjectMeta{Name: clusterName, Namespace: clusterName},
+		Spec: clustercuratorv1.ClusterCuratorSpec{
+			DesiredCuration: "install",
+			Install: clustercuratorv1.Hooks{Posthook: []clustercuratorv1.Hook{{
+				Name:  "Update the CMDB",
+				Type:  clustercuratorv1.HookTypeContainer,
+				Image: "quay.io/my-org/cmdb-hook:1.0",
+			}}},
+		},
+	}
+	validator := &ClusterCuratorValidator{}
+
+	_, err := validator.ValidateCreate(context.TODO(), curator)
+	assert.Nil(t, err, "err nil, when the image is in the allowlist")
+
+	oldCurator := curator.DeepCopy()
+	curator.Spec.Install.Posthook[0].Image = "docker.io/attacker/shell:latest"
+	_, err = validator.ValidateUpdate(context.TODO(), oldCurator, curator)
+	assert.NotNil(t, err, "err not nil, when the image is not in the allowlist")
+	assert.Contains(t, err.Error(), "spec.install.posthook[0].image")
+
+	curator.Spec.Install.Posthook[0] = clustercuratorv1.Hook{Name: "Deploy", RunnerImage: "docker.io/attacker/runner"}
+	_, err = validator.ValidateCreate(context.TODO(), curator)
+	assert.NotNil(t, err, "err not nil, when the runner image is not in the allowlist")
+	assert.Contains(t, err.Error(), "spec.install.posthook[0].runner_image")
+
+	curator.Spec.Install.Posthook = nil
+	curator.Spec.Scale.OnFailure = []clustercuratorv1.Hook{{
+		Name:  "Scale back",
+		Type:  clustercuratorv1.HookTypeContainer,
+		Image: "docker.io/attacker/shell:latest",
+	}}
+	_, err = validator.ValidateCreate(context.TODO(), curator)
+	assert.NotNil(t, err, "err not nil, when the image of a scale onFailure hook is not in the allowlist")
+	assert.Contains(t, err.Error(), "spec.scale.onFailure[0].image")
+
+	t.Log("A hook list that is not changed does not block the curator Job")
+	newCurator := curator.DeepCopy()
+	newCurator.Spec.CuratingJob = "curator-job-12345"
+	_, err = validator.ValidateUpdate(context.TODO(), curator, newCurator)
+	assert.Nil(t, err, "err nil, when the hooks are unchanged")
+}
+
+func TestValidateDelete(t *testing.T) {
+	curator := &clustercuratorv1.ClusterCurator{
+		ObjectMeta: v1.ObjectMeta{Name: clusterName, Namespace: clusterName},
+		Spec:       clustercuratorv1.ClusterCuratorSpec{ProviderCredentialPath: "provider-secret"},
+	}
+
+	_, err := (&ClusterCuratorValidator{}).ValidateDelete(context.TODO(), curator)
+	assert.Nil(t, err, "err nil, deletes are always allowed")
+}
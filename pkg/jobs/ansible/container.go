@@ -0,0 +1,223 @@
+// Copyright Contributors to the Open Cluster Management project.
+package ansible
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"time"
+
+	clustercuratorv1 "github.com/stolostron/cluster-curator-controller/pkg/api/v1beta1"
+	"github.com/stolostron/cluster-curator-controller/pkg/jobs/utils"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	utilrand "k8s.io/apimachinery/pkg/util/rand"
+	"k8s.io/klog/v2"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const HookContextDir = "/etc/curator"
+const HookContextFile = "hook-context.json"
+const HookContextPath = HookContextDir + "/" + HookContextFile
+
+// RunContainerJob creates the Kubernetes Job of an attempt of a Container hook. The extra_vars of
+// the hook, with its extraVarsFrom, the cluster_deployment, install_config and cluster_info, or
+// hosted_cluster and node_pools, of the cluster, the curation and managed_cluster and the
+// hook_outputs of the curation are mounted as a JSON file at HookContextPath, from a Secret owned
+// by the Job. The Job is owned by the curator and labeled with the curation run.
+func RunContainerJob(
+	client client.Client,
+	curator *clustercuratorv1.ClusterCurator,
+	jobtype string,
+	hookToRun clustercuratorv1.Hook,
+	attempt int) (*batchv1.Job, error) {
+
+	klog.V(2).Info("* Run " + jobtype + " Container hook " + hookToRun.Name)
+
+	if hookToRun.Image == "" {
+		return nil, errors.New("The Container hook " + hookToRun.Name + " has no image")
+	}
+	if !utils.IsHookImageAllowed(hookToRun.Image, utils.GetHookImageAllowlist()) {
+		return nil, errors.New("The image " + hookToRun.Image + " of the Container hook " + hookToRun.Name +
+			" is not allowed by the " + utils.HookImageAllowlistEnv + " of the controller")
+	}
+
+	hookContext := map[string]interface{}{}
+	if hookToRun.ExtraVars != nil {
+		if err := json.Unmarshal(hookToRun.ExtraVars.Raw, &hookContext); err != nil {
+			return nil, err
+		}
+	}
+	if err := addExtraVarsFrom(client, curator.Namespace, hookToRun, hookContext); err != nil {
+		return nil, err
+	}
+	if err := addClusterContext(client, curator, hookContext); err != nil {
+		return nil, err
+	}
+	if err := addCurationContext(client, curator, jobtype, attempt, hookContext); err != nil {
+		return nil, err
+	}
+	if err := addHookOutputs(client, curator, hookContext); err != nil {
+		return nil, err
+	}
+	rawContext, err := json.Marshal(hookContext)
+	if err != nil {
+		return nil, err
+	}
+
+	job := getContainerJob(jobtype, hookToRun, curator.Namespace)
+	utils.SetCurationRunOwner(job, curator)
+
+	klog.V(0).Info("Creating Job " + job.Name + " in namespace " + curator.Namespace)
+	if err := client.Create(context.Background(), job); err != nil {
+		return nil, err
+	}
+
+	// The Job is created first so the Secret can be garbage collected with it
+	secret := &corev1.Secret{
+		ObjectMeta: v1.ObjectMeta{
+			Name:      job.Name,
+			Namespace: job.Namespace,
+			Labels:    job.Labels,
+			OwnerReferences: []v1.OwnerReference{{
+				APIVersion: "batch/v1",
+				Kind:       "Job",
+				Name:       job.Name,
+				UID:        job.UID,
+			}},
+		},
+		Data: map[string][]byte{HookContextFile: rawContext},
+	}
+	if err := client.Create(context.Background(), secret); err != nil {
+		// Without its Secret the pod of the Job can not start, so the Job is removed
+		utils.LogWarning(deleteHookJob(client, job.Namespace, job.Name))
+		return nil, err
+	}
+
+	klog.V(2).Info("Created Job ✓")
+	return job, nil
+}
+
+func getContainerJob(jobtype string, hookToRun clustercuratorv1.Hook, namespace string) *batchv1.Job {
+	var ttlf int32 = 3600
+	automount := false
+	name := jobtype + "hook-" + utilrand.String(5)
+
+	return &batchv1.Job{
+		ObjectMeta: v1.ObjectMeta{
+			Name:      name,
+			Namespace: namespace,
+			Labels: map[string]string{
+				"open-cluster-management": "curator-hook",
+			},
+			Annotations: map[string]string{
+				"jobtype": jobtype,
+				"hook":    hookToRun.Name,
+			},
+		},
+		Spec: batchv1.JobSpec{
+			BackoffLimit:            new(int32),
+			TTLSecondsAfterFinished: &ttlf,
+			Template: corev1.PodTemplateSpec{
+				Spec: corev1.PodSpec{
+					RestartPolicy:                corev1.RestartPolicyNever,
+					AutomountServiceAccountToken: &automount,
+					Containers: []corev1.Container{{
+						Name:    "hook",
+						Image:   hookToRun.Image,
+						Command: hookToRun.Command,
+						Args:    hookToRun.Args,
+						Env: []corev1.EnvVar{{
+							Name:  "HOOK_CONTEXT",
+							Value: HookContextPath,
+						}},
+						VolumeMounts: []corev1.VolumeMount{{
+							Name:      "hook-context",
+							MountPath: HookContextDir,
+							ReadOnly:  true,
+						}},
+					}},
+					Volumes: []corev1.Volume{{
+						Name: "hook-context",
+						VolumeSource: corev1.VolumeSource{
+							Secret: &corev1.SecretVolumeSource{SecretName: name},
+						},
+					}},
+				},
+			},
+		},
+	}
+}
+
+// runContainerHookAttempt creates the Job of an attempt and waits for it to finish, a timed out or
+// cancelled Job is deleted. The attempt is nil when the Job could not be created.
+func runContainerHookAttempt(
+	client client.Client,
+	curator *clustercuratorv1.ClusterCurator,
+	jobType string,
+	stepName string,
+	hook clustercuratorv1.Hook,
+	attempt int,
+	timeout time.Duration,
+	cancel <-chan struct{}) (*clustercuratorv1.HookAttempt, error) {
+
+	job, err := RunContainerJob(client, curator, jobType, hook, attempt)
+	if err != nil {
+		return nil, err
+	}
+
+	hookAttempt := &clustercuratorv1.HookAttempt{
+		Hook:    hook.Name,
+		Attempt: attempt,
+		Job:     job.Name,
+		Result:  clustercuratorv1.CurationPhaseRunning,
+	}
+	utils.LogWarning(utils.RecordHookAttempt(client, curator.Name, curator.Namespace, stepName, *hookAttempt))
+
+	err = monitorContainerJob(client, job, timeout, cancel)
+	if errors.Is(err, errTimedOut) || errors.Is(err, errCancelled) {
+		// The Job is removed, so it does not keep running next to the retry or after the parallel
+		// group failed
+		utils.LogWarning(deleteHookJob(client, job.Namespace, job.Name))
+	}
+	return hookAttempt, err
+}
+
+// monitorContainerJob waits for the Job of a Container hook to finish, a timeout of zero waits
+// until it does. It stops once cancel is closed.
+func monitorContainerJob(
+	client client.Client, job *batchv1.Job, timeout time.Duration, cancel <-chan struct{}) error {
+
+	start := time.Now()
+	resource := "Job " + job.Namespace + "/" + job.Name
+	klog.V(0).Info("* Monitoring " + resource)
+
+	for {
+		if err := client.Get(context.Background(), types.NamespacedName{
+			Namespace: job.Namespace,
+			Name:      job.Name,
+		}, job); err != nil {
+			return err
+		}
+
+		if job.Status.Succeeded > 0 {
+			klog.V(2).Infof("%v finished successfully ✓", resource)
+			return nil
+		}
+		for _, condition := range job.Status.Conditions {
+			if condition.Type == batchv1.JobFailed && condition.Status == corev1.ConditionTrue {
+				return errors.New(resource + " failed: " + condition.Message)
+			}
+		}
+
+		klog.V(2).Infof("%v is still running", resource)
+		if err := checkTimeout(resource, start, timeout); err != nil {
+			return err
+		}
+		if err := waitForNextPoll(resource, cancel); err != nil {
+			return err
+		}
+	}
+}
@@ -0,0 +1,235 @@
+// Copyright Contributors to the Open Cluster Management project.
+package ansible
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"testing"
+	"time"
+
+	hivev1 "github.com/openshift/hive/apis/hive/v1"
+	clustercuratorv1 "github.com/stolostron/cluster-curator-controller/pkg/api/v1beta1"
+	"github.com/stolostron/cluster-curator-controller/pkg/jobs/utils"
+	"github.com/stretchr/testify/assert"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	clientfake "sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
+)
+
+const HookImage = "quay.io/my-org/cmdb-hook:1.0"
+
+func getContainerHookClusterCurator() *clustercuratorv1.ClusterCurator {
+	cc := getClusterCurator()
+	cc.Spec.Install.Posthook = []clustercuratorv1.Hook{{
+		Name:    "Update the CMDB",
+		Type:    clustercuratorv1.HookTypeContainer,
+		Image:   HookImage,
+		Command: []string{"/bin/update-cmdb"},
+		ExtraVars: &runtime.RawExtension{
+			Raw: []byte(`{"variable1": "1"}`),
+		},
+	}}
+	cc.Status.Steps = []clustercuratorv1.CurationStep{{
+		Name:  "posthook-ansiblejob",
+		State: clustercuratorv1.CurationPhaseRunning,
+	}}
+	return cc
+}
+
+func TestRunContainerJobNotAllowed(t *testing.T) {
+
+	cc := getContainerHookClusterCurator()
+	os.Setenv(utils.HookImageAllowlistEnv, "quay.io/other-org/")
+	defer os.Unsetenv(utils.HookImageAllowlistEnv)
+
+	client := clientfake.NewClientBuilder().WithScheme(s).Build()
+
+	_, err := RunContainerJob(client, cc, POSTHOOK, cc.Spec.Install.Posthook[0], 1)
+	assert.NotNil(t, err, "err not nil, when the image is not in the allowlist")
+	assert.Contains(t, err.Error(), "is not allowed")
+
+	cc.Spec.Install.Posthook[0].Image = ""
+	_, err = RunContainerJob(client, cc, POSTHOOK, cc.Spec.Install.Posthook[0], 1)
+	assert.NotNil(t, err, "err not nil, when the Container hook has no image")
+}
+
+func TestJobContainerHook(t *testing.T) {
+
+	cc := getContainerHookClusterCurator()
+	os.Setenv(EnvJobType, POSTHOOK)
+	os.Setenv(utils.HookImageAllowlistEnv, "quay.io/my-org/")
+	defer os.Unsetenv(utils.HookImageAllowlistEnv)
+
+	s.AddKnownTypes(clustercuratorv1.SchemeBuilder.GroupVersion, &clustercuratorv1.ClusterCurator{})
+	s.AddKnownTypes(hivev1.SchemeBuilder.GroupVersion, &hivev1.ClusterDeployment{}, &hivev1.MachinePool{})
+	s.AddKnownTypes(corev1.SchemeGroupVersion, &corev1.Secret{})
+	s.AddKnownTypes(batchv1.SchemeGroupVersion, &batchv1.Job{})
+	client := clientfake.NewClientBuilder().WithScheme(s).WithRuntimeObjects(
+		cc, genClusterDeployment(), genMachinePool()).Build()
+
+	go func() {
+		jobName := ""
+		for jobName == "" {
+			time.Sleep(utils.PauseFiveSeconds)
+			curator := &clustercuratorv1.ClusterCurator{}
+			_ = client.Get(
+				context.Background(),
+				types.NamespacedName{Namespace: ClusterName, Name: ClusterName},
+				curator)
+			if len(curator.Status.Steps[0].HookAttempts) > 0 {
+				jobName = curator.Status.Steps[0].HookAttempts[0].Job
+			}
+		}
+
+		job := &batchv1.Job{}
+		assert.Nil(t, client.Get(context.Background(),
+			types.NamespacedName{Namespace: ClusterName, Name: jobName}, job))
+		assert.Equal(t, HookImage, job.Spec.Template.Spec.Containers[0].Image)
+		assert.Equal(t, []string{"/bin/update-cmdb"}, job.Spec.Template.Spec.Containers[0].Command)
+		assert.False(t, *job.Spec.Template.Spec.AutomountServiceAccountToken, "the hook gets no API token")
+		assert.Equal(t, jobName, job.Spec.Template.Spec.Volumes[0].Secret.SecretName)
+
+		secret := &corev1.Secret{}
+		assert.Nil(t, client.Get(context.Background(),
+			types.NamespacedName{Namespace: ClusterName, Name: jobName}, secret))
+		assert.Equal(t, "Job", secret.OwnerReferences[0].Kind, "the Secret is removed with the Job")
+		hookContext := map[string]interface{}{}
+		assert.Nil(t, json.Unmarshal(secret.Data[HookContextFile], &hookContext))
+		assert.Equal(t, "1", hookContext["variable1"], "the hook extra_vars are in the context")
+		assert.Contains(t, hookContext, "cluster_deployment")
+
+		job.Status.Succeeded = 1
+		assert.Nil(t, client.Status().Update(context.Background(), job))
+		t.Logf("Job %v marked successful", jobName)
+	}()
+
+	assert.Nil(t, Job(client, cc), "err nil, when the Container hook Job succeeded")
+
+	curator := &clustercuratorv1.ClusterCurator{}
+	assert.Nil(t, client.Get(context.Background(),
+		types.NamespacedName{Namespace: ClusterName, Name: ClusterName}, curator))
+	assert.Equal(t, clustercuratorv1.CurationPhaseSucceeded, curator.Status.Steps[0].HookAttempts[0].Result)
+}
+
+func TestRunContainerJobSecretFailed(t *testing.T) {
+
+	cc := getContainerHookClusterCurator()
+	os.Setenv(utils.HookImageAllowlistEnv, "quay.io/my-org/")
+	defer os.Unsetenv(utils.HookImageAllowlistEnv)
+
+	s.AddKnownTypes(clustercuratorv1.SchemeBuilder.GroupVersion, &clustercuratorv1.ClusterCurator{})
+	s.AddKnownTypes(hivev1.SchemeBuilder.GroupVersion, &hivev1.ClusterDeployment{}, &hivev1.MachinePool{})
+	s.AddKnownTypes(corev1.SchemeGroupVersion, &corev1.Secret{})
+	s.AddKnownTypes(batchv1.SchemeGroupVersion, &batchv1.Job{}, &batchv1.JobList{})
+	client := clientfake.NewClientBuilder().WithScheme(s).WithRuntimeObjects(
+		cc, genClusterDeployment(), genMachinePool()).WithInterceptorFuncs(interceptor.Funcs{
+		Create: func(ctx context.Context, c client.WithWatch, obj client.Object, opts ...client.CreateOption) error {
+			if _, ok := obj.(*corev1.Secret); ok {
+				return errors.New("secrets is forbidden")
+			}
+			return c.Create(ctx, obj, opts...)
+		}}).Build()
+
+	_, err := RunContainerJob(client, cc, POSTHOOK, cc.Spec.Install.Posthook[0], 1)
+	assert.NotNil(t, err, "err not nil, when the context Secret can not be created")
+
+	jobs := &batchv1.JobList{}
+	assert.Nil(t, client.List(context.Background(), jobs))
+	assert.Equal(t, 0, len(jobs.Items), "the Job without its Secret is deleted")
+}
+
+func TestRunContainerHookAttemptTimedOut(t *testing.T) {
+
+	cc := getContainerHookClusterCurator()
+	os.Setenv(utils.HookImageAllowlistEnv, "quay.io/my-org/")
+	defer os.Unsetenv(utils.HookImageAllowlistEnv)
+
+	s.AddKnownTypes(clustercuratorv1.SchemeBuilder.GroupVersion, &clustercuratorv1.ClusterCurator{})
+	s.AddKnownTypes(hivev1.SchemeBuilder.GroupVersion, &hivev1.ClusterDeployment{}, &hivev1.MachinePool{})
+	s.AddKnownTypes(corev1.SchemeGroupVersion, &corev1.Secret{})
+	s.AddKnownTypes(batchv1.SchemeGroupVersion, &batchv1.Job{}, &batchv1.JobList{})
+	client := clientfake.NewClientBuilder().WithScheme(s).WithRuntimeObjects(
+		cc, genClusterDeployment(), genMachinePool()).Build()
+
+	hookAttempt, err := runContainerHookAttempt(
+		client, cc, POSTHOOK, "posthook-ansiblejob", cc.Spec.Install.Posthook[0], 1, time.Millisecond, nil)
+	assert.NotNil(t, err, "err not nil, when the Job does not finish in time")
+	assert.Contains(t, err.Error(), "timed out")
+	assert.NotEqual(t, "", hookAttempt.Job)
+
+	jobs := &batchv1.JobList{}
+	assert.Nil(t, client.List(context.Background(), jobs))
+	assert.Equal(t, 0, len(jobs.Items), "the timed out Job is deleted")
+}
+
+func TestMonitorContainerJobFailed(t *testing.T) {
+
+	job := &batchv1.Job{
+		ObjectMeta: v1.ObjectMeta{
+			Name:      "posthookhook-abcde",
+			Namespace: ClusterName,
+		},
+		Status: batchv1.JobStatus{
+			Conditions: []batchv1.JobCondition{{
+				Type:    batchv1.JobFailed,
+				Status:  corev1.ConditionTrue,
+				Message: "Job has reached the specified backoff limit",
+			}},
+		},
+	}
+
+	s.AddKnownTypes(batchv1.SchemeGroupVersion, &batchv1.Job{})
+	client := clientfake.NewClientBuilder().WithScheme(s).WithRuntimeObjects(job).Build()
+
+	err := monitorContainerJob(client, job, 0, nil)
+	assert.NotNil(t, err, "err not nil, when the Job failed")
+	assert.Contains(t, err.Error(), "backoff limit")
+
+	job.Status = batchv1.JobStatus{}
+	assert.Nil(t, client.Status().Update(context.Background(), job))
+	err = monitorContainerJob(client, job, time.Millisecond, nil)
+	assert.NotNil(t, err, "err not nil, when the Job does not finish in time")
+	assert.Contains(t, err.Error(), "timed out")
+
+	cancel := make(chan struct{})
+	close(cancel)
+	err = monitorContainerJob(client, job, 0, cancel)
+	assert.NotNil(t, err, "err not nil, when cancel is closed")
+	assert.Contains(t, err.Error(), "cancelled")
+}
+
+func TestDeleteAnsibleJobContainerHook(t *testing.T) {
+
+	cc := getClusterCurator()
+	cc.Status.Steps = []clustercuratorv1.CurationStep{{
+		Name: "posthook-ansiblejob",
+		HookAttempts: []clustercuratorv1.HookAttempt{
+			{Hook: "Update the CMDB", Attempt: 1, Job: "posthookhook-1", Result: clustercuratorv1.CurationPhaseRunning},
+			{Hook: "Notify", Attempt: 1, Job: "posthookhook-2", Result: clustercuratorv1.CurationPhaseFailed},
+		},
+	}}
+
+	s.AddKnownTypes(batchv1.SchemeGroupVersion, &batchv1.Job{})
+	objs := []runtime.Object{}
+	for _, name := range []string{"posthookhook-1", "posthookhook-2"} {
+		objs = append(objs, &batchv1.Job{
+			ObjectMeta: v1.ObjectMeta{Name: name, Namespace: ClusterName},
+		})
+	}
+	client := clientfake.NewClientBuilder().WithScheme(s).WithRuntimeObjects(objs...).Build()
+
+	assert.Nil(t, DeleteAnsibleJob(client, cc), "err nil, when the running Jobs are deleted")
+
+	for name, found := range map[string]bool{"posthookhook-1": false, "posthookhook-2": true} {
+		job := &batchv1.Job{}
+		err := client.Get(context.Background(), types.NamespacedName{Namespace: ClusterName, Name: name}, job)
+		assert.Equal(t, found, err == nil, "only the running Jobs are deleted: "+name)
+	}
+}
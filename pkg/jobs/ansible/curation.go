@@ -0,0 +1,113 @@
+// Copyright Contributors to the Open Cluster Management project.
+package ansible
+
+import (
+	"context"
+
+	clustercuratorv1 "github.com/stolostron/cluster-curator-controller/pkg/api/v1beta1"
+	"github.com/stolostron/cluster-curator-controller/pkg/jobs/utils"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/klog/v2"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// The extra_vars keys holding the facts of the curation and the labels and ClusterClaims of the
+// ManagedCluster
+const CurationKey = "curation"
+const ManagedClusterKey = "managed_cluster"
+
+// VersionClaim is the ClusterClaim holding the OpenShift version of a managed cluster
+const VersionClaim = "version.openshift.io"
+
+// getManagedCluster returns the name, labels and ClusterClaims of the ManagedCluster of the curator,
+// the ClusterClaims as a map of their name to their value
+func getManagedCluster(client client.Client, clusterName string) (map[string]interface{}, error) {
+	managedCluster := &unstructured.Unstructured{}
+	managedCluster.SetAPIVersion("cluster.open-cluster-management.io/v1")
+	managedCluster.SetKind("ManagedCluster")
+	if err := client.Get(context.Background(), types.NamespacedName{Name: clusterName}, managedCluster); err != nil {
+		return nil, err
+	}
+
+	labels := map[string]interface{}{}
+	for key, value := range managedCluster.GetLabels() {
+		labels[key] = value
+	}
+
+	claims := map[string]interface{}{}
+	clusterClaims, _, _ := unstructured.NestedSlice(managedCluster.Object, "status", "clusterClaims")
+	for _, claim := range clusterClaims {
+		claimMap, ok := claim.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		if name, ok := claimMap["name"].(string); ok {
+			claims[name] = claimMap["value"]
+		}
+	}
+
+	return map[string]interface{}{
+		"name":          managedCluster.GetName(),
+		"labels":        labels,
+		"clusterClaims": claims,
+	}, nil
+}
+
+// getCurationFacts returns the type and run of the curation, the attempt of the hook and whether it
+// is a retry, the phase of the hook, and the desired version of an upgrade
+func getCurationFacts(
+	curator *clustercuratorv1.ClusterCurator, jobType string, attempt int) map[string]interface{} {
+
+	curationType := utils.GetEffectiveCuration(curator)
+	isRetry := attempt > 1 || (curator.Operation != nil &&
+		(curator.Operation.RetryPosthook != "" || curator.Operation.ResumeFailed))
+
+	facts := map[string]interface{}{
+		"type":    curationType,
+		"runID":   curator.Spec.CuratingJob,
+		"attempt": attempt,
+		"retry":   isRetry,
+		"phase":   jobType,
+	}
+	if (curationType == "upgrade" || curationType == "upgradePosthook") && curator.Spec.Upgrade.DesiredUpdate != "" {
+		facts["desiredVersion"] = curator.Spec.Upgrade.DesiredUpdate
+	}
+	return facts
+}
+
+// addCurationContext adds the curation facts and the managed_cluster to the extra_vars of an
+// attempt of a hook. The currentVersion of the curation is read from the version ClusterClaim,
+// nothing of the ManagedCluster is added when it does not exist or the cluster-installer
+// ServiceAccount may not read it, as the ManagedCluster is cluster scoped.
+func addCurationContext(
+	client client.Client,
+	curator *clustercuratorv1.ClusterCurator,
+	jobType string,
+	attempt int,
+	extraVars map[string]interface{}) error {
+
+	facts := getCurationFacts(curator, jobType, attempt)
+	extraVars[CurationKey] = facts
+
+	managedCluster, err := getManagedCluster(client, curator.Name)
+	if err != nil {
+		if k8serrors.IsNotFound(err) || meta.IsNoMatchError(err) {
+			klog.Warning("Did not find managedCluster")
+			return nil
+		}
+		if k8serrors.IsForbidden(err) {
+			klog.Warningf("Could not read managedCluster: %v", err.Error())
+			return nil
+		}
+		return err
+	}
+	extraVars[ManagedClusterKey] = managedCluster
+
+	if version, ok := managedCluster["clusterClaims"].(map[string]interface{})[VersionClaim]; ok {
+		facts["currentVersion"] = version
+	}
+	return nil
+}
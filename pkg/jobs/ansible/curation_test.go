@@ -0,0 +1,121 @@
+// Copyright Contributors to the Open Cluster Management project.
+package ansible
+
+import (
+	"context"
+	"testing"
+
+	clustercuratorv1 "github.com/stolostron/cluster-curator-controller/pkg/api/v1beta1"
+	"github.com/stretchr/testify/assert"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	clientfake "sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
+)
+
+func getManagedClusterResource() *unstructured.Unstructured {
+	return &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "cluster.open-cluster-management.io/v1",
+		"kind":       "ManagedCluster",
+		"metadata": map[string]interface{}{
+			"name":   ClusterName,
+			"labels": map[string]interface{}{"env": "prod", "region": "us-east-1"},
+		},
+		"status": map[string]interface{}{
+			"clusterClaims": []interface{}{
+				map[string]interface{}{"name": VersionClaim, "value": "4.15.10"},
+				map[string]interface{}{"name": "platform.open-cluster-management.io", "value": "AWS"},
+			},
+		},
+	}}
+}
+
+func TestAddCurationContext(t *testing.T) {
+
+	cc := getClusterCurator()
+	cc.Spec.DesiredCuration = "upgrade"
+	cc.Spec.CuratingJob = "curator-job-d8sk2"
+	cc.Spec.Upgrade.DesiredUpdate = "4.16.0"
+
+	s := runtime.NewScheme()
+	assert.Nil(t, clustercuratorv1.AddToScheme(s))
+	client := clientfake.NewClientBuilder().WithScheme(s).WithObjects(cc, getManagedClusterResource()).Build()
+
+	extraVars := map[string]interface{}{}
+	assert.Nil(t, addCurationContext(client, cc, PREHOOK, 2, extraVars))
+
+	assert.Equal(t, map[string]interface{}{
+		"type":           "upgrade",
+		"runID":          "curator-job-d8sk2",
+		"attempt":        2,
+		"retry":          true,
+		"phase":          PREHOOK,
+		"desiredVersion": "4.16.0",
+		"currentVersion": "4.15.10",
+	}, extraVars[CurationKey])
+
+	assert.Equal(t, map[string]interface{}{
+		"name":   ClusterName,
+		"labels": map[string]interface{}{"env": "prod", "region": "us-east-1"},
+		"clusterClaims": map[string]interface{}{
+			VersionClaim:                          "4.15.10",
+			"platform.open-cluster-management.io": "AWS",
+		},
+	}, extraVars[ManagedClusterKey])
+}
+
+func TestAddCurationContextNoManagedCluster(t *testing.T) {
+
+	cc := getClusterCurator()
+
+	s := runtime.NewScheme()
+	assert.Nil(t, clustercuratorv1.AddToScheme(s))
+	client := clientfake.NewClientBuilder().WithScheme(s).WithObjects(cc).Build()
+
+	extraVars := map[string]interface{}{}
+	assert.Nil(t, addCurationContext(client, cc, POSTHOOK, 1, extraVars),
+		"err nil, when there is no ManagedCluster")
+
+	assert.Equal(t, map[string]interface{}{
+		"type":    "install",
+		"runID":   "",
+		"attempt": 1,
+		"retry":   false,
+		"phase":   POSTHOOK,
+	}, extraVars[CurationKey], "no versions, outside of an upgrade")
+	assert.Nil(t, extraVars[ManagedClusterKey])
+
+	t.Log("A resumed curation is a retry")
+	cc.Operation = &clustercuratorv1.Operation{ResumeFailed: true}
+	assert.Nil(t, addCurationContext(client, cc, POSTHOOK, 1, extraVars))
+	assert.Equal(t, true, extraVars[CurationKey].(map[string]interface{})["retry"])
+}
+
+func TestAddCurationContextManagedClusterForbidden(t *testing.T) {
+
+	cc := getClusterCurator()
+
+	s := runtime.NewScheme()
+	assert.Nil(t, clustercuratorv1.AddToScheme(s))
+	client := clientfake.NewClientBuilder().WithScheme(s).WithObjects(cc, getManagedClusterResource()).
+		WithInterceptorFuncs(interceptor.Funcs{Get: func(ctx context.Context, c client.WithWatch,
+			key client.ObjectKey, obj client.Object, opts ...client.GetOption) error {
+
+			if _, ok := obj.(*unstructured.Unstructured); ok {
+				return k8serrors.NewForbidden(schema.GroupResource{
+					Group:    "cluster.open-cluster-management.io",
+					Resource: "managedclusters",
+				}, key.Name, nil)
+			}
+			return c.Get(ctx, key, obj, opts...)
+		}}).Build()
+
+	extraVars := map[string]interface{}{}
+	assert.Nil(t, addCurationContext(client, cc, PREHOOK, 1, extraVars),
+		"err nil, when the ManagedCluster can not be read")
+	assert.NotNil(t, extraVars[CurationKey])
+	assert.Nil(t, extraVars[ManagedClusterKey])
+}
@@ -0,0 +1,133 @@
+// Copyright Contributors to the Open Cluster Management project.
+package ansible
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"maps"
+
+	clustercuratorv1 "github.com/stolostron/cluster-curator-controller/pkg/api/v1beta1"
+	corev1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/klog/v2"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// RedactedValue replaces the extra_vars values when an AnsibleJob is logged, they can come from
+// the Secrets of extraVarsFrom
+const RedactedValue = "<redacted>"
+
+// addExtraVarsFrom adds the keys of the extraVarsFrom ConfigMaps and Secrets of a hook to its
+// extra_vars. A later source overrides an earlier one, and a key already in the extra_vars is kept.
+func addExtraVarsFrom(
+	client client.Client,
+	namespace string,
+	hookToRun clustercuratorv1.Hook,
+	extraVars map[string]interface{}) error {
+
+	fromVars := map[string]interface{}{}
+	for _, source := range hookToRun.ExtraVarsFrom {
+		if source.ConfigMapRef == nil && source.SecretRef == nil {
+			return errors.New("An extraVarsFrom entry of hook " + hookToRun.Name +
+				" has no configMapRef or secretRef")
+		}
+
+		if source.ConfigMapRef != nil {
+			configMap := &corev1.ConfigMap{}
+			found, err := getExtraVarsSource(client, namespace, "ConfigMap", source.ConfigMapRef, configMap)
+			if err != nil {
+				return err
+			}
+			if found {
+				for key, value := range configMap.Data {
+					fromVars[key] = decodeExtraVarsValue([]byte(value))
+				}
+			}
+		}
+
+		if source.SecretRef != nil {
+			secret := &corev1.Secret{}
+			found, err := getExtraVarsSource(client, namespace, "Secret", source.SecretRef, secret)
+			if err != nil {
+				return err
+			}
+			if found {
+				for key, value := range secret.Data {
+					fromVars[key] = decodeExtraVarsValue(value)
+				}
+			}
+		}
+	}
+
+	for key, value := range fromVars {
+		if _, ok := extraVars[key]; !ok {
+			extraVars[key] = value
+		}
+	}
+	return nil
+}
+
+// getExtraVarsSource reads the ConfigMap or Secret of an extraVarsFrom entry, found is false when
+// an optional one does not exist
+func getExtraVarsSource(
+	client client.Client,
+	namespace string,
+	kind string,
+	ref *clustercuratorv1.ExtraVarsReference,
+	obj client.Object) (bool, error) {
+
+	err := client.Get(context.Background(), types.NamespacedName{Namespace: namespace, Name: ref.Name}, obj)
+	if err == nil {
+		return true, nil
+	}
+	if k8serrors.IsNotFound(err) && ref.Optional {
+		klog.V(2).Infof("The optional extraVarsFrom %v %v/%v does not exist", kind, namespace, ref.Name)
+		return false, nil
+	}
+	return false, errors.New("The extraVarsFrom " + kind + " " + namespace + "/" + ref.Name +
+		" could not be read: " + err.Error())
+}
+
+// decodeExtraVarsValue returns a JSON object or array decoded, and any other value as a string
+func decodeExtraVarsValue(value []byte) interface{} {
+	trimmed := bytes.TrimSpace(value)
+	if len(trimmed) > 0 && (trimmed[0] == '{' || trimmed[0] == '[') {
+		decoder := json.NewDecoder(bytes.NewReader(trimmed))
+		decoder.UseNumber()
+		var decoded interface{}
+		if err := decoder.Decode(&decoded); err == nil {
+			if _, err := decoder.Token(); err == io.EOF {
+				return decoded
+			}
+		}
+	}
+	return string(value)
+}
+
+// redactExtraVars returns the AnsibleJob to log, with each extra_vars value replaced by
+// RedactedValue. The AnsibleJob itself is not changed.
+func redactExtraVars(ansibleJob *unstructured.Unstructured) *unstructured.Unstructured {
+	spec, ok := ansibleJob.Object["spec"].(map[string]interface{})
+	if !ok {
+		return ansibleJob
+	}
+	extraVars, ok := spec["extra_vars"].(map[string]interface{})
+	if !ok {
+		return ansibleJob
+	}
+
+	redactedExtraVars := make(map[string]interface{}, len(extraVars))
+	for key := range extraVars {
+		redactedExtraVars[key] = RedactedValue
+	}
+	redactedSpec := maps.Clone(spec)
+	redactedSpec["extra_vars"] = redactedExtraVars
+	redacted := maps.Clone(ansibleJob.Object)
+	redacted["spec"] = redactedSpec
+	return &unstructured.Unstructured{Object: redacted}
+}
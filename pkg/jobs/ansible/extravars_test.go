@@ -0,0 +1,119 @@
+// Copyright Contributors to the Open Cluster Management project.
+package ansible
+
+import (
+	"testing"
+
+	hivev1 "github.com/openshift/hive/apis/hive/v1"
+	ajv1 "github.com/stolostron/cluster-curator-controller/pkg/api/v1alpha1"
+	clustercuratorv1 "github.com/stolostron/cluster-curator-controller/pkg/api/v1beta1"
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	clientfake "sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func getExtraVarsFromClient(objs ...runtime.Object) client.Client {
+	s.AddKnownTypes(corev1.SchemeGroupVersion, &corev1.Secret{}, &corev1.ConfigMap{})
+	return clientfake.NewClientBuilder().WithScheme(s).WithRuntimeObjects(append(objs,
+		&corev1.ConfigMap{
+			ObjectMeta: v1.ObjectMeta{Name: "itsm-config", Namespace: ClusterName},
+			Data: map[string]string{
+				"itsm_url":    "https://itsm.example.com",
+				"assignment":  `{"group": "platform", "priority": 3}`,
+				"environment": "staging",
+			},
+		},
+		&corev1.Secret{
+			ObjectMeta: v1.ObjectMeta{Name: "itsm-credentials", Namespace: ClusterName},
+			Data: map[string][]byte{
+				"itsm_token":  []byte("s3cr3t"),
+				"environment": []byte("production"),
+			},
+		})...).Build()
+}
+
+func TestAddExtraVarsFrom(t *testing.T) {
+
+	client := getExtraVarsFromClient()
+	hook := clustercuratorv1.Hook{
+		Name: "Service now App Update",
+		ExtraVarsFrom: []clustercuratorv1.ExtraVarsSource{
+			{ConfigMapRef: &clustercuratorv1.ExtraVarsReference{Name: "itsm-config"}},
+			{SecretRef: &clustercuratorv1.ExtraVarsReference{Name: "itsm-credentials"}},
+			{SecretRef: &clustercuratorv1.ExtraVarsReference{Name: "missing", Optional: true}},
+		},
+	}
+	extraVars := map[string]interface{}{"itsm_url": "https://itsm-test.example.com"}
+
+	assert.Nil(t, addExtraVarsFrom(client, ClusterName, hook, extraVars))
+	assert.Equal(t, "https://itsm-test.example.com", extraVars["itsm_url"], "extra_vars override extraVarsFrom")
+	assert.Equal(t, "s3cr3t", extraVars["itsm_token"])
+	assert.Equal(t, "production", extraVars["environment"], "a later source overrides an earlier one")
+	assert.Equal(t, "platform", extraVars["assignment"].(map[string]interface{})["group"],
+		"a JSON object is decoded")
+
+	hook.ExtraVarsFrom[2].SecretRef.Optional = false
+	err := addExtraVarsFrom(client, ClusterName, hook, map[string]interface{}{})
+	assert.NotNil(t, err, "err not nil, when a Secret that is not optional is missing")
+	assert.Contains(t, err.Error(), "missing")
+
+	hook.ExtraVarsFrom = []clustercuratorv1.ExtraVarsSource{{}}
+	assert.NotNil(t, addExtraVarsFrom(client, ClusterName, hook, map[string]interface{}{}),
+		"err not nil, when an entry has no reference")
+}
+
+func TestDecodeExtraVarsValue(t *testing.T) {
+	assert.Equal(t, "plain", decodeExtraVarsValue([]byte("plain")))
+	assert.Equal(t, "42", decodeExtraVarsValue([]byte("42")), "only objects and arrays are decoded")
+	assert.Equal(t, []interface{}{"a", "b"}, decodeExtraVarsValue([]byte(` ["a", "b"] `)))
+	assert.Equal(t, "{not json", decodeExtraVarsValue([]byte("{not json")))
+	assert.Equal(t, `{"a": 1} trailing`, decodeExtraVarsValue([]byte(`{"a": 1} trailing`)))
+}
+
+func TestRunAnsibleJobExtraVarsFrom(t *testing.T) {
+
+	cc := getClusterCurator()
+	hook := cc.Spec.Install.Posthook[0]
+	hook.ExtraVarsFrom = []clustercuratorv1.ExtraVarsSource{
+		{SecretRef: &clustercuratorv1.ExtraVarsReference{Name: "itsm-credentials"}},
+	}
+
+	s.AddKnownTypes(ajv1.SchemeBuilder.GroupVersion, &ajv1.AnsibleJob{})
+	s.AddKnownTypes(clustercuratorv1.SchemeBuilder.GroupVersion, &clustercuratorv1.ClusterCurator{})
+	s.AddKnownTypes(hivev1.SchemeBuilder.GroupVersion, &hivev1.ClusterDeployment{}, &hivev1.MachinePool{})
+	client := getExtraVarsFromClient(cc, genClusterDeployment(), genMachinePool(), genInstallConfigSecret())
+
+	aJob, err := RunAnsibleJob(client, cc, POSTHOOK, hook, "toweraccess")
+	assert.Nil(t, err, "err is nil when job is started")
+
+	extraVars := aJob.Object["spec"].(map[string]interface{})["extra_vars"].(map[string]interface{})
+	assert.Equal(t, "s3cr3t", extraVars["itsm_token"], "the Secret keys are in the extra_vars")
+	assert.Equal(t, "3", extraVars["variable1"], "the inline extra_vars are kept")
+	assert.NotNil(t, extraVars["cluster_deployment"], "nil when cluster_deployment missing")
+}
+
+func TestRedactExtraVars(t *testing.T) {
+
+	ansibleJob := &unstructured.Unstructured{Object: map[string]interface{}{
+		"kind": "AnsibleJob",
+		"spec": map[string]interface{}{
+			"job_template_name": "Service now App Update",
+			"extra_vars":        map[string]interface{}{"itsm_token": "s3cr3t"},
+		},
+	}}
+
+	redacted := redactExtraVars(ansibleJob)
+	spec := redacted.Object["spec"].(map[string]interface{})
+	assert.Equal(t, RedactedValue, spec["extra_vars"].(map[string]interface{})["itsm_token"])
+	assert.Equal(t, "Service now App Update", spec["job_template_name"])
+	assert.Equal(t, "s3cr3t",
+		ansibleJob.Object["spec"].(map[string]interface{})["extra_vars"].(map[string]interface{})["itsm_token"],
+		"the AnsibleJob is not changed")
+
+	noSpec := &unstructured.Unstructured{Object: map[string]interface{}{"kind": "AnsibleJob"}}
+	assert.Equal(t, noSpec, redactExtraVars(noSpec))
+}
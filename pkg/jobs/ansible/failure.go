@@ -0,0 +1,120 @@
+// Copyright Contributors to the Open Cluster Management project.
+package ansible
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"strings"
+
+	clustercuratorv1 "github.com/stolostron/cluster-curator-controller/pkg/api/v1beta1"
+	"github.com/stolostron/cluster-curator-controller/pkg/jobs/utils"
+	corev1 "k8s.io/api/core/v1"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/klog/v2"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// The bounds of the runner pod logs recorded in status.lastFailure
+const FailureLogTailLines = 50
+const FailureLogLimitBytes = 4096
+
+// getAnsibleJobFailure returns the failure details the AnsibleJob reports on the hub, the
+// namespaced name of its Kubernetes Job and the task counts of the ansibleResult of its conditions
+func getAnsibleJobFailure(jobResource *unstructured.Unstructured) *clustercuratorv1.AnsibleJobFailure {
+	failure := &clustercuratorv1.AnsibleJobFailure{
+		AnsibleJob: jobResource.GetName(),
+		URL:        getAnsibleJobURL(jobResource),
+	}
+	failure.K8sJob, _, _ = unstructured.NestedString(jobResource.Object, "status", "k8sJob", "namespacedName")
+
+	conditions, _, _ := unstructured.NestedSlice(jobResource.Object, "status", "conditions")
+	for _, condition := range conditions {
+		result, ok := condition.(map[string]interface{})["ansibleResult"].(map[string]interface{})
+		if !ok {
+			continue
+		}
+		failure.Ok = getResultCount(result, "ok")
+		failure.Changed = getResultCount(result, "changed")
+		failure.Failures = getResultCount(result, "failures")
+	}
+	return failure
+}
+
+func getResultCount(result map[string]interface{}, key string) int64 {
+	switch count := result[key].(type) {
+	case int64:
+		return count
+	case float64:
+		return int64(count)
+	}
+	return 0
+}
+
+// getRunnerPodLogs returns the last lines of the logs of the newest pod of the Kubernetes Job,
+// k8sJob is its namespace/name
+func getRunnerPodLogs(kubeset kubernetes.Interface, k8sJob string) (string, error) {
+	namespace, name, found := strings.Cut(k8sJob, "/")
+	if !found {
+		return "", errors.New("Invalid Kubernetes Job name " + k8sJob)
+	}
+
+	pods, err := kubeset.CoreV1().Pods(namespace).List(context.TODO(), v1.ListOptions{
+		LabelSelector: "job-name=" + name,
+	})
+	if err != nil {
+		return "", err
+	}
+	if len(pods.Items) == 0 {
+		return "", errors.New("No runner pod found for Kubernetes Job " + k8sJob)
+	}
+	sort.Slice(pods.Items, func(i, j int) bool {
+		return pods.Items[j].CreationTimestamp.Before(&pods.Items[i].CreationTimestamp)
+	})
+
+	tailLines := int64(FailureLogTailLines)
+	limitBytes := int64(FailureLogLimitBytes)
+	logs, err := kubeset.CoreV1().Pods(namespace).GetLogs(pods.Items[0].Name, &corev1.PodLogOptions{
+		TailLines:  &tailLines,
+		LimitBytes: &limitBytes,
+	}).DoRaw(context.TODO())
+	if err != nil {
+		return "", err
+	}
+	return string(logs), nil
+}
+
+// recordAnsibleJobFailure records the failure details of the AnsibleJob of a hook attempt in
+// status.lastFailure. The runner pod logs are only recorded when spec.captureFailureLogs is true,
+// and left out when kubeset is nil or they can not be read.
+func recordAnsibleJobFailure(
+	client client.Client,
+	kubeset kubernetes.Interface,
+	curator *clustercuratorv1.ClusterCurator,
+	stepName string,
+	hook clustercuratorv1.Hook,
+	attempt int,
+	jobResource *unstructured.Unstructured,
+	jobErr error) error {
+
+	failure := getAnsibleJobFailure(jobResource)
+	failure.Step = stepName
+	failure.Hook = hook.Name
+	failure.Attempt = attempt
+	failure.Message = jobErr.Error()
+	now := v1.Now()
+	failure.Timestamp = &now
+
+	if curator.Spec.CaptureFailureLogs && kubeset != nil && failure.K8sJob != "" {
+		logs, err := getRunnerPodLogs(kubeset, failure.K8sJob)
+		if err != nil {
+			klog.Warningf("Could not read the logs of Kubernetes Job %v: %v", failure.K8sJob, err)
+		}
+		failure.Logs = logs
+	}
+
+	klog.V(2).Infof("Recording the failure of AnsibleJob %v", failure.AnsibleJob)
+	return utils.RecordLastFailure(client, curator.Name, curator.Namespace, failure)
+}
@@ -0,0 +1,128 @@
+// Copyright Contributors to the Open Cluster Management project.
+package ansible
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	clustercuratorv1 "github.com/stolostron/cluster-curator-controller/pkg/api/v1beta1"
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/fake"
+	clientfake "sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func getRunnerPod(name string, age time.Duration) *corev1.Pod {
+	return &corev1.Pod{
+		ObjectMeta: v1.ObjectMeta{
+			Name:              name,
+			Namespace:         ClusterName,
+			Labels:            map[string]string{"job-name": "prehookjob-runner"},
+			CreationTimestamp: v1.NewTime(time.Now().Add(-age)),
+		},
+	}
+}
+
+func TestGetAnsibleJobFailure(t *testing.T) {
+
+	jobResource := getFinishedAnsibleJob(map[string]interface{}{
+		"ansibleJobResult": map[string]interface{}{"status": "error", "url": "https://tower/#/jobs/1"},
+		"k8sJob":           map[string]interface{}{"namespacedName": ClusterName + "/prehookjob-runner"},
+		"conditions": []interface{}{
+			map[string]interface{}{
+				"type": "Running",
+				"ansibleResult": map[string]interface{}{
+					"ok":       int64(5),
+					"changed":  int64(2),
+					"failures": int64(1),
+				},
+			},
+			map[string]interface{}{"type": "Failure", "reason": "Failed"},
+		},
+	})
+
+	assert.Equal(t, &clustercuratorv1.AnsibleJobFailure{
+		AnsibleJob: AnsibleJobName,
+		K8sJob:     ClusterName + "/prehookjob-runner",
+		URL:        "https://tower/#/jobs/1",
+		Ok:         5,
+		Changed:    2,
+		Failures:   1,
+	}, getAnsibleJobFailure(jobResource))
+
+	assert.Equal(t, &clustercuratorv1.AnsibleJobFailure{AnsibleJob: AnsibleJobName},
+		getAnsibleJobFailure(getFinishedAnsibleJob(map[string]interface{}{})),
+		"only the name, when the AnsibleJob reports nothing")
+}
+
+func TestGetRunnerPodLogs(t *testing.T) {
+
+	kubeset := fake.NewSimpleClientset(
+		getRunnerPod("prehookjob-runner-old", time.Hour), getRunnerPod("prehookjob-runner-new", time.Minute))
+
+	logs, err := getRunnerPodLogs(kubeset, ClusterName+"/prehookjob-runner")
+	assert.Nil(t, err)
+	assert.Equal(t, "fake logs", logs)
+
+	_, err = getRunnerPodLogs(kubeset, ClusterName+"/missing-runner")
+	assert.NotNil(t, err, "err not nil, when the Kubernetes Job has no pod")
+
+	_, err = getRunnerPodLogs(kubeset, "prehookjob-runner")
+	assert.NotNil(t, err, "err not nil, when the name has no namespace")
+}
+
+func TestRecordAnsibleJobFailure(t *testing.T) {
+
+	cc := getClusterCurator()
+	cc.Spec.CaptureFailureLogs = true
+
+	s := runtime.NewScheme()
+	assert.Nil(t, clustercuratorv1.AddToScheme(s))
+	client := clientfake.NewClientBuilder().WithScheme(s).WithObjects(cc).Build()
+	kubeset := fake.NewSimpleClientset(getRunnerPod("prehookjob-runner-abc", time.Minute))
+
+	jobResource := getFinishedAnsibleJob(map[string]interface{}{
+		"ansibleJobResult": map[string]interface{}{"status": "error"},
+		"k8sJob":           map[string]interface{}{"namespacedName": ClusterName + "/prehookjob-runner"},
+	})
+	hook := clustercuratorv1.Hook{Name: "Service now App Update"}
+
+	assert.Nil(t, recordAnsibleJobFailure(client, kubeset, cc, "prehook-ansiblejob", hook, 2, jobResource,
+		errors.New("AnsibleJob exited with an error")))
+
+	curator := &clustercuratorv1.ClusterCurator{}
+	assert.Nil(t, client.Get(context.Background(),
+		types.NamespacedName{Namespace: ClusterName, Name: ClusterName}, curator))
+
+	failure := curator.Status.LastFailure
+	assert.NotNil(t, failure)
+	assert.Equal(t, "prehook-ansiblejob", failure.Step)
+	assert.Equal(t, "Service now App Update", failure.Hook)
+	assert.Equal(t, 2, failure.Attempt)
+	assert.Equal(t, AnsibleJobName, failure.AnsibleJob)
+	assert.Equal(t, "AnsibleJob exited with an error", failure.Message)
+	assert.Equal(t, "fake logs", failure.Logs, "the runner pod logs are recorded")
+	assert.NotNil(t, failure.Timestamp)
+
+	t.Log("No logs, without a kubeset")
+	assert.Nil(t, recordAnsibleJobFailure(client, nil, cc, "prehook-ansiblejob", hook, 3, jobResource,
+		errors.New("AnsibleJob exited with an error")))
+	assert.Nil(t, client.Get(context.Background(),
+		types.NamespacedName{Namespace: ClusterName, Name: ClusterName}, curator))
+	assert.Equal(t, 3, curator.Status.LastFailure.Attempt)
+	assert.Equal(t, "", curator.Status.LastFailure.Logs)
+
+	t.Log("No logs, unless spec.captureFailureLogs is true")
+	cc.Spec.CaptureFailureLogs = false
+	assert.Nil(t, recordAnsibleJobFailure(client, kubeset, cc, "prehook-ansiblejob", hook, 4, jobResource,
+		errors.New("AnsibleJob exited with an error")))
+	assert.Nil(t, client.Get(context.Background(),
+		types.NamespacedName{Namespace: ClusterName, Name: ClusterName}, curator))
+	assert.Equal(t, 4, curator.Status.LastFailure.Attempt)
+	assert.Equal(t, "", curator.Status.LastFailure.Logs, "the runner pod logs are not recorded")
+}
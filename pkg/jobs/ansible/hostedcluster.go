@@ -0,0 +1,127 @@
+// Copyright Contributors to the Open Cluster Management project.
+package ansible
+
+import (
+	"context"
+	"strings"
+
+	clustercuratorv1 "github.com/stolostron/cluster-curator-controller/pkg/api/v1beta1"
+	"github.com/stolostron/cluster-curator-controller/pkg/jobs/utils"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/klog/v2"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// The extra_vars keys holding the HostedCluster and NodePools of a HyperShift cluster
+const HostedClusterKey = "hosted_cluster"
+const NodePoolsKey = "node_pools"
+
+// getHostedCluster returns the platform, release, networking and dns of the HostedCluster spec,
+// with the references to credentials removed
+func getHostedCluster(client client.Client, curator *clustercuratorv1.ClusterCurator) (map[string]interface{}, error) {
+	hostedCluster := &unstructured.Unstructured{}
+	hostedCluster.SetGroupVersionKind(schema.GroupVersionKind{
+		Group:   utils.HCGVR.Group,
+		Version: utils.HCGVR.Version,
+		Kind:    "HostedCluster",
+	})
+	if err := client.Get(context.Background(), types.NamespacedName{
+		Namespace: curator.Namespace,
+		Name:      curator.Name,
+	}, hostedCluster); err != nil {
+		return nil, err
+	}
+
+	spec, _, _ := unstructured.NestedMap(hostedCluster.Object, "spec")
+	subset := map[string]interface{}{}
+	for _, key := range []string{"platform", "release", "networking", "dns"} {
+		if value, ok := spec[key]; ok {
+			subset[key] = scrubCredentials(value)
+		}
+	}
+	return subset, nil
+}
+
+// getNodePools returns the name, replicas, release and platform of each NodePool of the
+// HostedCluster, with the references to credentials removed
+func getNodePools(client client.Client, curator *clustercuratorv1.ClusterCurator) ([]interface{}, error) {
+	nodePools := &unstructured.UnstructuredList{}
+	nodePools.SetGroupVersionKind(schema.GroupVersionKind{
+		Group:   utils.NPGVR.Group,
+		Version: utils.NPGVR.Version,
+		Kind:    "NodePoolList",
+	})
+	if err := client.List(context.Background(), nodePools, listOptions(curator.Namespace, nil)...); err != nil {
+		return nil, err
+	}
+
+	pools := []interface{}{}
+	for _, np := range nodePools.Items {
+		spec, _, _ := unstructured.NestedMap(np.Object, "spec")
+		if spec["clusterName"] != curator.Name {
+			continue
+		}
+		pool := map[string]interface{}{"name": np.GetName()}
+		for _, key := range []string{"replicas", "release", "platform"} {
+			if value, ok := spec[key]; ok {
+				pool[key] = scrubCredentials(value)
+			}
+		}
+		pools = append(pools, pool)
+	}
+	return pools, nil
+}
+
+// scrubCredentials removes the keys that reference secrets, credentials or kubeconfigs from a
+// HostedCluster or NodePool value, at any depth
+func scrubCredentials(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		scrubbed := map[string]interface{}{}
+		for key, item := range v {
+			lower := strings.ToLower(key)
+			if strings.Contains(lower, "secret") || strings.Contains(lower, "credential") ||
+				strings.Contains(lower, "kubeconfig") {
+				continue
+			}
+			scrubbed[key] = scrubCredentials(item)
+		}
+		return scrubbed
+	case []interface{}:
+		scrubbed := make([]interface{}, 0, len(v))
+		for _, item := range v {
+			scrubbed = append(scrubbed, scrubCredentials(item))
+		}
+		return scrubbed
+	}
+	return value
+}
+
+// addHostedClusterContext adds the hosted_cluster and node_pools of the HostedCluster the curator
+// targets to the extra_vars, nothing is added when there is no HostedCluster
+func addHostedClusterContext(
+	client client.Client,
+	curator *clustercuratorv1.ClusterCurator,
+	extraVars map[string]interface{}) error {
+
+	hostedCluster, err := getHostedCluster(client, curator)
+	if err != nil {
+		if k8serrors.IsNotFound(err) || meta.IsNoMatchError(err) {
+			klog.Warning("Did not find hostedCluster")
+			return nil
+		}
+		return err
+	}
+	extraVars[HostedClusterKey] = hostedCluster
+
+	nodePools, err := getNodePools(client, curator)
+	if err != nil {
+		return err
+	}
+	extraVars[NodePoolsKey] = nodePools
+	return nil
+}
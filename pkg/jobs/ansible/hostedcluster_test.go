@@ -0,0 +1,127 @@
+// Copyright Contributors to the Open Cluster Management project.
+package ansible
+
+import (
+	"testing"
+
+	hivev1 "github.com/openshift/hive/apis/hive/v1"
+	clustercuratorv1 "github.com/stolostron/cluster-curator-controller/pkg/api/v1beta1"
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	clientfake "sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+const HostedClusterName = "my-hosted"
+const HostedClusterNamespace = "clusters"
+
+func getHostedClusterCurator() *clustercuratorv1.ClusterCurator {
+	return &clustercuratorv1.ClusterCurator{
+		ObjectMeta: v1.ObjectMeta{
+			Name:      HostedClusterName,
+			Namespace: HostedClusterNamespace,
+		},
+		Spec: clustercuratorv1.ClusterCuratorSpec{
+			DesiredCuration: "install",
+		},
+	}
+}
+
+func getHostedClusterResource() *unstructured.Unstructured {
+	return &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "hypershift.openshift.io/v1beta1",
+		"kind":       "HostedCluster",
+		"metadata":   map[string]interface{}{"name": HostedClusterName, "namespace": HostedClusterNamespace},
+		"spec": map[string]interface{}{
+			"release": map[string]interface{}{"image": "quay.io/openshift-release-dev/ocp-release:4.16.0-x86_64"},
+			"dns":     map[string]interface{}{"baseDomain": "example.com"},
+			"networking": map[string]interface{}{
+				"clusterNetwork": []interface{}{map[string]interface{}{"cidr": "10.132.0.0/14"}},
+			},
+			"platform": map[string]interface{}{
+				"type": "KubeVirt",
+				"kubevirt": map[string]interface{}{
+					"baseDomainPassthrough": true,
+					"credentials": map[string]interface{}{
+						"infraKubeConfigSecret": map[string]interface{}{"name": "infra-kubeconfig"},
+					},
+				},
+			},
+			"pullSecret": map[string]interface{}{"name": "pull-secret"},
+			"sshKey":     map[string]interface{}{"name": "ssh-key"},
+		},
+	}}
+}
+
+func getNodePoolResource(name string, clusterName string) *unstructured.Unstructured {
+	return &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "hypershift.openshift.io/v1beta1",
+		"kind":       "NodePool",
+		"metadata":   map[string]interface{}{"name": name, "namespace": HostedClusterNamespace},
+		"spec": map[string]interface{}{
+			"clusterName": clusterName,
+			"replicas":    int64(2),
+			"release":     map[string]interface{}{"image": "quay.io/openshift-release-dev/ocp-release:4.16.0-x86_64"},
+			"platform":    map[string]interface{}{"type": "KubeVirt"},
+			"management":  map[string]interface{}{"upgradeType": "Replace"},
+		},
+	}}
+}
+
+func TestAddHostedClusterContext(t *testing.T) {
+
+	cc := getHostedClusterCurator()
+
+	s := runtime.NewScheme()
+	s.AddKnownTypes(clustercuratorv1.SchemeBuilder.GroupVersion, &clustercuratorv1.ClusterCurator{})
+	s.AddKnownTypes(hivev1.SchemeBuilder.GroupVersion, &hivev1.ClusterDeployment{})
+	s.AddKnownTypes(corev1.SchemeGroupVersion, &corev1.Secret{})
+	client := clientfake.NewClientBuilder().WithScheme(s).WithObjects(
+		cc, getHostedClusterResource(),
+		getNodePoolResource(HostedClusterName+"-workers", HostedClusterName),
+		getNodePoolResource("other-workers", "other-hosted")).Build()
+
+	extraVars := map[string]interface{}{}
+	assert.Nil(t, addClusterContext(client, cc, extraVars))
+
+	assert.Equal(t, map[string]interface{}{
+		"release": map[string]interface{}{"image": "quay.io/openshift-release-dev/ocp-release:4.16.0-x86_64"},
+		"dns":     map[string]interface{}{"baseDomain": "example.com"},
+		"networking": map[string]interface{}{
+			"clusterNetwork": []interface{}{map[string]interface{}{"cidr": "10.132.0.0/14"}},
+		},
+		"platform": map[string]interface{}{
+			"type":     "KubeVirt",
+			"kubevirt": map[string]interface{}{"baseDomainPassthrough": true},
+		},
+	}, extraVars[HostedClusterKey], "the spec subset is added without the credentials")
+
+	assert.Equal(t, []interface{}{
+		map[string]interface{}{
+			"name":     HostedClusterName + "-workers",
+			"replicas": int64(2),
+			"release":  map[string]interface{}{"image": "quay.io/openshift-release-dev/ocp-release:4.16.0-x86_64"},
+			"platform": map[string]interface{}{"type": "KubeVirt"},
+		},
+	}, extraVars[NodePoolsKey], "only the NodePools of the HostedCluster are added")
+
+	assert.Nil(t, extraVars["cluster_deployment"])
+}
+
+func TestAddHostedClusterContextMissing(t *testing.T) {
+
+	cc := getHostedClusterCurator()
+
+	s := runtime.NewScheme()
+	s.AddKnownTypes(clustercuratorv1.SchemeBuilder.GroupVersion, &clustercuratorv1.ClusterCurator{})
+	s.AddKnownTypes(hivev1.SchemeBuilder.GroupVersion, &hivev1.ClusterDeployment{})
+	s.AddKnownTypes(corev1.SchemeGroupVersion, &corev1.Secret{})
+	client := clientfake.NewClientBuilder().WithScheme(s).WithObjects(cc).Build()
+
+	extraVars := map[string]interface{}{}
+	assert.Nil(t, addClusterContext(client, cc, extraVars), "err nil, when there is no HostedCluster")
+	assert.Nil(t, extraVars[HostedClusterKey])
+	assert.Nil(t, extraVars[NodePoolsKey])
+}
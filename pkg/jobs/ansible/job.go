@@ -5,7 +5,10 @@ import (
 	"context"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"os"
+	"slices"
+	"strconv"
 	"time"
 
 	hivev1 "github.com/openshift/hive/apis/hive/v1"
@@ -13,43 +16,406 @@ import (
 	"github.com/stolostron/cluster-curator-controller/pkg/jobs/utils"
 	managedclusterinfov1beta1 "github.com/stolostron/cluster-lifecycle-api/clusterinfo/v1beta1"
 	"gopkg.in/yaml.v2"
+	batchv1 "k8s.io/api/batch/v1"
 	corev1 "k8s.io/api/core/v1"
 	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
 	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes"
 	"k8s.io/klog/v2"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 )
 
 const PREHOOK = "prehook"
 const POSTHOOK = "posthook"
+const ONFAILURE = "onfailure"
 const MPSUFFIX = "-worker"
 const ICSUFFIX = "-install-config"
 const JOB_TEMPLATE_NAME_KEY = "job_template_name"
 const WORKFLOW_TEMPLATE_NAME_KEY = "workflow_template_name"
 
+// DefaultRetryBackoff is the time in seconds before a failed hook is retried the first time
+const DefaultRetryBackoff = 30
+
+// DefaultFailureHookTimeout is the timeoutMinutes of an onFailure hook that does not set one, so a
+// hung hook does not keep the failed curation from being recorded
+const DefaultFailureHookTimeout = 30
+
+// The labels of an AnsibleJob that identify the curator Job, hook and attempt it was created for,
+// so a restarted curator pod resumes it instead of launching the Tower job again
+const CurationRunLabel = utils.CurationRunLabel
+const HookIndexLabel = "cluster.open-cluster-management.io/hook-index"
+const HookAttemptLabel = "cluster.open-cluster-management.io/hook-attempt"
+
 var ansibleJobGVR = schema.GroupVersionResource{
 	Group: "tower.ansible.com", Version: "v1alpha1", Resource: "ansiblejobs"}
 
+var deleteInBackground = client.PropagationPolicy(v1.DeletePropagationBackground)
+
+func listOptions(namespace string, labels map[string]string) []client.ListOption {
+	return []client.ListOption{client.InNamespace(namespace), client.MatchingLabels(labels)}
+}
+
 func Job(client client.Client, curator *clustercuratorv1.ClusterCurator) error {
 	jobType := os.Getenv("JOB_TYPE")
 	if jobType != PREHOOK && jobType != POSTHOOK {
 		return errors.New("Missing JOB_TYPE environment parameter, use \"prehook\" or \"posthook\"")
 	}
 
-	// var hooks clustercuratorv1.Hooks
-	var prehook []clustercuratorv1.Hook
-	var posthook []clustercuratorv1.Hook
-	var towerauthsecret string
+	prehook, posthook, towerauthsecret, err := GetHooks(curator)
+	if err != nil {
+		return err
+	}
+
+	// Extract the prehooks or posthooks
+	hooksToRun := prehook
+	if jobType == POSTHOOK {
+		hooksToRun = posthook
+	}
+
+	// Move on when clusterCurator is missing or job hook is missing
+	if len(hooksToRun) == 0 {
+		klog.V(0).Infof("No ansibleJob detected for %v", jobType)
+		return nil
+	}
+
+	stepName := jobType + "-ansiblejob"
+	policy := GetParallelFailurePolicy(curator)
+	index := 0
+	for _, group := range groupHooks(hooksToRun) {
+		if len(group) == 1 {
+			if err := runStepHook(client, curator, jobType, stepName, group[0], index, towerauthsecret, nil); err != nil {
+				return err
+			}
+			index++
+			continue
+		}
 
-	desiredCuration := curator.Spec.DesiredCuration
-	if curator.Operation != nil && curator.Operation.RetryPosthook != "" {
-		desiredCuration = curator.Operation.RetryPosthook
+		klog.V(0).Infof("Running %v hooks of parallel group %v", len(group), group[0].ParallelGroup)
+		err := runParallelHooks(client, curator, jobType, stepName, group, index, towerauthsecret, policy)
+		if err != nil {
+			return err
+		}
+		index += len(group)
 	}
 
+	return nil
+}
+
+// GetParallelFailurePolicy returns the parallelFailurePolicy of the curation the curator Job runs,
+// FailFast when it is not set
+func GetParallelFailurePolicy(curator *clustercuratorv1.ClusterCurator) clustercuratorv1.ParallelFailurePolicy {
+	policy := clustercuratorv1.ParallelFailurePolicy("")
+	switch utils.GetEffectiveCuration(curator) {
+	case "install", "installPosthook":
+		policy = curator.Spec.Install.ParallelFailurePolicy
+	case "upgrade", "upgradePosthook":
+		policy = curator.Spec.Upgrade.ParallelFailurePolicy
+	case "destroy":
+		policy = curator.Spec.Destroy.ParallelFailurePolicy
+	case "scale":
+		policy = curator.Spec.Scale.ParallelFailurePolicy
+	}
+	if policy == "" {
+		return clustercuratorv1.ParallelFailurePolicyFailFast
+	}
+	return policy
+}
+
+// groupHooks splits the hooks into the groups that run one after the other, consecutive hooks with
+// the same parallelGroup share a group and every other hook is a group of its own
+func groupHooks(hooks []clustercuratorv1.Hook) [][]clustercuratorv1.Hook {
+	groups := [][]clustercuratorv1.Hook{}
+	for i, hook := range hooks {
+		if i > 0 && hook.ParallelGroup != "" && hook.ParallelGroup == hooks[i-1].ParallelGroup {
+			groups[len(groups)-1] = append(groups[len(groups)-1], hook)
+			continue
+		}
+		groups = append(groups, []clustercuratorv1.Hook{hook})
+	}
+	return groups
+}
+
+// runParallelHooks runs the hooks of a parallel group at the same time. With FailFast, the first
+// error is returned without waiting for the other hooks to finish. They stop monitoring, their
+// AnsibleJobs and Jobs are deleted and their attempts recorded as Cancelled. A panic of a hook is
+// returned as its error. firstIndex is the index of the first hook of the group in its hook list.
+func runParallelHooks(
+	client client.Client,
+	curator *clustercuratorv1.ClusterCurator,
+	jobType string,
+	stepName string,
+	hooks []clustercuratorv1.Hook,
+	firstIndex int,
+	towerauthsecret string,
+	policy clustercuratorv1.ParallelFailurePolicy) error {
+
+	results := make(chan error, len(hooks))
+	cancel := make(chan struct{})
+	for i, ttn := range hooks {
+		go func(hook clustercuratorv1.Hook, index int) {
+			var err error
+			defer func() {
+				if r := recover(); r != nil {
+					err = fmt.Errorf("%v", r)
+				}
+				if err != nil {
+					err = fmt.Errorf("Hook %v failed: %w", hook.Name, err)
+				}
+				results <- err
+			}()
+			err = runStepHook(client, curator, jobType, stepName, hook, index, towerauthsecret, cancel)
+		}(ttn, firstIndex+i)
+	}
+
+	var errs []error
+	for i := range hooks {
+		if err := <-results; err != nil {
+			if policy != clustercuratorv1.ParallelFailurePolicyWaitForAll {
+				close(cancel)
+				// The rest of the group returns once cancel is closed, an AnsibleJob or Job it
+				// created before its attempt was recorded is deleted by the hook itself
+				for range hooks[i+1:] {
+					<-results
+				}
+				utils.LogWarning(cancelRunningHooks(client, curator, stepName,
+					"Cancelled, another hook of the parallel group failed: "+err.Error()))
+				return err
+			}
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// cancelRunningHooks deletes the AnsibleJobs and Jobs of the Running attempts of stepName and
+// records these attempts as Cancelled with the message
+func cancelRunningHooks(
+	client client.Client, curator *clustercuratorv1.ClusterCurator, stepName string, message string) error {
+
+	current, err := utils.GetClusterCurator(client, curator.Name, curator.Namespace)
+	if err != nil {
+		return err
+	}
+
+	var errs []error
+	for _, step := range current.Status.Steps {
+		if step.Name != stepName {
+			continue
+		}
+		for _, attempt := range step.HookAttempts {
+			if attempt.Result != clustercuratorv1.CurationPhaseRunning {
+				continue
+			}
+			if attempt.AnsibleJob != "" {
+				errs = append(errs, deleteAnsibleJob(client, curator.Namespace, attempt.AnsibleJob))
+			}
+			if attempt.Job != "" {
+				errs = append(errs, deleteHookJob(client, curator.Namespace, attempt.Job))
+			}
+			attempt.Result = clustercuratorv1.CurationPhaseCancelled
+			attempt.Message = message
+			errs = append(errs, utils.RecordHookAttempt(client, curator.Name, curator.Namespace, stepName, attempt))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// runStepHook renders the extra_vars of a prehook or posthook and runs it, a failure is only
+// recorded as a warning on the step when the hook has continueOnError
+func runStepHook(
+	client client.Client,
+	curator *clustercuratorv1.ClusterCurator,
+	jobType string,
+	stepName string,
+	hook clustercuratorv1.Hook,
+	index int,
+	towerauthsecret string,
+	cancel <-chan struct{}) error {
+
+	klog.V(3).Info("Tower Job name: " + hook.Name + " type:" + string(hook.Type))
+	var err error
+	hook.ExtraVars, err = RenderExtraVars(client, curator, hook.Name, hook.ExtraVars)
+	if err == nil {
+		err = runHook(client, curator, jobType, stepName, hook, index, towerauthsecret, cancel)
+	}
+	if err == nil || !hook.ContinueOnError {
+		return err
+	}
+
+	klog.Warningf("Hook %v failed, continuing as continueOnError is set: %v", hook.Name, err.Error())
+	utils.LogWarning(utils.RecordStepWarning(client, curator.Name, curator.Namespace, stepName,
+		"Hook "+hook.Name+" failed: "+err.Error()))
+	return nil
+}
+
+// runHook creates the AnsibleJob, the Kubernetes Job of a Container hook or the request of a
+// Webhook hook, and waits for it to finish. A failed or timed out attempt is created again until
+// the retries of the hook are used up, each attempt is recorded under the status.steps entry of
+// stepName. index is the position of the hook in its hook list. Once cancel is closed, the attempt
+// stops monitoring and the hook is not retried, the attempt is left Running for cancelRunningHooks.
+// cancel is nil when the hook is not part of a parallel group.
+func runHook(
+	client client.Client,
+	curator *clustercuratorv1.ClusterCurator,
+	jobType string,
+	stepName string,
+	hook clustercuratorv1.Hook,
+	index int,
+	towerauthsecret string,
+	cancel <-chan struct{}) error {
+
+	timeout := time.Duration(0)
+	if hook.TimeoutMinutes > 0 {
+		timeout = time.Duration(hook.TimeoutMinutes) * time.Minute
+	}
+
+	backoff := time.Duration(DefaultRetryBackoff) * time.Second
+	if hook.RetryBackoff > 0 {
+		backoff = time.Duration(hook.RetryBackoff) * time.Second
+	}
+
+	// A negative number of retries would skip the hook, it still runs once
+	retries := max(hook.Retries, 0)
+
+	var err error
+	for attempt := 1; attempt <= retries+1; attempt++ {
+		if attempt > 1 {
+			klog.V(0).Infof("Retrying hook %v in %v, attempt %v of %v", hook.Name, backoff, attempt, retries+1)
+			select {
+			case <-cancel:
+				return err
+			case <-time.After(backoff):
+			}
+			backoff = backoff * 2
+		}
+
+		var hookAttempt *clustercuratorv1.HookAttempt
+		switch hook.Type {
+		case clustercuratorv1.HookTypeContainer:
+			hookAttempt, err = runContainerHookAttempt(
+				client, curator, jobType, stepName, hook, attempt, timeout, cancel)
+		case clustercuratorv1.HookTypeWebhook:
+			hookAttempt, err = runWebhookHookAttempt(
+				client, curator, jobType, stepName, hook, attempt, timeout, cancel)
+		default:
+			hookAttempt, err = runAnsibleHookAttempt(
+				client, curator, jobType, stepName, hook, index, towerauthsecret, attempt, timeout, cancel)
+		}
+		if hookAttempt == nil || (err != nil && isCancelled(cancel)) {
+			return err
+		}
+
+		hookAttempt.Result = clustercuratorv1.CurationPhaseSucceeded
+		if err != nil {
+			hookAttempt.Result = clustercuratorv1.CurationPhaseFailed
+			hookAttempt.Message = err.Error()
+		}
+		utils.LogWarning(utils.RecordHookAttempt(client, curator.Name, curator.Namespace, stepName, *hookAttempt))
+
+		var noRetry *noRetryError
+		if err == nil || errors.As(err, &noRetry) {
+			return err
+		}
+		klog.Warningf("Attempt %v of hook %v failed: %v", attempt, hook.Name, err.Error())
+	}
+
+	return err
+}
+
+func isCancelled(cancel <-chan struct{}) bool {
+	select {
+	case <-cancel:
+		return true
+	default:
+		return false
+	}
+}
+
+// runAnsibleHookAttempt creates the AnsibleJob of an attempt and waits for it to finish. When the
+// curator Job already created the AnsibleJob of the attempt, before its pod was restarted, that
+// AnsibleJob is monitored instead. A timed out or cancelled AnsibleJob is deleted. The attempt is
+// nil when the AnsibleJob could not be created.
+func runAnsibleHookAttempt(
+	client client.Client,
+	curator *clustercuratorv1.ClusterCurator,
+	jobType string,
+	stepName string,
+	hook clustercuratorv1.Hook,
+	index int,
+	towerauthsecret string,
+	attempt int,
+	timeout time.Duration,
+	cancel <-chan struct{}) (*clustercuratorv1.HookAttempt, error) {
+
+	labels := getAnsibleJobLabels(curator, jobType, index, attempt)
+	jobResource, err := findAnsibleJob(client, curator.Namespace, labels)
+	if err != nil {
+		return nil, err
+	}
+	if jobResource != nil {
+		klog.V(0).Infof("Resuming AnsibleJob %v of attempt %v of hook %v", jobResource.GetName(), attempt, hook.Name)
+	} else {
+		jobResource, err = runAnsibleJob(client, curator, jobType, hook, towerauthsecret, labels, attempt)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	klog.V(0).Infof("Monitor AnsibleJob: %v", jobResource.GetName())
+	if jobResource.GetName() == "" {
+		return nil, errors.New("Name was not generated")
+	}
+	klog.V(4).Infof("AnsibleJob: %v", redactExtraVars(jobResource))
+
+	hookAttempt := &clustercuratorv1.HookAttempt{
+		Hook:       hook.Name,
+		Attempt:    attempt,
+		AnsibleJob: jobResource.GetName(),
+		Result:     clustercuratorv1.CurationPhaseRunning,
+	}
+	utils.LogWarning(utils.RecordHookAttempt(client, curator.Name, curator.Namespace, stepName, *hookAttempt))
+
+	err = monitorAnsibleJob(client, jobResource, curator, timeout, cancel)
+	hookAttempt.URL = getAnsibleJobURL(jobResource)
+	if err == nil {
+		err = recordHookOutputs(client, curator, stepName, hook, jobResource)
+	} else if jobResource.Object["status"] != nil && !errors.Is(err, errCancelled) {
+		var kubeset kubernetes.Interface
+		if curator.Spec.CaptureFailureLogs {
+			var kubesetErr error
+			kubeset, kubesetErr = utils.GetKubeset()
+			if kubesetErr != nil {
+				klog.Warningf("Could not read the runner pod logs: %v", kubesetErr)
+				kubeset = nil
+			}
+		}
+		utils.LogWarning(recordAnsibleJobFailure(
+			client, kubeset, curator, stepName, hook, attempt, jobResource, err))
+	}
+	if errors.Is(err, errTimedOut) || errors.Is(err, errCancelled) {
+		// The AnsibleJob is removed, so it does not keep running next to the retry or after the
+		// parallel group failed
+		klog.V(0).Infof("Deleting the stopped AnsibleJob %v", jobResource.GetName())
+		if deleteErr := client.Delete(context.Background(), jobResource); deleteErr != nil &&
+			!k8serrors.IsNotFound(deleteErr) {
+			klog.Warningf("Could not delete the stopped AnsibleJob %v: %v", jobResource.GetName(), deleteErr)
+		}
+	}
+	return hookAttempt, err
+}
+
+// GetHooks returns the prehooks, the posthooks and the Tower auth secret of the curation the
+// curator Job runs
+func GetHooks(curator *clustercuratorv1.ClusterCurator) (
+	prehook []clustercuratorv1.Hook, posthook []clustercuratorv1.Hook, towerauthsecret string, err error) {
+
+	desiredCuration := utils.GetEffectiveCuration(curator)
+
 	switch desiredCuration {
 	case "install":
 		prehook = curator.Spec.Install.Prehook
@@ -63,11 +429,10 @@ func Job(client client.Client, curator *clustercuratorv1.ClusterCurator) error {
 		prehook = curator.Spec.Destroy.Prehook
 		posthook = curator.Spec.Destroy.Posthook
 		towerauthsecret = curator.Spec.Destroy.TowerAuthSecret
-		/*	case "scale":
-				hooks = curator.Spec.Scale
-			case "upgrade":
-				hooks = curator.Spec.Upgrade
-		*/
+	case "scale":
+		prehook = curator.Spec.Scale.Prehook
+		posthook = curator.Spec.Scale.Posthook
+		towerauthsecret = curator.Spec.Scale.TowerAuthSecret
 	case "installPosthook":
 		posthook = curator.Spec.Install.Posthook
 		towerauthsecret = curator.Spec.Install.TowerAuthSecret
@@ -75,40 +440,89 @@ func Job(client client.Client, curator *clustercuratorv1.ClusterCurator) error {
 		posthook = curator.Spec.Upgrade.Posthook
 		towerauthsecret = curator.Spec.Upgrade.TowerAuthSecret
 	default:
-		return errors.New("The Spec.DesiredCuration value is not supported: " + curator.Spec.DesiredCuration)
+		return nil, nil, "", errors.New("The Spec.DesiredCuration value is not supported: " + curator.Spec.DesiredCuration)
 	}
+	return prehook, posthook, towerauthsecret, nil
+}
 
-	// Extract the prehooks or posthooks
-	hooksToRun := prehook
-	if jobType == POSTHOOK {
-		hooksToRun = posthook
+// GetFailureHooks returns the onFailure hooks of the curation the curator Job runs
+func GetFailureHooks(curator *clustercuratorv1.ClusterCurator) []clustercuratorv1.Hook {
+	switch utils.GetEffectiveCuration(curator) {
+	case "install", "installPosthook":
+		return curator.Spec.Install.OnFailure
+	case "upgrade", "upgradePosthook":
+		return curator.Spec.Upgrade.OnFailure
+	case "destroy":
+		return curator.Spec.Destroy.OnFailure
+	case "scale":
+		return curator.Spec.Scale.OnFailure
 	}
+	return nil
+}
 
-	// Move on when clusterCurator is missing or job hook is missing
+// RunFailureHooks runs the onFailure hooks after failedStep failed with failureMessage. The step
+// and the message are added to the extra_vars of each AnsibleJob, and the attempts are recorded
+// under the failed step. A hook without timeoutMinutes times out after DefaultFailureHookTimeout
+// minutes. Every hook is run, even when an earlier one fails, so an incident is still opened when
+// the rollback hook fails.
+func RunFailureHooks(
+	client client.Client,
+	curator *clustercuratorv1.ClusterCurator,
+	failedStep string,
+	failureMessage string) error {
+
+	hooksToRun := GetFailureHooks(curator)
 	if len(hooksToRun) == 0 {
-		klog.V(0).Infof("No ansibleJob detected for %v", jobType)
+		klog.V(2).Infof("No ansibleJob detected for %v", ONFAILURE)
 		return nil
 	}
 
-	for _, ttn := range hooksToRun {
+	_, _, towerauthsecret, err := GetHooks(curator)
+	if err != nil {
+		return err
+	}
+
+	var errs []error
+	for i, ttn := range hooksToRun {
 		klog.V(3).Info("Tower Job name: " + ttn.Name + " type:" + string(ttn.Type))
-		jobResource, err := RunAnsibleJob(client, curator, jobType, ttn, towerauthsecret)
+		if ttn.TimeoutMinutes <= 0 {
+			ttn.TimeoutMinutes = DefaultFailureHookTimeout
+		}
+		// The failure message is added after rendering, so it is never read as a template
+		ttn.ExtraVars, err = RenderExtraVars(client, curator, ttn.Name, ttn.ExtraVars)
+		if err == nil {
+			ttn.ExtraVars, err = addFailureExtraVars(ttn.ExtraVars, failedStep, failureMessage)
+		}
 		if err != nil {
-			return err
+			errs = append(errs, err)
+			continue
 		}
 
-		klog.V(0).Infof("Monitor AnsibleJob: %v", jobResource.GetName())
-		if jobResource.GetName() == "" {
-			return errors.New("Name was not generated")
+		if err := runHook(client, curator, ONFAILURE, failedStep, ttn, i, towerauthsecret, nil); err != nil {
+			errs = append(errs, err)
 		}
-		klog.V(4).Infof("AnsibleJob: %v", jobResource)
-		err = MonitorAnsibleJob(client, jobResource, curator)
-		if err != nil {
-			return err
+	}
+
+	return errors.Join(errs...)
+}
+
+func addFailureExtraVars(extraVars *runtime.RawExtension, failedStep string, failureMessage string) (
+	*runtime.RawExtension, error) {
+
+	mapExtraVars := map[string]interface{}{}
+	if extraVars != nil {
+		if err := json.Unmarshal(extraVars.Raw, &mapExtraVars); err != nil {
+			return nil, err
 		}
 	}
+	mapExtraVars["failed_step"] = failedStep
+	mapExtraVars["failure_message"] = failureMessage
 
-	return nil
+	raw, err := json.Marshal(mapExtraVars)
+	if err != nil {
+		return nil, err
+	}
+	return &runtime.RawExtension{Raw: raw}, nil
 }
 
 func getAnsibleJob(jobtype string, // pre or post
@@ -119,7 +533,7 @@ func getAnsibleJob(jobtype string, // pre or post
 	ansibleJobName string,
 	clusterName string,
 	jobTags string,
-	skipTags string) *unstructured.Unstructured {
+	skipTags string) (*unstructured.Unstructured, error) {
 
 	/*mapExtraVars := map[string]interface{}{}
 	if extraVars != nil {
@@ -161,8 +575,9 @@ func getAnsibleJob(jobtype string, // pre or post
 
 	if extraVars != nil {
 
-		err := json.Unmarshal(extraVars.Raw, &mapExtraVars)
-		utils.CheckError(err)
+		if err := json.Unmarshal(extraVars.Raw, &mapExtraVars); err != nil {
+			return nil, err
+		}
 	}
 
 	ansibleJob.Object["spec"].(map[string]interface{})["extra_vars"] = mapExtraVars
@@ -177,7 +592,32 @@ func getAnsibleJob(jobtype string, // pre or post
 		}
 	}
 
-	return ansibleJob
+	return ansibleJob, nil
+}
+
+// setAnsibleJobOptions copies the limit, verbosity, job_ttl and runner image of the hook into the
+// spec of the AnsibleJob. Like the tags, the limit and verbosity are not used by a Workflow.
+func setAnsibleJobOptions(ansibleJob *unstructured.Unstructured, hookToRun clustercuratorv1.Hook) {
+	spec := ansibleJob.Object["spec"].(map[string]interface{})
+
+	if hookToRun.Type != clustercuratorv1.HookTypeWorkflow {
+		if hookToRun.Limit != "" {
+			spec["limit"] = hookToRun.Limit
+		}
+		if hookToRun.Verbosity != nil {
+			spec["verbosity"] = int64(*hookToRun.Verbosity)
+		}
+	}
+
+	if hookToRun.JobTTL > 0 {
+		spec["job_ttl"] = int64(hookToRun.JobTTL)
+	}
+	if hookToRun.RunnerImage != "" {
+		spec["runner_image"] = hookToRun.RunnerImage
+	}
+	if hookToRun.RunnerVersion != "" {
+		spec["runner_version"] = hookToRun.RunnerVersion
+	}
 }
 
 // Retreive the cluster deployment for use in the extra_vars
@@ -322,12 +762,66 @@ func getManagedClusterInfo(client client.Client, clusterName string) (map[string
 // 	return ret
 // }
 
+// addClusterContext adds the cluster_deployment, install_config and, for an upgrade, cluster_info
+// of the cluster to the extra_vars of a hook. Without a ClusterDeployment, the hosted_cluster and
+// node_pools of a HostedCluster are added instead.
+func addClusterContext(
+	client client.Client,
+	curator *clustercuratorv1.ClusterCurator,
+	extraVars map[string]interface{}) error {
+
+	namespace := curator.Namespace
+
+	cd, err := getClusterDeployment(client, namespace)
+	if err != nil {
+		if k8serrors.IsNotFound(err) {
+			klog.Warning("Did not find clusterDeployment")
+			if err := addHostedClusterContext(client, curator, extraVars); err != nil {
+				return err
+			}
+		} else {
+			return err
+		}
+	} else {
+		extraVars["cluster_deployment"] = cd["spec"]
+	}
+
+	mp, err := getInstallConfig(client, namespace)
+	if err != nil {
+		if k8serrors.IsNotFound(err) {
+			klog.Warning("Did not find install-config")
+		} else {
+			return err
+		}
+	} else {
+		extraVars["install_config"] = mp
+	}
+
+	if curator.Spec.DesiredCuration == "upgrade" {
+		mcl, err := getManagedClusterInfo(client, namespace)
+		if err != nil {
+			if k8serrors.IsNotFound(err) {
+				klog.Warning("Did not find managedClusterInfo")
+			} else {
+				return err
+			}
+		} else {
+			extraVars["cluster_info"] = mcl
+		}
+	}
+	return nil
+}
+
 /* RunAnsibleJob - Run a basic AnsbileJob kind to trigger an Ansible Teamplte Job playbook
  *  config           # kubeconfig
  *  namespace        # The cluster's namespace
  *  jobtype          # "pre" or "post"
  *  jobTemplateName  # Tower Template job to run
  *  secretRef		 # The secret to connect to Tower in the cluster namespace, ie. toweraccess
+ *
+ * The towerAuthSecret and inventory of the hook override the secretRef and the inventory of the
+ * ClusterCurator. Its limit, verbosity, job_ttl, runner_image and runner_version are passed to the
+ * AnsibleJob, the runner_image must be allowed by the HOOK_IMAGE_ALLOWLIST of the controller.
  */
 func RunAnsibleJob(
 	client client.Client,
@@ -336,12 +830,34 @@ func RunAnsibleJob(
 	hookToRun clustercuratorv1.Hook,
 	secretRef string) (*unstructured.Unstructured, error) {
 
+	return runAnsibleJob(client, curator, jobtype, hookToRun, secretRef, nil, 1)
+}
+
+// runAnsibleJob creates the AnsibleJob of an attempt like RunAnsibleJob, with the labels added to
+// it. The AnsibleJob is owned by the curator and labeled with the curation run.
+func runAnsibleJob(
+	client client.Client,
+	curator *clustercuratorv1.ClusterCurator,
+	jobtype string,
+	hookToRun clustercuratorv1.Hook,
+	secretRef string,
+	labels map[string]string,
+	attempt int) (*unstructured.Unstructured, error) {
+
 	klog.V(2).Info("* Run " + jobtype + " AnsibleJob " + string(hookToRun.Type))
 
 	namespace := curator.Namespace
 	klog.V(4).Infof("hookToRun: %v", hookToRun)
 
-	ansibleJob := getAnsibleJob(
+	if hookToRun.TowerAuthSecret != "" {
+		secretRef = hookToRun.TowerAuthSecret
+	}
+	if hookToRun.RunnerImage != "" && !utils.IsHookImageAllowed(hookToRun.RunnerImage, utils.GetHookImageAllowlist()) {
+		return nil, errors.New("The runner_image " + hookToRun.RunnerImage + " of hook " + hookToRun.Name +
+			" is not allowed by the " + utils.HookImageAllowlistEnv + " of the controller")
+	}
+
+	ansibleJob, err := getAnsibleJob(
 		jobtype,
 		string(hookToRun.Type),
 		hookToRun.Name,
@@ -351,48 +867,40 @@ func RunAnsibleJob(
 		namespace,
 		hookToRun.JobTags,
 		hookToRun.SkipTags)
-
-	cd, err := getClusterDeployment(client, namespace)
 	if err != nil {
-		if k8serrors.IsNotFound(err) {
-			klog.Warning("Did not find clusterDeployment")
-		} else {
-			return nil, err
-		}
-	} else {
-		ansibleJob.Object["spec"].(map[string]interface{})["extra_vars"].(map[string]interface{})["cluster_deployment"] = cd["spec"]
+		return nil, err
+	}
+	setAnsibleJobOptions(ansibleJob, hookToRun)
+	if len(labels) > 0 {
+		ansibleJob.SetLabels(labels)
 	}
+	utils.SetCurationRunOwner(ansibleJob, curator)
 
-	mp, err := getInstallConfig(client, namespace)
+	extraVars := ansibleJob.Object["spec"].(map[string]interface{})["extra_vars"].(map[string]interface{})
+	if err := addExtraVarsFrom(client, namespace, hookToRun, extraVars); err != nil {
+		return nil, err
+	}
+	err = addClusterContext(client, curator, extraVars)
 	if err != nil {
-		if k8serrors.IsNotFound(err) {
-			klog.Warning("Did not find install-config")
-		} else {
-			return nil, err
-		}
-	} else {
-		ansibleJob.Object["spec"].(map[string]interface{})["extra_vars"].(map[string]interface{})["install_config"] = mp
+		return nil, err
 	}
-
-	if curator.Spec.DesiredCuration == "upgrade" {
-		mcl, err := getManagedClusterInfo(client, namespace)
-		if err != nil {
-			if k8serrors.IsNotFound(err) {
-				klog.Warning("Did not find managedClusterInfo")
-			} else {
-				return nil, err
-			}
-		} else {
-			ansibleJob.Object["spec"].(map[string]interface{})["extra_vars"].(map[string]interface{})["cluster_info"] = mcl
-		}
+	if err := addCurationContext(client, curator, jobtype, attempt, extraVars); err != nil {
+		return nil, err
+	}
+	if err := addHookOutputs(client, curator, extraVars); err != nil {
+		return nil, err
 	}
 
-	if curator.Spec.Inventory != "" {
-		ansibleJob.Object["spec"].(map[string]interface{})["inventory"] = curator.Spec.Inventory
+	inventory := curator.Spec.Inventory
+	if hookToRun.Inventory != "" {
+		inventory = hookToRun.Inventory
+	}
+	if inventory != "" {
+		ansibleJob.Object["spec"].(map[string]interface{})["inventory"] = inventory
 	}
 
 	klog.V(0).Info("Creating AnsibleJob " + ansibleJob.GetName() + " in namespace " + namespace)
-	klog.V(4).Infof("ansibleJob: %v", ansibleJob)
+	klog.V(4).Infof("ansibleJob: %v", redactExtraVars(ansibleJob))
 	err = client.Create(context.Background(), ansibleJob)
 
 	if err != nil {
@@ -404,22 +912,84 @@ func RunAnsibleJob(
 	return ansibleJob, nil
 }
 
+// getAnsibleJobLabels returns the labels of the AnsibleJob of an attempt of the hook at index in the
+// hooks of jobType. The curator Job name identifies the curation run, no labels are returned when
+// the curator is not run by a curator Job.
+func getAnsibleJobLabels(
+	curator *clustercuratorv1.ClusterCurator, jobType string, index int, attempt int) map[string]string {
+
+	if curator.Spec.CuratingJob == "" {
+		return nil
+	}
+	return map[string]string{
+		CurationRunLabel: curator.Spec.CuratingJob,
+		HookIndexLabel:   jobType + "-" + strconv.Itoa(index),
+		HookAttemptLabel: strconv.Itoa(attempt),
+	}
+}
+
+// findAnsibleJob returns the AnsibleJob in namespace with the labels, nil when there is none or no
+// labels are given. It is also nil when the AnsibleJobs can not be listed, as RBAC created by an
+// earlier version of the curator does not allow it, so a new AnsibleJob is created.
+func findAnsibleJob(
+	client client.Client, namespace string, labels map[string]string) (*unstructured.Unstructured, error) {
+
+	if len(labels) == 0 {
+		return nil, nil
+	}
+
+	ansibleJobs := &unstructured.UnstructuredList{}
+	ansibleJobs.SetAPIVersion("tower.ansible.com/v1alpha1")
+	ansibleJobs.SetKind("AnsibleJobList")
+	if err := client.List(context.Background(), ansibleJobs, listOptions(namespace, labels)...); err != nil {
+		if k8serrors.IsForbidden(err) {
+			klog.Warningf("Could not list the AnsibleJobs to resume, creating a new one: %v", err.Error())
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	if len(ansibleJobs.Items) == 0 {
+		return nil, nil
+	}
+	if len(ansibleJobs.Items) > 1 {
+		klog.Warningf("Found %v AnsibleJobs for %v, monitoring %v", len(ansibleJobs.Items), labels,
+			ansibleJobs.Items[0].GetName())
+	}
+	return &ansibleJobs.Items[0], nil
+}
+
 func MonitorAnsibleJob(
 	client client.Client,
 	jobResource *unstructured.Unstructured,
 	curator *clustercuratorv1.ClusterCurator) error {
 
+	return monitorAnsibleJob(client, jobResource, curator, 0, nil)
+}
+
+// monitorAnsibleJob waits for the AnsibleJob to finish, a timeout of zero waits until it does. It
+// stops once cancel is closed.
+func monitorAnsibleJob(
+	client client.Client,
+	jobResource *unstructured.Unstructured,
+	curator *clustercuratorv1.ClusterCurator,
+	timeout time.Duration,
+	cancel <-chan struct{}) error {
+
+	start := time.Now()
 	namespace := jobResource.GetNamespace()
 	ansibleJobName := jobResource.GetName()
 	klog.V(0).Info("* Monitoring AnsibleJob " + namespace + "/" + jobResource.GetName())
 
-	utils.CheckError(utils.RecordCurrentStatusCondition(
+	if err := utils.RecordCurrentStatusCondition(
 		client,
 		curator.Name,
 		curator.Namespace,
 		"current-ansiblejob",
 		v1.ConditionFalse,
-		jobResource.GetName()))
+		jobResource.GetName()); err != nil {
+		return err
+	}
 
 	// Monitor the AnsibeJob resource
 	foundUrlOnce := false
@@ -434,14 +1004,19 @@ func MonitorAnsibleJob(
 			return err
 		}
 
-		klog.V(4).Infof("ansibleJob: %v", jobResource)
+		klog.V(4).Infof("ansibleJob: %v", redactExtraVars(jobResource))
 
 		// Track initialization of status
 		if jobResource.Object == nil || jobResource.Object["status"] == nil ||
 			jobResource.Object["status"].(map[string]interface{})["conditions"] == nil {
 
 			klog.V(2).Infof("AnsibleJob %v/%v is initializing", namespace, ansibleJobName)
-			time.Sleep(utils.PauseFiveSeconds)
+			if err := checkTimeout("AnsibleJob "+namespace+"/"+ansibleJobName, start, timeout); err != nil {
+				return err
+			}
+			if err := waitForNextPoll("AnsibleJob "+namespace+"/"+ansibleJobName, cancel); err != nil {
+				return err
+			}
 			continue
 		}
 
@@ -452,13 +1027,15 @@ func MonitorAnsibleJob(
 			klog.V(2).Infof("Found result url %v", jobStatusUrl)
 
 			if !foundUrlOnce && jobStatusUrl != nil {
-				utils.CheckError(utils.RecordAnsibleJobStatusUrlCondition(
+				if err := utils.RecordAnsibleJobStatusUrlCondition(
 					client,
 					curator.Name,
 					curator.Namespace,
 					jobResource.GetName(),
 					v1.ConditionTrue,
-					jobStatusUrl.(string)))
+					jobStatusUrl.(string)); err != nil {
+					return err
+				}
 				foundUrlOnce = true
 			}
 
@@ -469,13 +1046,15 @@ func MonitorAnsibleJob(
 
 				klog.V(2).Infof("AnsibleJob %v/%v finished successfully ✓", namespace, ansibleJobName)
 
-				utils.CheckError(utils.RecordCurrentStatusCondition(
+				if err := utils.RecordCurrentStatusCondition(
 					client,
 					curator.Name,
 					curator.Namespace,
 					"current-ansiblejob",
 					v1.ConditionTrue,
-					jobResource.GetName()))
+					jobResource.GetName()); err != nil {
+					return err
+				}
 
 				break
 			} else if jobStatus == "error" {
@@ -497,7 +1076,125 @@ func MonitorAnsibleJob(
 			}
 		}
 		klog.V(2).Infof("AnsibleJob %v/%v is still running", namespace, ansibleJobName)
-		time.Sleep(utils.PauseFiveSeconds)
+		if err := checkTimeout("AnsibleJob "+namespace+"/"+ansibleJobName, start, timeout); err != nil {
+			return err
+		}
+		if err := waitForNextPoll("AnsibleJob "+namespace+"/"+ansibleJobName, cancel); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// errTimedOut is wrapped by the error of a hook attempt that did not finish within its timeout
+var errTimedOut = errors.New("timed out")
+
+// errCancelled is wrapped by the error of a hook attempt that stopped as another hook of its
+// parallel group failed
+var errCancelled = errors.New("cancelled")
+
+// noRetryError is the error of a hook attempt that is not retried
+type noRetryError struct {
+	err error
+}
+
+func (e *noRetryError) Error() string {
+	return e.err.Error()
+}
+
+func (e *noRetryError) Unwrap() error {
+	return e.err
+}
+
+func checkTimeout(resource string, start time.Time, timeout time.Duration) error {
+	if timeout > 0 && time.Since(start) >= timeout {
+		return fmt.Errorf("%v %w after %v", resource, errTimedOut, timeout)
+	}
+	return nil
+}
+
+// waitForNextPoll waits before a monitor polls the resource again, an error wrapping errCancelled
+// is returned as soon as cancel is closed
+func waitForNextPoll(resource string, cancel <-chan struct{}) error {
+	select {
+	case <-cancel:
+		return fmt.Errorf("%v %w", resource, errCancelled)
+	case <-time.After(utils.PauseFiveSeconds):
+		return nil
+	}
+}
+
+// getAnsibleJobURL returns the Tower URL of the AnsibleJob, or an empty string before Tower
+// reports it
+func getAnsibleJobURL(jobResource *unstructured.Unstructured) string {
+	url, _, _ := unstructured.NestedString(jobResource.Object, "status", "ansibleJobResult", "url")
+	return url
+}
+
+// DeleteAnsibleJob removes the AnsibleJobs the curator is waiting on. The in-flight AnsibleJob is
+// found by its current-ansiblejob condition, and the hooks of a parallel group by their Running
+// attempts in status.steps. The Jobs of running Container hooks are removed as well.
+func DeleteAnsibleJob(client client.Client, curator *clustercuratorv1.ClusterCurator) error {
+	names := []string{}
+	jobNames := []string{}
+	condition := meta.FindStatusCondition(curator.Status.Conditions, "current-ansiblejob")
+	if condition != nil && condition.Status == v1.ConditionFalse && condition.Message != "" {
+		names = append(names, condition.Message)
+	}
+	for _, step := range curator.Status.Steps {
+		for _, attempt := range step.HookAttempts {
+			if attempt.Result != clustercuratorv1.CurationPhaseRunning {
+				continue
+			}
+			if attempt.AnsibleJob != "" && !slices.Contains(names, attempt.AnsibleJob) {
+				names = append(names, attempt.AnsibleJob)
+			}
+			if attempt.Job != "" {
+				jobNames = append(jobNames, attempt.Job)
+			}
+		}
+	}
+
+	if len(names) == 0 && len(jobNames) == 0 {
+		klog.V(2).Info("No AnsibleJob running for " + curator.Namespace + "/" + curator.Name)
+		return nil
+	}
+
+	for _, name := range names {
+		if err := deleteAnsibleJob(client, curator.Namespace, name); err != nil {
+			return err
+		}
+	}
+
+	for _, name := range jobNames {
+		if err := deleteHookJob(client, curator.Namespace, name); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func deleteAnsibleJob(client client.Client, namespace string, name string) error {
+	ansibleJob := &unstructured.Unstructured{}
+	ansibleJob.SetAPIVersion("tower.ansible.com/v1alpha1")
+	ansibleJob.SetKind("AnsibleJob")
+	ansibleJob.SetNamespace(namespace)
+	ansibleJob.SetName(name)
+
+	klog.V(0).Info("Deleting AnsibleJob " + namespace + "/" + name)
+	if err := client.Delete(context.Background(), ansibleJob); err != nil && !k8serrors.IsNotFound(err) {
+		return err
+	}
+	return nil
+}
+
+// deleteHookJob deletes the Kubernetes Job of a Container hook with its pods
+func deleteHookJob(client client.Client, namespace string, name string) error {
+	job := &batchv1.Job{ObjectMeta: v1.ObjectMeta{Name: name, Namespace: namespace}}
+
+	klog.V(0).Info("Deleting Job " + namespace + "/" + name)
+	if err := client.Delete(context.Background(), job, deleteInBackground); err != nil && !k8serrors.IsNotFound(err) {
+		return err
 	}
 	return nil
 }
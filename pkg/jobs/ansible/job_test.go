@@ -3,7 +3,9 @@ package ansible
 
 import (
 	"context"
+	"errors"
 	"os"
+	"sync/atomic"
 	"testing"
 	"time"
 
@@ -14,6 +16,7 @@ import (
 	managedclusterinfov1beta1 "github.com/stolostron/cluster-lifecycle-api/clusterinfo/v1beta1"
 	"github.com/stretchr/testify/assert"
 	corev1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
 	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime"
@@ -21,6 +24,7 @@ import (
 	"open-cluster-management.io/api/client/cluster/clientset/versioned/scheme"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	clientfake "sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
 )
 
 const EnvJobType = "JOB_TYPE"
@@ -29,7 +33,7 @@ const AnsibleJobName = "my-ansiblejob-12345"
 const SecretRef = "toweraccess"
 const AnsibleJobTemplateName = "Ansible Tower Template to run as a job"
 
-var ansibleJob = getAnsibleJob(PREHOOK, "", AnsibleJobTemplateName, SecretRef, nil, AnsibleJobName, ClusterName, "", "")
+var ansibleJob, _ = getAnsibleJob(PREHOOK, "", AnsibleJobTemplateName, SecretRef, nil, AnsibleJobName, ClusterName, "", "")
 var s = scheme.Scheme
 
 func getClusterCurator() *clustercuratorv1.ClusterCurator {
@@ -301,6 +305,27 @@ func TestJobInstallUpgradeRetryposthook(t *testing.T) {
 	assert.Nil(t, Job(nil, cc), "Test upgradePosthook case statement only")
 }
 
+func TestJobScaleNoHooks(t *testing.T) {
+	cc := getClusterCuratorEmpty()
+	cc.Spec.DesiredCuration = "scale"
+	cc.Spec.Scale = clustercuratorv1.ScaleHooks{
+		MachinePools: []clustercuratorv1.ScaleTarget{
+			{
+				Name:     ClusterName + "-worker",
+				Replicas: 3,
+			},
+		},
+	}
+
+	t.Logf("Test %v", PREHOOK)
+	os.Setenv(EnvJobType, PREHOOK)
+	assert.Nil(t, Job(nil, cc), "err nil, when scale has no Ansible prehooks")
+
+	t.Logf("Test %v", POSTHOOK)
+	os.Setenv(EnvJobType, POSTHOOK)
+	assert.Nil(t, Job(nil, cc), "err nil, when scale has no Ansible posthooks")
+}
+
 func TestFindAnsibleTemplateNamefromClusterCurator(t *testing.T) {
 
 	cc := getClusterCurator()
@@ -411,6 +436,659 @@ func TestJobPosthook(t *testing.T) {
 	assert.Nil(t, err,
 		"err nil, when Ansible Job created and monitored with AnsibleJobStatus successful")
 }
+func TestRunFailureHooks(t *testing.T) {
+
+	cc := getClusterCurator()
+	cc.Spec.Install.OnFailure = []clustercuratorv1.Hook{
+		clustercuratorv1.Hook{
+			Name: "Open an incident",
+			ExtraVars: &runtime.RawExtension{
+				Raw: []byte(`{"variable1": "5"}`),
+			},
+		},
+	}
+
+	s.AddKnownTypes(ajv1.SchemeBuilder.GroupVersion, &ajv1.AnsibleJob{})
+	s.AddKnownTypes(clustercuratorv1.SchemeBuilder.GroupVersion, &clustercuratorv1.ClusterCurator{})
+	s.AddKnownTypes(hivev1.SchemeBuilder.GroupVersion, &hivev1.ClusterDeployment{}, &hivev1.MachinePool{})
+	s.AddKnownTypes(corev1.SchemeGroupVersion, &corev1.Secret{})
+	client := clientfake.NewClientBuilder().WithScheme(s).WithRuntimeObjects(
+		cc, genClusterDeployment(), genMachinePool()).Build()
+
+	go func() {
+		time.Sleep(utils.PauseFiveSeconds)
+
+		curator := &clustercuratorv1.ClusterCurator{}
+
+		_ = client.Get(
+			context.Background(),
+			types.NamespacedName{Namespace: ClusterName, Name: ClusterName},
+			curator)
+
+		jobName := curator.Status.Conditions[0].Message
+		t.Logf("clusterCurator job: %v", jobName)
+
+		createdJob := &unstructured.Unstructured{}
+		createdJob.SetGroupVersionKind(ajv1.SchemeBuilder.GroupVersion.WithKind("AnsibleJob"))
+		assert.Nil(t, client.Get(context.Background(),
+			types.NamespacedName{Namespace: ClusterName, Name: jobName}, createdJob))
+		extraVars := createdJob.Object["spec"].(map[string]interface{})["extra_vars"].(map[string]interface{})
+		assert.Equal(t, "activate-and-monitor", extraVars["failed_step"])
+		assert.Equal(t, "Timed out", extraVars["failure_message"])
+		assert.Equal(t, "5", extraVars["variable1"], "the hook extra_vars are kept")
+		assert.Equal(t, ONFAILURE, createdJob.GetAnnotations()["jobtype"])
+
+		newJob := buildAnsibleJob("successful", AnsibleJobTemplateName)
+
+		newJob.SetName(jobName)
+		newJob.SetNamespace(ClusterName)
+
+		assert.Nil(t,
+			client.Delete(context.Background(), newJob),
+			"err is nil, when ansibleJob resource is deleted")
+		assert.Nil(t,
+			client.Create(context.Background(), newJob),
+			"err is nil, when ansibleJob resource is created")
+		t.Logf("AnsibleJob %v marked successful", jobName)
+	}()
+	err := RunFailureHooks(client, cc, "activate-and-monitor", "Timed out")
+
+	assert.Nil(t, err,
+		"err nil, when the onFailure Ansible Job created and monitored with AnsibleJobStatus successful")
+}
+
+func TestRunFailureHooksNoHooks(t *testing.T) {
+
+	cc := getClusterCurator()
+	client := clientfake.NewClientBuilder().WithScheme(s).Build()
+
+	assert.Nil(t, RunFailureHooks(client, cc, "activate-and-monitor", "Timed out"),
+		"err nil, when there are no onFailure hooks")
+	assert.Nil(t, GetFailureHooks(cc))
+
+	cc.Spec.DesiredCuration = "upgrade"
+	cc.Spec.Upgrade.OnFailure = []clustercuratorv1.Hook{{Name: "Roll back"}}
+	assert.Equal(t, "Roll back", GetFailureHooks(cc)[0].Name)
+
+	cc.Spec.DesiredCuration = "scale"
+	cc.Spec.Scale.OnFailure = []clustercuratorv1.Hook{{Name: "Scale back"}}
+	assert.Equal(t, "Scale back", GetFailureHooks(cc)[0].Name)
+}
+
+func TestJobRetry(t *testing.T) {
+
+	cc := getClusterCurator()
+	cc.Spec.Install.Prehook[0].Retries = 1
+	cc.Spec.Install.Prehook[0].RetryBackoff = 1
+	cc.Status.Steps = []clustercuratorv1.CurationStep{{
+		Name:  "prehook-ansiblejob",
+		State: clustercuratorv1.CurationPhaseRunning,
+	}}
+
+	os.Setenv(EnvJobType, PREHOOK)
+
+	s.AddKnownTypes(ajv1.SchemeBuilder.GroupVersion, &ajv1.AnsibleJob{})
+	s.AddKnownTypes(clustercuratorv1.SchemeBuilder.GroupVersion, &clustercuratorv1.ClusterCurator{})
+	s.AddKnownTypes(hivev1.SchemeBuilder.GroupVersion, &hivev1.ClusterDeployment{}, &hivev1.MachinePool{})
+	s.AddKnownTypes(corev1.SchemeGroupVersion, &corev1.Secret{})
+	client := clientfake.NewClientBuilder().WithScheme(s).WithRuntimeObjects(
+		cc, genClusterDeployment(), genMachinePool()).Build()
+
+	// Replaces the AnsibleJob the curator is monitoring with one in the given state
+	finishAnsibleJob := func(ajs string, previousJob string) string {
+		curator := &clustercuratorv1.ClusterCurator{}
+		jobName := previousJob
+		for jobName == previousJob {
+			time.Sleep(utils.PauseFiveSeconds)
+			_ = client.Get(
+				context.Background(),
+				types.NamespacedName{Namespace: ClusterName, Name: ClusterName},
+				curator)
+			jobName = curator.Status.Conditions[0].Message
+		}
+
+		newJob := buildAnsibleJob(ajs, AnsibleJobTemplateName)
+		newJob.Status.AnsibleJobResult.Url = "https://tower/#/jobs/" + jobName
+		newJob.SetName(jobName)
+		newJob.SetNamespace(ClusterName)
+
+		assert.Nil(t,
+			client.Delete(context.Background(), newJob),
+			"err is nil, when ansibleJob resource is deleted")
+		assert.Nil(t,
+			client.Create(context.Background(), newJob),
+			"err is nil, when ansibleJob resource is created")
+		t.Logf("AnsibleJob %v marked %v", jobName, ajs)
+		return jobName
+	}
+
+	go func() {
+		firstJob := finishAnsibleJob("error", "")
+		finishAnsibleJob("successful", firstJob)
+	}()
+
+	assert.Nil(t, Job(client, cc), "err nil, when the second attempt is successful")
+
+	curator := &clustercuratorv1.ClusterCurator{}
+	assert.Nil(t, client.Get(context.Background(),
+		types.NamespacedName{Namespace: ClusterName, Name: ClusterName}, curator))
+
+	attempts := curator.Status.Steps[0].HookAttempts
+	assert.Equal(t, 2, len(attempts), "both attempts are recorded")
+	assert.Equal(t, 1, attempts[0].Attempt)
+	assert.Equal(t, clustercuratorv1.CurationPhaseFailed, attempts[0].Result)
+	assert.Contains(t, attempts[0].Message, "exited with an error")
+	assert.Equal(t, "https://tower/#/jobs/"+attempts[0].AnsibleJob, attempts[0].URL)
+	assert.Equal(t, 2, attempts[1].Attempt)
+	assert.Equal(t, clustercuratorv1.CurationPhaseSucceeded, attempts[1].Result)
+	assert.Equal(t, "Service now App Update", attempts[1].Hook)
+	assert.NotEqual(t, attempts[0].AnsibleJob, attempts[1].AnsibleJob, "a new AnsibleJob is created")
+
+	assert.NotNil(t, curator.Status.LastFailure, "the failed AnsibleJob is recorded in lastFailure")
+	assert.Equal(t, attempts[0].AnsibleJob, curator.Status.LastFailure.AnsibleJob)
+	assert.Equal(t, 1, curator.Status.LastFailure.Attempt)
+	assert.Contains(t, curator.Status.LastFailure.Message, "exited with an error")
+}
+
+func TestJobContinueOnError(t *testing.T) {
+
+	cc := getClusterCurator()
+	cc.Spec.Install.Posthook = []clustercuratorv1.Hook{
+		{Name: "Update the CMDB", ContinueOnError: true},
+		{Name: "Service now App Update"},
+	}
+	cc.Status.Steps = []clustercuratorv1.CurationStep{{
+		Name:  "posthook-ansiblejob",
+		State: clustercuratorv1.CurationPhaseRunning,
+	}}
+
+	os.Setenv(EnvJobType, POSTHOOK)
+
+	s.AddKnownTypes(ajv1.SchemeBuilder.GroupVersion, &ajv1.AnsibleJob{})
+	s.AddKnownTypes(clustercuratorv1.SchemeBuilder.GroupVersion, &clustercuratorv1.ClusterCurator{})
+	s.AddKnownTypes(hivev1.SchemeBuilder.GroupVersion, &hivev1.ClusterDeployment{}, &hivev1.MachinePool{})
+	s.AddKnownTypes(corev1.SchemeGroupVersion, &corev1.Secret{})
+	client := clientfake.NewClientBuilder().WithScheme(s).WithRuntimeObjects(
+		cc, genClusterDeployment(), genMachinePool()).Build()
+
+	go func() {
+		jobName := ""
+		for _, ajs := range []string{"error", "successful"} {
+			curator := &clustercuratorv1.ClusterCurator{}
+			previousJob := jobName
+			for jobName == previousJob {
+				time.Sleep(utils.PauseFiveSeconds)
+				_ = client.Get(
+					context.Background(),
+					types.NamespacedName{Namespace: ClusterName, Name: ClusterName},
+					curator)
+				jobName = curator.Status.Conditions[0].Message
+			}
+
+			newJob := buildAnsibleJob(ajs, AnsibleJobTemplateName)
+			newJob.SetName(jobName)
+			newJob.SetNamespace(ClusterName)
+
+			assert.Nil(t,
+				client.Delete(context.Background(), newJob),
+				"err is nil, when ansibleJob resource is deleted")
+			assert.Nil(t,
+				client.Create(context.Background(), newJob),
+				"err is nil, when ansibleJob resource is created")
+			t.Logf("AnsibleJob %v marked %v", jobName, ajs)
+		}
+	}()
+
+	assert.Nil(t, Job(client, cc), "err nil, when the failed hook has continueOnError")
+
+	curator := &clustercuratorv1.ClusterCurator{}
+	assert.Nil(t, client.Get(context.Background(),
+		types.NamespacedName{Namespace: ClusterName, Name: ClusterName}, curator))
+
+	step := curator.Status.Steps[0]
+	assert.Equal(t, 1, len(step.Warnings), "the failure is recorded as a warning")
+	assert.Contains(t, step.Warnings[0], "Hook Update the CMDB failed")
+	assert.Equal(t, 2, len(step.HookAttempts), "the next hook still runs")
+	assert.Equal(t, clustercuratorv1.CurationPhaseSucceeded, step.HookAttempts[1].Result)
+}
+
+func TestGroupHooks(t *testing.T) {
+
+	groups := groupHooks([]clustercuratorv1.Hook{
+		{Name: "dns", ParallelGroup: "network"},
+		{Name: "ipam", ParallelGroup: "network"},
+		{Name: "load balancer", ParallelGroup: "network"},
+		{Name: "cmdb"},
+		{Name: "notify", ParallelGroup: "network"},
+	})
+
+	assert.Equal(t, 3, len(groups))
+	assert.Equal(t, 3, len(groups[0]), "consecutive hooks of a group run together")
+	assert.Equal(t, "cmdb", groups[1][0].Name)
+	assert.Equal(t, "notify", groups[2][0].Name, "a group only spans consecutive hooks")
+}
+
+func TestGetParallelFailurePolicy(t *testing.T) {
+
+	cc := getClusterCurator()
+	assert.Equal(t, clustercuratorv1.ParallelFailurePolicyFailFast, GetParallelFailurePolicy(cc))
+
+	cc.Spec.Install.ParallelFailurePolicy = clustercuratorv1.ParallelFailurePolicyWaitForAll
+	assert.Equal(t, clustercuratorv1.ParallelFailurePolicyWaitForAll, GetParallelFailurePolicy(cc))
+}
+
+// getParallelTestClient returns a client with a curator running two prehooks in a parallel group,
+// and a function that waits until both AnsibleJobs are created
+func getParallelTestClient(t *testing.T, policy clustercuratorv1.ParallelFailurePolicy) (
+	*clustercuratorv1.ClusterCurator, client.Client, func() []string) {
+
+	cc := getClusterCurator()
+	cc.Spec.Install.ParallelFailurePolicy = policy
+	cc.Spec.Install.Prehook = []clustercuratorv1.Hook{
+		{Name: "Register DNS", ParallelGroup: "network"},
+		{Name: "Allocate IPs", ParallelGroup: "network"},
+	}
+	cc.Status.Steps = []clustercuratorv1.CurationStep{{
+		Name:  "prehook-ansiblejob",
+		State: clustercuratorv1.CurationPhaseRunning,
+	}}
+
+	os.Setenv(EnvJobType, PREHOOK)
+
+	s.AddKnownTypes(ajv1.SchemeBuilder.GroupVersion, &ajv1.AnsibleJob{})
+	s.AddKnownTypes(clustercuratorv1.SchemeBuilder.GroupVersion, &clustercuratorv1.ClusterCurator{})
+	s.AddKnownTypes(hivev1.SchemeBuilder.GroupVersion, &hivev1.ClusterDeployment{}, &hivev1.MachinePool{})
+	s.AddKnownTypes(corev1.SchemeGroupVersion, &corev1.Secret{})
+	client := clientfake.NewClientBuilder().WithScheme(s).WithRuntimeObjects(
+		cc, genClusterDeployment(), genMachinePool()).Build()
+
+	waitForAnsibleJobs := func() []string {
+		for {
+			time.Sleep(utils.PauseFiveSeconds)
+			curator := &clustercuratorv1.ClusterCurator{}
+			_ = client.Get(
+				context.Background(),
+				types.NamespacedName{Namespace: ClusterName, Name: ClusterName},
+				curator)
+			names := []string{}
+			for _, attempt := range curator.Status.Steps[0].HookAttempts {
+				if attempt.Result == clustercuratorv1.CurationPhaseRunning {
+					names = append(names, attempt.AnsibleJob)
+				}
+			}
+			if len(names) == 2 {
+				return names
+			}
+		}
+	}
+
+	return cc, client, waitForAnsibleJobs
+}
+
+func setAnsibleJobStatus(t *testing.T, client client.Client, jobName string, ajs string) {
+	newJob := buildAnsibleJob(ajs, AnsibleJobTemplateName)
+	newJob.SetName(jobName)
+	newJob.SetNamespace(ClusterName)
+
+	assert.Nil(t,
+		client.Delete(context.Background(), newJob),
+		"err is nil, when ansibleJob resource is deleted")
+	assert.Nil(t,
+		client.Create(context.Background(), newJob),
+		"err is nil, when ansibleJob resource is created")
+	t.Logf("AnsibleJob %v marked %v", jobName, ajs)
+}
+
+func TestJobParallel(t *testing.T) {
+
+	cc, client, waitForAnsibleJobs := getParallelTestClient(t, clustercuratorv1.ParallelFailurePolicyWaitForAll)
+
+	go func() {
+		names := waitForAnsibleJobs()
+		t.Log("Both AnsibleJobs of the group are running")
+		setAnsibleJobStatus(t, client, names[0], "successful")
+		setAnsibleJobStatus(t, client, names[1], "successful")
+	}()
+
+	assert.Nil(t, Job(client, cc), "err nil, when every hook of the group is successful")
+
+	curator := &clustercuratorv1.ClusterCurator{}
+	assert.Nil(t, client.Get(context.Background(),
+		types.NamespacedName{Namespace: ClusterName, Name: ClusterName}, curator))
+	for _, attempt := range curator.Status.Steps[0].HookAttempts {
+		assert.Equal(t, clustercuratorv1.CurationPhaseSucceeded, attempt.Result)
+	}
+}
+
+func TestJobParallelWaitForAll(t *testing.T) {
+
+	cc, client, waitForAnsibleJobs := getParallelTestClient(t, clustercuratorv1.ParallelFailurePolicyWaitForAll)
+
+	go func() {
+		names := waitForAnsibleJobs()
+		setAnsibleJobStatus(t, client, names[0], "error")
+		time.Sleep(utils.PauseFiveSeconds)
+		setAnsibleJobStatus(t, client, names[1], "successful")
+	}()
+
+	assert.NotNil(t, Job(client, cc), "err not nil, when a hook of the group failed")
+
+	curator := &clustercuratorv1.ClusterCurator{}
+	assert.Nil(t, client.Get(context.Background(),
+		types.NamespacedName{Namespace: ClusterName, Name: ClusterName}, curator))
+	results := []clustercuratorv1.CurationPhase{}
+	for _, attempt := range curator.Status.Steps[0].HookAttempts {
+		results = append(results, attempt.Result)
+	}
+	assert.ElementsMatch(t,
+		[]clustercuratorv1.CurationPhase{clustercuratorv1.CurationPhaseFailed, clustercuratorv1.CurationPhaseSucceeded},
+		results, "the rest of the group finished")
+}
+
+func TestJobParallelFailFast(t *testing.T) {
+
+	cc, client, waitForAnsibleJobs := getParallelTestClient(t, "")
+
+	running := make(chan []string, 1)
+	go func() {
+		names := waitForAnsibleJobs()
+		running <- names
+		setAnsibleJobStatus(t, client, names[0], "error")
+	}()
+
+	assert.NotNil(t, Job(client, cc), "err not nil, without waiting for the rest of the group")
+
+	t.Log("The AnsibleJob of the rest of the group is deleted and its attempt cancelled")
+	names := <-running
+	err := client.Get(context.Background(),
+		types.NamespacedName{Namespace: ClusterName, Name: names[1]}, &ajv1.AnsibleJob{})
+	assert.True(t, k8serrors.IsNotFound(err), "the AnsibleJob still running is deleted")
+	for _, attempt := range getHookAttempts(t, client) {
+		if attempt.AnsibleJob == names[1] {
+			assert.Equal(t, clustercuratorv1.CurationPhaseCancelled, attempt.Result)
+			assert.Contains(t, attempt.Message, "another hook of the parallel group failed")
+		}
+	}
+}
+
+func TestJobParallelRecordError(t *testing.T) {
+
+	cc, _, _ := getParallelTestClient(t, clustercuratorv1.ParallelFailurePolicyWaitForAll)
+
+	t.Log("Without the ClusterCurator, recording the status of the AnsibleJob fails")
+	client := clientfake.NewClientBuilder().WithScheme(s).WithRuntimeObjects(
+		genClusterDeployment(), genMachinePool()).Build()
+
+	err := Job(client, cc)
+	assert.NotNil(t, err, "err not nil, when the hooks of the group can not record their status")
+	assert.Contains(t, err.Error(), "Hook Register DNS failed")
+	assert.Contains(t, err.Error(), "Hook Allocate IPs failed")
+}
+
+func TestDeleteAnsibleJob(t *testing.T) {
+
+	cc := getClusterCurator()
+	cc.Status.Conditions = []v1.Condition{{
+		Type:    "current-ansiblejob",
+		Status:  v1.ConditionFalse,
+		Message: "prehookjob-1",
+	}}
+	cc.Status.Steps = []clustercuratorv1.CurationStep{{
+		Name: "prehook-ansiblejob",
+		HookAttempts: []clustercuratorv1.HookAttempt{
+			{Hook: "Register DNS", Attempt: 1, AnsibleJob: "prehookjob-1", Result: clustercuratorv1.CurationPhaseRunning},
+			{Hook: "Allocate IPs", Attempt: 1, AnsibleJob: "prehookjob-2", Result: clustercuratorv1.CurationPhaseRunning},
+			{Hook: "Create LB", Attempt: 1, AnsibleJob: "prehookjob-3", Result: clustercuratorv1.CurationPhaseSucceeded},
+		},
+	}}
+
+	s.AddKnownTypes(ajv1.SchemeBuilder.GroupVersion, &ajv1.AnsibleJob{})
+	objs := []runtime.Object{}
+	for _, name := range []string{"prehookjob-1", "prehookjob-2", "prehookjob-3"} {
+		aj := buildAnsibleJob("", "")
+		aj.SetName(name)
+		objs = append(objs, aj)
+	}
+	client := clientfake.NewClientBuilder().WithScheme(s).WithRuntimeObjects(objs...).Build()
+
+	assert.Nil(t, DeleteAnsibleJob(client, cc), "err nil, when the running AnsibleJobs are deleted")
+
+	for name, found := range map[string]bool{"prehookjob-1": false, "prehookjob-2": false, "prehookjob-3": true} {
+		aj := &ajv1.AnsibleJob{}
+		err := client.Get(context.Background(), types.NamespacedName{Namespace: ClusterName, Name: name}, aj)
+		assert.Equal(t, found, err == nil, "only the running AnsibleJobs are deleted: "+name)
+	}
+}
+
+func TestRunAnsibleHookAttemptResume(t *testing.T) {
+
+	cc := getClusterCurator()
+	cc.UID = "curator-uid"
+	cc.Spec.CuratingJob = "curator-job-d8sk2"
+	cc.Status.Steps = []clustercuratorv1.CurationStep{{Name: "prehook-ansiblejob"}}
+
+	t.Log("The AnsibleJob created by the curator pod before it restarted")
+	aj := buildAnsibleJob("successful", AnsibleJobTemplateName)
+	aj.SetLabels(getAnsibleJobLabels(cc, PREHOOK, 0, 1))
+
+	s.AddKnownTypes(ajv1.SchemeBuilder.GroupVersion, &ajv1.AnsibleJob{}, &ajv1.AnsibleJobList{})
+	s.AddKnownTypes(clustercuratorv1.SchemeBuilder.GroupVersion, &clustercuratorv1.ClusterCurator{})
+	s.AddKnownTypes(hivev1.SchemeBuilder.GroupVersion, &hivev1.ClusterDeployment{}, &hivev1.MachinePool{})
+	s.AddKnownTypes(corev1.SchemeGroupVersion, &corev1.Secret{})
+	deleted := map[string]client.Object{}
+	client := clientfake.NewClientBuilder().WithScheme(s).WithRuntimeObjects(
+		cc, aj, genClusterDeployment(), genMachinePool(), genInstallConfigSecret()).WithInterceptorFuncs(
+		interceptor.Funcs{Delete: func(
+			ctx context.Context, c client.WithWatch, obj client.Object, opts ...client.DeleteOption) error {
+
+			deleted[obj.GetName()] = obj.DeepCopyObject().(client.Object)
+			return c.Delete(ctx, obj, opts...)
+		}}).Build()
+
+	hook := cc.Spec.Install.Prehook[0]
+	hookAttempt, err := runAnsibleHookAttempt(client, cc, PREHOOK, "prehook-ansiblejob", hook, 0, "toweraccess", 1, 0, nil)
+	assert.Nil(t, err, "err nil, when the existing AnsibleJob succeeded")
+	assert.Equal(t, AnsibleJobName, hookAttempt.AnsibleJob, "the existing AnsibleJob is monitored")
+
+	ansibleJobs := &ajv1.AnsibleJobList{}
+	assert.Nil(t, client.List(context.Background(), ansibleJobs))
+	assert.Equal(t, 1, len(ansibleJobs.Items), "no AnsibleJob is created")
+
+	t.Log("The next attempt creates its own AnsibleJob")
+	hookAttempt, err = runAnsibleHookAttempt(
+		client, cc, PREHOOK, "prehook-ansiblejob", hook, 0, "toweraccess", 2, time.Millisecond, nil)
+	assert.NotNil(t, err, "err not nil, when the new AnsibleJob does not finish in time")
+	assert.NotEqual(t, AnsibleJobName, hookAttempt.AnsibleJob)
+
+	t.Log("The timed out AnsibleJob is deleted")
+	assert.NotNil(t, client.Get(context.Background(),
+		types.NamespacedName{Namespace: ClusterName, Name: hookAttempt.AnsibleJob}, &ajv1.AnsibleJob{}))
+	newJob, found := deleted[hookAttempt.AnsibleJob]
+	assert.True(t, found, "the AnsibleJob of the timed out attempt is deleted")
+	assert.Equal(t, map[string]string{
+		CurationRunLabel: "curator-job-d8sk2",
+		HookIndexLabel:   "prehook-0",
+		HookAttemptLabel: "2",
+	}, newJob.GetLabels())
+	assert.Equal(t, 1, len(newJob.GetOwnerReferences()), "the AnsibleJob is owned by the curator")
+	assert.Equal(t, cc.UID, newJob.GetOwnerReferences()[0].UID)
+	assert.Nil(t, deleted[AnsibleJobName], "the AnsibleJob that succeeded is kept")
+}
+
+// The curator pod is killed while it monitors the AnsibleJob of a prehook, the pod the curator Job
+// starts again runs the same step and resumes that AnsibleJob
+func TestJobResumesAfterCuratorPodRestart(t *testing.T) {
+
+	t.Setenv(EnvJobType, PREHOOK)
+
+	cc := getClusterCurator()
+	cc.Spec.CuratingJob = "curator-job-d8sk2"
+	cc.Status.Steps = []clustercuratorv1.CurationStep{{Name: "prehook-ansiblejob"}}
+
+	s.AddKnownTypes(ajv1.SchemeBuilder.GroupVersion, &ajv1.AnsibleJob{}, &ajv1.AnsibleJobList{})
+	s.AddKnownTypes(clustercuratorv1.SchemeBuilder.GroupVersion, &clustercuratorv1.ClusterCurator{})
+	s.AddKnownTypes(hivev1.SchemeBuilder.GroupVersion, &hivev1.ClusterDeployment{}, &hivev1.MachinePool{})
+	s.AddKnownTypes(corev1.SchemeGroupVersion, &corev1.Secret{})
+	cluster := clientfake.NewClientBuilder().WithScheme(s).WithRuntimeObjects(
+		cc, genClusterDeployment(), genMachinePool(), genInstallConfigSecret()).Build()
+
+	killed := atomic.Bool{}
+	errKilled := errors.New("the curator pod was killed")
+	firstPod := interceptor.NewClient(cluster, interceptor.Funcs{
+		Get: func(ctx context.Context, c client.WithWatch, key client.ObjectKey, obj client.Object,
+			opts ...client.GetOption) error {
+			if killed.Load() {
+				return errKilled
+			}
+			return c.Get(ctx, key, obj, opts...)
+		},
+		List: func(ctx context.Context, c client.WithWatch, list client.ObjectList, opts ...client.ListOption) error {
+			if killed.Load() {
+				return errKilled
+			}
+			return c.List(ctx, list, opts...)
+		},
+		Create: func(ctx context.Context, c client.WithWatch, obj client.Object, opts ...client.CreateOption) error {
+			if killed.Load() {
+				return errKilled
+			}
+			return c.Create(ctx, obj, opts...)
+		},
+		Update: func(ctx context.Context, c client.WithWatch, obj client.Object, opts ...client.UpdateOption) error {
+			if killed.Load() {
+				return errKilled
+			}
+			return c.Update(ctx, obj, opts...)
+		},
+		Delete: func(ctx context.Context, c client.WithWatch, obj client.Object, opts ...client.DeleteOption) error {
+			if killed.Load() {
+				return errKilled
+			}
+			return c.Delete(ctx, obj, opts...)
+		},
+	})
+
+	firstPodDone := make(chan struct{})
+	go func() {
+		defer close(firstPodDone)
+		defer func() { _ = recover() }()
+		_ = Job(firstPod, cc)
+	}()
+
+	t.Log("The first curator pod creates the AnsibleJob and is killed while it monitors it")
+	ansibleJobs := &ajv1.AnsibleJobList{}
+	assert.Eventually(t, func() bool {
+		return cluster.List(context.Background(), ansibleJobs) == nil && len(ansibleJobs.Items) == 1
+	}, 10*time.Second, 100*time.Millisecond, "the first curator pod creates the AnsibleJob")
+	killed.Store(true)
+	<-firstPodDone
+
+	t.Log("The Tower job finishes while no curator pod is running")
+	aj := &ansibleJobs.Items[0]
+	aj.Status = buildAnsibleJob("successful", "").Status
+	assert.Nil(t, cluster.Update(context.Background(), aj))
+
+	t.Log("The restarted curator pod runs the prehook step again")
+	current, err := utils.GetClusterCurator(cluster, ClusterName, ClusterName)
+	assert.Nil(t, err)
+	assert.Nil(t, Job(cluster, current), "err nil, when the resumed AnsibleJob succeeded")
+
+	assert.Nil(t, cluster.List(context.Background(), ansibleJobs))
+	assert.Equal(t, 1, len(ansibleJobs.Items), "the restarted pod does not create another AnsibleJob")
+
+	current, err = utils.GetClusterCurator(cluster, ClusterName, ClusterName)
+	assert.Nil(t, err)
+	attempts := current.Status.Steps[0].HookAttempts
+	assert.Equal(t, 1, len(attempts), "the resumed AnsibleJob is the same attempt")
+	assert.Equal(t, aj.Name, attempts[0].AnsibleJob)
+	assert.Equal(t, clustercuratorv1.CurationPhaseSucceeded, attempts[0].Result)
+}
+
+func TestRunAnsibleHookAttemptCancelled(t *testing.T) {
+
+	cc := getClusterCurator()
+	cc.Spec.CuratingJob = "curator-job-d8sk2"
+	cc.Status.Steps = []clustercuratorv1.CurationStep{{Name: "prehook-ansiblejob"}}
+
+	s.AddKnownTypes(ajv1.SchemeBuilder.GroupVersion, &ajv1.AnsibleJob{}, &ajv1.AnsibleJobList{})
+	s.AddKnownTypes(clustercuratorv1.SchemeBuilder.GroupVersion, &clustercuratorv1.ClusterCurator{})
+	s.AddKnownTypes(hivev1.SchemeBuilder.GroupVersion, &hivev1.ClusterDeployment{}, &hivev1.MachinePool{})
+	s.AddKnownTypes(corev1.SchemeGroupVersion, &corev1.Secret{})
+	client := clientfake.NewClientBuilder().WithScheme(s).WithRuntimeObjects(
+		cc, genClusterDeployment(), genMachinePool(), genInstallConfigSecret()).Build()
+
+	t.Log("Another hook of the parallel group failed while the AnsibleJob was created")
+	cancel := make(chan struct{})
+	close(cancel)
+
+	start := time.Now()
+	hookAttempt, err := runAnsibleHookAttempt(client, cc, PREHOOK, "prehook-ansiblejob",
+		cc.Spec.Install.Prehook[0], 0, "toweraccess", 1, 0, cancel)
+	assert.True(t, errors.Is(err, errCancelled), "err is cancelled, when cancel is closed")
+	assert.Less(t, time.Since(start), utils.PauseFiveSeconds, "the monitor stops without waiting")
+	assert.NotEqual(t, "", hookAttempt.AnsibleJob)
+
+	ansibleJobs := &ajv1.AnsibleJobList{}
+	assert.Nil(t, client.List(context.Background(), ansibleJobs))
+	assert.Equal(t, 0, len(ansibleJobs.Items), "the AnsibleJob of the cancelled attempt is deleted")
+}
+
+func TestRunAnsibleHookAttemptListForbidden(t *testing.T) {
+
+	cc := getClusterCurator()
+	cc.Spec.CuratingJob = "curator-job-d8sk2"
+	cc.Status.Steps = []clustercuratorv1.CurationStep{{Name: "prehook-ansiblejob"}}
+
+	s.AddKnownTypes(ajv1.SchemeBuilder.GroupVersion, &ajv1.AnsibleJob{}, &ajv1.AnsibleJobList{})
+	s.AddKnownTypes(clustercuratorv1.SchemeBuilder.GroupVersion, &clustercuratorv1.ClusterCurator{})
+	s.AddKnownTypes(hivev1.SchemeBuilder.GroupVersion, &hivev1.ClusterDeployment{}, &hivev1.MachinePool{})
+	s.AddKnownTypes(corev1.SchemeGroupVersion, &corev1.Secret{})
+	client := clientfake.NewClientBuilder().WithScheme(s).WithRuntimeObjects(
+		cc, genClusterDeployment(), genMachinePool(), genInstallConfigSecret()).WithInterceptorFuncs(
+		interceptor.Funcs{List: func(
+			ctx context.Context, c client.WithWatch, list client.ObjectList, opts ...client.ListOption) error {
+
+			if _, ok := list.(*unstructured.UnstructuredList); ok {
+				return k8serrors.NewForbidden(
+					ajv1.SchemeBuilder.GroupVersion.WithResource("ansiblejobs").GroupResource(), "", nil)
+			}
+			return c.List(ctx, list, opts...)
+		}}).Build()
+
+	hookAttempt, err := runAnsibleHookAttempt(client, cc, PREHOOK, "prehook-ansiblejob",
+		cc.Spec.Install.Prehook[0], 0, "toweraccess", 1, time.Millisecond, nil)
+	assert.NotNil(t, err, "err not nil, when the new AnsibleJob does not finish in time")
+	assert.NotEqual(t, "", hookAttempt.AnsibleJob, "an AnsibleJob is created when the list is forbidden")
+}
+
+func TestGetAnsibleJobLabels(t *testing.T) {
+
+	cc := getClusterCurator()
+	assert.Nil(t, getAnsibleJobLabels(cc, POSTHOOK, 1, 1), "no labels, without a curator Job")
+
+	cc.Spec.CuratingJob = "curator-job-d8sk2"
+	assert.Equal(t, "posthook-1", getAnsibleJobLabels(cc, POSTHOOK, 1, 1)[HookIndexLabel])
+}
+
+func TestMonitorAnsibleJobTimeout(t *testing.T) {
+
+	cc := getClusterCurator()
+	aj := buildAnsibleJob("", "")
+	aj.Status = ajv1.AnsibleJobStatus{}
+
+	s.AddKnownTypes(ajv1.SchemeBuilder.GroupVersion, &ajv1.AnsibleJob{})
+	s.AddKnownTypes(clustercuratorv1.SchemeBuilder.GroupVersion, &clustercuratorv1.ClusterCurator{})
+	client := clientfake.NewClientBuilder().WithScheme(s).WithRuntimeObjects(
+		aj, cc).Build()
+
+	mapAJ, _ := runtime.DefaultUnstructuredConverter.ToUnstructured(&aj)
+	unstructAJ := &unstructured.Unstructured{Object: mapAJ}
+
+	err := monitorAnsibleJob(client, unstructAJ, cc, time.Millisecond, nil)
+	assert.NotNil(t, err, "err not nil, when the AnsibleJob does not finish in time")
+	assert.Contains(t, err.Error(), "timed out")
+}
+
 func TestMonitorAnsibleJobAnsibleJobStatusSuccessfulPreHook(t *testing.T) {
 
 	cc := getClusterCurator()
@@ -769,21 +1447,42 @@ func TestUpgradeAnsibleJobExtraVars(t *testing.T) {
 
 func TestInventory(t *testing.T) {
 	tests := []struct {
-		name                 string
-		inventory            string
-		expectedInventory    bool
-		expectedInventoryVar string
+		name                    string
+		inventory               string
+		hookInventory           string
+		hookTowerAuthSecret     string
+		expectedInventory       bool
+		expectedInventoryVar    string
+		expectedTowerAuthSecret string
 	}{
 		{
-			name:                 "configure inventory",
-			inventory:            ClusterName,
-			expectedInventory:    true,
-			expectedInventoryVar: ClusterName,
+			name:                    "configure inventory",
+			inventory:               ClusterName,
+			expectedInventory:       true,
+			expectedInventoryVar:    ClusterName,
+			expectedTowerAuthSecret: "toweraccess",
+		},
+		{
+			name:                    "configure no inventory",
+			inventory:               "",
+			expectedInventory:       false,
+			expectedTowerAuthSecret: "toweraccess",
 		},
 		{
-			name:              "configure no inventory",
-			inventory:         "",
-			expectedInventory: false,
+			name:                    "hook overrides inventory and towerAuthSecret",
+			inventory:               ClusterName,
+			hookInventory:           "network-inventory",
+			hookTowerAuthSecret:     "network-tower",
+			expectedInventory:       true,
+			expectedInventoryVar:    "network-inventory",
+			expectedTowerAuthSecret: "network-tower",
+		},
+		{
+			name:                    "hook inventory without curator inventory",
+			hookInventory:           "network-inventory",
+			expectedInventory:       true,
+			expectedInventoryVar:    "network-inventory",
+			expectedTowerAuthSecret: "toweraccess",
 		},
 	}
 
@@ -804,11 +1503,15 @@ func TestInventory(t *testing.T) {
 			client := clientfake.NewClientBuilder().WithScheme(s).WithRuntimeObjects(
 				cc, genClusterDeployment(), genMachinePool(), genInstallConfigSecret()).Build()
 
-			aJob, err := RunAnsibleJob(client, cc, POSTHOOK, cc.Spec.Install.Posthook[0], "toweraccess")
+			hook := cc.Spec.Install.Posthook[0]
+			hook.Inventory = test.hookInventory
+			hook.TowerAuthSecret = test.hookTowerAuthSecret
+			aJob, err := RunAnsibleJob(client, cc, POSTHOOK, hook, "toweraccess")
 			assert.Nil(t, err, "err is nil when job is started")
 
 			spec := aJob.Object["spec"].(map[string]interface{})
 			assert.NotNil(t, spec, "not nil, spec")
+			assert.Equal(t, test.expectedTowerAuthSecret, spec["tower_auth_secret"])
 
 			_, existed := spec["inventory"]
 			assert.Equal(t, existed, test.expectedInventory)
@@ -819,6 +1522,53 @@ func TestInventory(t *testing.T) {
 	}
 }
 
+func TestAnsibleJobOptions(t *testing.T) {
+	os.Setenv(EnvJobType, POSTHOOK)
+	os.Setenv(utils.HookImageAllowlistEnv, "quay.io/my-org/")
+	defer os.Unsetenv(utils.HookImageAllowlistEnv)
+
+	s.AddKnownTypes(ajv1.SchemeBuilder.GroupVersion, &ajv1.AnsibleJob{})
+	s.AddKnownTypes(clustercuratorv1.SchemeBuilder.GroupVersion, &clustercuratorv1.ClusterCurator{})
+	s.AddKnownTypes(hivev1.SchemeBuilder.GroupVersion, &hivev1.ClusterDeployment{}, &hivev1.MachinePool{})
+	s.AddKnownTypes(corev1.SchemeGroupVersion, &corev1.Secret{})
+
+	cc := getClusterCurator()
+	client := clientfake.NewClientBuilder().WithScheme(s).WithRuntimeObjects(
+		cc, genClusterDeployment(), genMachinePool(), genInstallConfigSecret()).Build()
+
+	verbosity := 0
+	hook := cc.Spec.Install.Posthook[0]
+	hook.Limit = "masters"
+	hook.Verbosity = &verbosity
+	hook.JobTTL = 600
+	hook.RunnerImage = "quay.io/my-org/ansible-runner"
+	hook.RunnerVersion = "2.4"
+
+	aJob, err := RunAnsibleJob(client, cc, POSTHOOK, hook, "toweraccess")
+	assert.Nil(t, err, "err is nil when job is started")
+	spec := aJob.Object["spec"].(map[string]interface{})
+	assert.Equal(t, "masters", spec["limit"])
+	assert.Equal(t, int64(0), spec["verbosity"], "a verbosity of 0 is passed")
+	assert.Equal(t, int64(600), spec["job_ttl"])
+	assert.Equal(t, "quay.io/my-org/ansible-runner", spec["runner_image"])
+	assert.Equal(t, "2.4", spec["runner_version"])
+
+	t.Log("A Workflow does not use the limit and verbosity")
+	hook.Type = clustercuratorv1.HookTypeWorkflow
+	aJob, err = RunAnsibleJob(client, cc, POSTHOOK, hook, "toweraccess")
+	assert.Nil(t, err, "err is nil when job is started")
+	spec = aJob.Object["spec"].(map[string]interface{})
+	assert.Nil(t, spec["limit"], "no limit for a Workflow")
+	assert.Nil(t, spec["verbosity"], "no verbosity for a Workflow")
+	assert.Equal(t, int64(600), spec["job_ttl"])
+
+	t.Log("The runner_image must be in the allowlist")
+	hook.RunnerImage = "docker.io/someone/ansible-runner"
+	_, err = RunAnsibleJob(client, cc, POSTHOOK, hook, "toweraccess")
+	assert.NotNil(t, err, "err is not nil, when the runner_image is not allowed")
+	assert.Contains(t, err.Error(), utils.HookImageAllowlistEnv)
+}
+
 func Test_getAnsibleJobHooktype(t *testing.T) {
 	tests := []struct {
 		name                       string
@@ -847,7 +1597,7 @@ func Test_getAnsibleJobHooktype(t *testing.T) {
 	}
 	for _, test := range tests {
 		t.Run(test.name, func(t *testing.T) {
-			aJob := getAnsibleJob(PREHOOK, test.hooktype, AnsibleJobTemplateName, SecretRef, nil, AnsibleJobName, ClusterName, "", "")
+			aJob, _ := getAnsibleJob(PREHOOK, test.hooktype, AnsibleJobTemplateName, SecretRef, nil, AnsibleJobName, ClusterName, "", "")
 			_, ok := aJob.Object["spec"].(map[string]interface{})[test.expectedTemplateNameKey]
 			assert.True(t, ok, "template name key is not %s", test.expectedTemplateNameKey)
 			_, ok = aJob.Object["spec"].(map[string]interface{})[test.expectedNotTemplateNameKey]
@@ -892,9 +1642,9 @@ func Test_getAnsibleJob_Tags(t *testing.T) {
 		t.Run(test.name, func(t *testing.T) {
 			var aJob *unstructured.Unstructured
 			if test.isWorkflow {
-				aJob = getAnsibleJob(PREHOOK, string(clustercuratorv1.HookTypeWorkflow), AnsibleJobTemplateName, SecretRef, nil, AnsibleJobName, ClusterName, test.jobTags, test.skipTags)
+				aJob, _ = getAnsibleJob(PREHOOK, string(clustercuratorv1.HookTypeWorkflow), AnsibleJobTemplateName, SecretRef, nil, AnsibleJobName, ClusterName, test.jobTags, test.skipTags)
 			} else {
-				aJob = getAnsibleJob(PREHOOK, string(clustercuratorv1.HookTypeJob), AnsibleJobTemplateName, SecretRef, nil, AnsibleJobName, ClusterName, test.jobTags, test.skipTags)
+				aJob, _ = getAnsibleJob(PREHOOK, string(clustercuratorv1.HookTypeJob), AnsibleJobTemplateName, SecretRef, nil, AnsibleJobName, ClusterName, test.jobTags, test.skipTags)
 			}
 			if test.expectEmptyFields {
 				assert.Nil(t, aJob.Object["spec"].(map[string]interface{})["job_tags"])
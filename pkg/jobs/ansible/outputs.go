@@ -0,0 +1,94 @@
+// Copyright Contributors to the Open Cluster Management project.
+package ansible
+
+import (
+	"maps"
+	"slices"
+
+	clustercuratorv1 "github.com/stolostron/cluster-curator-controller/pkg/api/v1beta1"
+	"github.com/stolostron/cluster-curator-controller/pkg/jobs/utils"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/klog/v2"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// HookOutputsKey is the extra_vars key holding the outputs recorded by the earlier hooks of the
+// curation
+const HookOutputsKey = "hook_outputs"
+
+// getAnsibleJobOutputs returns the declared outputs of a hook from the artifacts of its finished
+// AnsibleJob. The AWX resource operator surfaces the artifacts, including the set_stats data of the
+// playbook, in status.artifacts, older versions in status.ansibleJobResult.artifacts.
+func getAnsibleJobOutputs(
+	jobResource *unstructured.Unstructured,
+	hook clustercuratorv1.Hook) map[string]interface{} {
+
+	artifacts, found, err := unstructured.NestedMap(jobResource.Object, "status", "artifacts")
+	if err != nil || !found {
+		artifacts, _, _ = unstructured.NestedMap(jobResource.Object, "status", "ansibleJobResult", "artifacts")
+	}
+
+	outputs := map[string]interface{}{}
+	for _, key := range hook.Outputs {
+		value, ok := artifacts[key]
+		if !ok {
+			klog.Warningf("The AnsibleJob %v of hook %v has no artifact %v", jobResource.GetName(), hook.Name, key)
+			continue
+		}
+		outputs[key] = value
+	}
+	return outputs
+}
+
+// recordHookOutputs records the declared outputs of a hook in the status of the step
+func recordHookOutputs(
+	client client.Client,
+	curator *clustercuratorv1.ClusterCurator,
+	stepName string,
+	hook clustercuratorv1.Hook,
+	jobResource *unstructured.Unstructured) error {
+
+	if len(hook.Outputs) == 0 {
+		return nil
+	}
+
+	outputs := getAnsibleJobOutputs(jobResource, hook)
+	if len(outputs) == 0 {
+		return nil
+	}
+	klog.V(2).Infof("Recording the outputs %v of hook %v", slices.Sorted(maps.Keys(outputs)), hook.Name)
+	return utils.RecordStepOutputs(client, curator.Name, curator.Namespace, stepName, outputs)
+}
+
+// getHookOutputs returns the outputs recorded so far in the curation. The ClusterCurator is read
+// again, as the hooks that ran before in the same step recorded theirs after it was read.
+func getHookOutputs(
+	client client.Client,
+	curator *clustercuratorv1.ClusterCurator) (map[string]interface{}, error) {
+
+	latest, err := utils.GetClusterCurator(client, curator.Name, curator.Namespace)
+	if k8serrors.IsNotFound(err) {
+		latest = curator
+	} else if err != nil {
+		return nil, err
+	}
+	return utils.GetHookOutputs(latest)
+}
+
+// addHookOutputs adds the outputs of the earlier hooks of the curation to the extra_vars as
+// hook_outputs, nothing is added before a hook recorded outputs
+func addHookOutputs(
+	client client.Client,
+	curator *clustercuratorv1.ClusterCurator,
+	extraVars map[string]interface{}) error {
+
+	outputs, err := getHookOutputs(client, curator)
+	if err != nil {
+		return err
+	}
+	if len(outputs) > 0 {
+		extraVars[HookOutputsKey] = outputs
+	}
+	return nil
+}
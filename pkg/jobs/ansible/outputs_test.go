@@ -0,0 +1,99 @@
+// Copyright Contributors to the Open Cluster Management project.
+package ansible
+
+import (
+	"encoding/json"
+	"testing"
+
+	hivev1 "github.com/openshift/hive/apis/hive/v1"
+	ajv1 "github.com/stolostron/cluster-curator-controller/pkg/api/v1alpha1"
+	clustercuratorv1 "github.com/stolostron/cluster-curator-controller/pkg/api/v1beta1"
+	"github.com/stolostron/cluster-curator-controller/pkg/jobs/utils"
+	managedclusterinfov1beta1 "github.com/stolostron/cluster-lifecycle-api/clusterinfo/v1beta1"
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	clientfake "sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func getFinishedAnsibleJob(status map[string]interface{}) *unstructured.Unstructured {
+	return &unstructured.Unstructured{Object: map[string]interface{}{
+		"kind":     "AnsibleJob",
+		"metadata": map[string]interface{}{"name": AnsibleJobName, "namespace": ClusterName},
+		"status":   status,
+	}}
+}
+
+func TestGetAnsibleJobOutputs(t *testing.T) {
+
+	hook := clustercuratorv1.Hook{Name: "Allocate IPs", Outputs: []string{"api_vip", "change_id", "missing"}}
+
+	jobResource := getFinishedAnsibleJob(map[string]interface{}{
+		"ansibleJobResult": map[string]interface{}{"status": "successful"},
+		"artifacts": map[string]interface{}{
+			"api_vip":   "10.0.0.5",
+			"change_id": int64(12345),
+			"internal":  "not declared",
+		},
+	})
+	assert.Equal(t, map[string]interface{}{"api_vip": "10.0.0.5", "change_id": int64(12345)},
+		getAnsibleJobOutputs(jobResource, hook), "only the declared artifacts are outputs")
+
+	jobResource = getFinishedAnsibleJob(map[string]interface{}{
+		"ansibleJobResult": map[string]interface{}{
+			"status":    "successful",
+			"artifacts": map[string]interface{}{"api_vip": "10.0.0.6"},
+		},
+	})
+	assert.Equal(t, map[string]interface{}{"api_vip": "10.0.0.6"}, getAnsibleJobOutputs(jobResource, hook),
+		"the artifacts of the ansibleJobResult are read")
+
+	jobResource = getFinishedAnsibleJob(map[string]interface{}{})
+	assert.Equal(t, map[string]interface{}{}, getAnsibleJobOutputs(jobResource, hook),
+		"no outputs, when the AnsibleJob has no artifacts")
+}
+
+func TestHookOutputs(t *testing.T) {
+
+	cc := getClusterCurator()
+	cc.Status.Steps = []clustercuratorv1.CurationStep{{Name: "prehook-ansiblejob"}, {Name: "posthook-ansiblejob"}}
+	prehook := cc.Spec.Install.Prehook[0]
+	prehook.Outputs = []string{"change_id"}
+
+	s.AddKnownTypes(ajv1.SchemeBuilder.GroupVersion, &ajv1.AnsibleJob{})
+	s.AddKnownTypes(clustercuratorv1.SchemeBuilder.GroupVersion, &clustercuratorv1.ClusterCurator{})
+	s.AddKnownTypes(hivev1.SchemeBuilder.GroupVersion, &hivev1.ClusterDeployment{}, &hivev1.MachinePool{})
+	s.AddKnownTypes(corev1.SchemeGroupVersion, &corev1.Secret{})
+	s.AddKnownTypes(managedclusterinfov1beta1.SchemeGroupVersion, &managedclusterinfov1beta1.ManagedClusterInfo{})
+	client := clientfake.NewClientBuilder().WithScheme(s).WithRuntimeObjects(
+		cc, genClusterDeployment(), genMachinePool(), genInstallConfigSecret()).Build()
+
+	aJob, err := RunAnsibleJob(client, cc, POSTHOOK, cc.Spec.Install.Posthook[0], "toweraccess")
+	assert.Nil(t, err, "err is nil when job is started")
+	extraVars := aJob.Object["spec"].(map[string]interface{})["extra_vars"].(map[string]interface{})
+	assert.Nil(t, extraVars[HookOutputsKey], "no hook_outputs, before a hook recorded outputs")
+
+	jobResource := getFinishedAnsibleJob(map[string]interface{}{
+		"artifacts": map[string]interface{}{"change_id": int64(12345678901234567)},
+	})
+	assert.Nil(t, recordHookOutputs(client, cc, "prehook-ansiblejob", prehook, jobResource))
+
+	curator, err := utils.GetClusterCurator(client, ClusterName, ClusterName)
+	assert.Nil(t, err)
+	assert.JSONEq(t, `{"change_id": 12345678901234567}`, string(curator.Status.Steps[0].Outputs.Raw),
+		"the outputs are recorded on the step")
+
+	t.Log("A later hook receives the outputs, the curator passed in predates them")
+	aJob, err = RunAnsibleJob(client, cc, POSTHOOK, cc.Spec.Install.Posthook[0], "toweraccess")
+	assert.Nil(t, err, "err is nil when job is started")
+	extraVars = aJob.Object["spec"].(map[string]interface{})["extra_vars"].(map[string]interface{})
+	raw, err := json.Marshal(extraVars[HookOutputsKey])
+	assert.Nil(t, err)
+	assert.JSONEq(t, `{"change_id": 12345678901234567}`, string(raw), "large numbers are kept as they are")
+
+	rendered, err := RenderExtraVars(client, cc, "Close change",
+		&runtime.RawExtension{Raw: []byte(`{"ticket": "CHG[[ .HookOutputs.change_id ]]"}`)})
+	assert.Nil(t, err)
+	assert.JSONEq(t, `{"ticket": "CHG12345678901234567"}`, string(rendered.Raw), "templates can use the outputs")
+}
@@ -0,0 +1,225 @@
+// Copyright Contributors to the Open Cluster Management project.
+package ansible
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"strings"
+	"text/template"
+	"text/template/parse"
+
+	clustercuratorv1 "github.com/stolostron/cluster-curator-controller/pkg/api/v1beta1"
+	"github.com/stolostron/cluster-curator-controller/pkg/jobs/utils"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/klog/v2"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// The delimiters of the extra_vars templates. They differ from the {{ }} of Go, so the Jinja
+// expressions of a playbook, such as {{ inventory_hostname }}, are passed through as they are.
+const TemplateLeftDelim = "[["
+const TemplateRightDelim = "]]"
+
+// RenderExtraVars renders the Go templates in the string values of the extra_vars of a hook, such
+// as [[ .ClusterName ]], [[ .Upgrade.DesiredUpdate ]] or
+// [[ .ClusterDeployment.spec.platform.aws.region ]]. The extra_vars are returned as they are when
+// no value is a template.
+func RenderExtraVars(
+	client client.Client,
+	curator *clustercuratorv1.ClusterCurator,
+	hookName string,
+	extraVars *runtime.RawExtension) (*runtime.RawExtension, error) {
+
+	if extraVars == nil || !bytes.Contains(extraVars.Raw, []byte(TemplateLeftDelim)) {
+		return extraVars, nil
+	}
+
+	// UseNumber keeps large integers as they are when the extra_vars are marshalled again
+	decoder := json.NewDecoder(bytes.NewReader(extraVars.Raw))
+	decoder.UseNumber()
+	mapExtraVars := map[string]interface{}{}
+	if err := decoder.Decode(&mapExtraVars); err != nil {
+		return nil, err
+	}
+
+	data, err := getTemplateData(client, curator)
+	if err != nil {
+		return nil, err
+	}
+
+	rendered, err := renderTemplates(mapExtraVars, data, curator.Spec.StrictTemplates)
+	if err != nil {
+		return nil, errors.New("The extra_vars of hook " + hookName + " could not be rendered: " + err.Error())
+	}
+
+	raw, err := json.Marshal(rendered)
+	if err != nil {
+		return nil, err
+	}
+	klog.V(4).Infof("Rendered extra_vars of hook %v: %v", hookName, string(raw))
+	return &runtime.RawExtension{Raw: raw}, nil
+}
+
+// getTemplateData returns the cluster facts and the hook outputs the extra_vars templates can refer
+// to. The ClusterDeployment and ClusterInfo keys are missing when the cluster has none.
+func getTemplateData(
+	client client.Client,
+	curator *clustercuratorv1.ClusterCurator) (map[string]interface{}, error) {
+
+	data := map[string]interface{}{
+		"ClusterName":      curator.Name,
+		"ClusterNamespace": curator.Namespace,
+		"Curation":         utils.GetEffectiveCuration(curator),
+		"Install":          curator.Spec.Install,
+		"Upgrade":          curator.Spec.Upgrade,
+		"Scale":            curator.Spec.Scale,
+		"Destroy":          curator.Spec.Destroy,
+	}
+
+	cd, err := getClusterDeployment(client, curator.Namespace)
+	if err != nil && !k8serrors.IsNotFound(err) {
+		return nil, err
+	} else if err == nil {
+		data["ClusterDeployment"] = cd
+	}
+
+	clusterInfo, err := getManagedClusterInfo(client, curator.Namespace)
+	if err != nil && !k8serrors.IsNotFound(err) {
+		return nil, err
+	} else if err == nil {
+		data["ClusterInfo"] = clusterInfo
+	}
+
+	outputs, err := getHookOutputs(client, curator)
+	if err != nil {
+		return nil, err
+	}
+	data["HookOutputs"] = outputs
+
+	return data, nil
+}
+
+// renderTemplates renders every string of a decoded JSON value, keys are left as they are
+func renderTemplates(value interface{}, data map[string]interface{}, strict bool) (interface{}, error) {
+	switch v := value.(type) {
+	case string:
+		return renderTemplate(v, data, strict)
+	case map[string]interface{}:
+		for key, item := range v {
+			rendered, err := renderTemplates(item, data, strict)
+			if err != nil {
+				return nil, err
+			}
+			v[key] = rendered
+		}
+	case []interface{}:
+		for i, item := range v {
+			rendered, err := renderTemplates(item, data, strict)
+			if err != nil {
+				return nil, err
+			}
+			v[i] = rendered
+		}
+	}
+	return value, nil
+}
+
+func renderTemplate(text string, data map[string]interface{}, strict bool) (string, error) {
+	if !strings.Contains(text, TemplateLeftDelim) {
+		return text, nil
+	}
+
+	tmpl := newTemplate()
+	if strict {
+		tmpl = tmpl.Option("missingkey=error")
+	} else {
+		tmpl = tmpl.Option("missingkey=zero")
+	}
+	tmpl, err := tmpl.Parse(text)
+	if err != nil {
+		return "", err
+	}
+	if !strict {
+		emptyMissingKeys(tmpl.Root)
+	}
+
+	var out strings.Builder
+	if err := tmpl.Execute(&out, data); err != nil {
+		return "", err
+	}
+	return out.String(), nil
+}
+
+func newTemplate() *template.Template {
+	return template.New("extra_vars").Delims(TemplateLeftDelim, TemplateRightDelim).Funcs(template.FuncMap{
+		"emptyMissingKey": emptyMissingKey,
+	})
+}
+
+// emptyMissingKeys pipes the value of every action that prints into emptyMissingKey. With
+// missingkey=zero, a key missing in a map[string]interface{}, such as the ClusterDeployment, is a nil
+// value that would print as "<no value>".
+func emptyMissingKeys(node parse.Node) {
+	switch n := node.(type) {
+	case *parse.ListNode:
+		if n == nil {
+			return
+		}
+		for _, child := range n.Nodes {
+			emptyMissingKeys(child)
+		}
+	case *parse.ActionNode:
+		if len(n.Pipe.Decl) == 0 {
+			n.Pipe.Cmds = append(n.Pipe.Cmds, &parse.CommandNode{
+				NodeType: parse.NodeCommand,
+				Pos:      n.Pos,
+				Args:     []parse.Node{parse.NewIdentifier("emptyMissingKey").SetPos(n.Pos)},
+			})
+		}
+	case *parse.IfNode:
+		emptyMissingKeys(n.List)
+		emptyMissingKeys(n.ElseList)
+	case *parse.RangeNode:
+		emptyMissingKeys(n.List)
+		emptyMissingKeys(n.ElseList)
+	case *parse.WithNode:
+		emptyMissingKeys(n.List)
+		emptyMissingKeys(n.ElseList)
+	}
+}
+
+// emptyMissingKey returns an empty string for the nil value of a missing key
+func emptyMissingKey(value interface{}) interface{} {
+	if value == nil {
+		return ""
+	}
+	return value
+}
+
+// CheckExtraVarsTemplates returns an error when a string value of the extra_vars is not a valid
+// template. Without the cluster facts, only the template syntax is checked. A Jinja expression in
+// {{ }} is not a template of the curator and is not checked.
+func CheckExtraVarsTemplates(value interface{}) error {
+	switch v := value.(type) {
+	case string:
+		if strings.Contains(v, TemplateLeftDelim) {
+			_, err := newTemplate().Parse(v)
+			return err
+		}
+	case map[string]interface{}:
+		for _, item := range v {
+			if err := CheckExtraVarsTemplates(item); err != nil {
+				return err
+			}
+		}
+	case []interface{}:
+		for _, item := range v {
+			if err := CheckExtraVarsTemplates(item); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,133 @@
+// Copyright Contributors to the Open Cluster Management project.
+package ansible
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	hivev1 "github.com/openshift/hive/apis/hive/v1"
+	"github.com/openshift/hive/apis/hive/v1/aws"
+	clustercuratorv1 "github.com/stolostron/cluster-curator-controller/pkg/api/v1beta1"
+	managedclusterinfov1beta1 "github.com/stolostron/cluster-lifecycle-api/clusterinfo/v1beta1"
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	clientfake "sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func getTemplateTestClient(cc *clustercuratorv1.ClusterCurator, objs ...runtime.Object) client.Client {
+	s.AddKnownTypes(clustercuratorv1.SchemeBuilder.GroupVersion, &clustercuratorv1.ClusterCurator{})
+	s.AddKnownTypes(hivev1.SchemeBuilder.GroupVersion, &hivev1.ClusterDeployment{}, &hivev1.MachinePool{})
+	s.AddKnownTypes(corev1.SchemeGroupVersion, &corev1.Secret{})
+	s.AddKnownTypes(managedclusterinfov1beta1.SchemeGroupVersion, &managedclusterinfov1beta1.ManagedClusterInfo{})
+	return clientfake.NewClientBuilder().WithScheme(s).WithRuntimeObjects(append(objs, cc)...).Build()
+}
+
+func TestRenderExtraVars(t *testing.T) {
+
+	cc := getClusterCurator()
+	cc.Spec.DesiredCuration = "upgrade"
+	cc.Spec.Upgrade.DesiredUpdate = "4.15.3"
+	cd := genClusterDeployment()
+	cd.Spec.Platform.AWS = &aws.Platform{Region: "us-east-2"}
+	client := getTemplateTestClient(cc, cd, genManagedClusterInfo())
+
+	extraVars, err := RenderExtraVars(client, cc, "Service now App Update", &runtime.RawExtension{Raw: []byte(`{
+		"cluster": "[[ .ClusterName ]]",
+		"version": "[[ .Upgrade.DesiredUpdate ]]",
+		"region": "[[ .ClusterDeployment.spec.platform.aws.region ]]",
+		"targets": [{"name": "[[ .ClusterName ]]-[[ .ClusterInfo.cloudVendor ]]", "weight": 1}],
+		"ticket": 12345678901234567890,
+		"missing": "x[[ .Missing ]]",
+		"missingField": "[[ if true ]]x[[ .ClusterDeployment.spec.missing ]][[ end ]]",
+		"jinja": "{{ inventory_hostname }}-[[ .ClusterName ]]",
+		"[[ .ClusterName ]]": "key"
+	}`)})
+	assert.Nil(t, err, "err nil, when the extra_vars are rendered")
+
+	rendered := map[string]interface{}{}
+	assert.Nil(t, json.Unmarshal(extraVars.Raw, &rendered))
+	assert.Equal(t, ClusterName, rendered["cluster"])
+	assert.Equal(t, "4.15.3", rendered["version"])
+	assert.Equal(t, "us-east-2", rendered["region"])
+	assert.Equal(t, ClusterName+"-AWS", rendered["targets"].([]interface{})[0].(map[string]interface{})["name"])
+	assert.Contains(t, string(extraVars.Raw), "12345678901234567890", "numbers are kept as they are")
+	assert.Equal(t, "x", rendered["missing"], "a missing key renders as an empty string")
+	assert.Equal(t, "x", rendered["missingField"], "a missing field of the ClusterDeployment renders as an empty string")
+	assert.Equal(t, "{{ inventory_hostname }}-"+ClusterName, rendered["jinja"], "Jinja expressions are not rendered")
+	assert.Equal(t, "key", rendered["[[ .ClusterName ]]"], "keys are not rendered")
+}
+
+func TestRenderExtraVarsStrict(t *testing.T) {
+
+	cc := getClusterCurator()
+	cc.Spec.StrictTemplates = true
+	client := getTemplateTestClient(cc)
+
+	_, err := RenderExtraVars(client, cc, "Service now App Update",
+		&runtime.RawExtension{Raw: []byte(`{"region": "[[ .ClusterDeployment.spec.platform.aws.region ]]"}`)})
+	assert.NotNil(t, err, "err not nil, when a key is missing in strict mode")
+	assert.Contains(t, err.Error(), "Service now App Update")
+
+	extraVars, err := RenderExtraVars(client, cc, "Service now App Update",
+		&runtime.RawExtension{Raw: []byte(`{"cluster": "[[ .ClusterName ]]"}`)})
+	assert.Nil(t, err, "err nil, when every key exists in strict mode")
+	assert.JSONEq(t, `{"cluster": "`+ClusterName+`"}`, string(extraVars.Raw))
+}
+
+func TestRenderExtraVarsNoTemplate(t *testing.T) {
+
+	cc := getClusterCurator()
+	extraVars := cc.Spec.Install.Prehook[0].ExtraVars
+
+	rendered, err := RenderExtraVars(nil, cc, "Service now App Update", extraVars)
+	assert.Nil(t, err)
+	assert.Same(t, extraVars, rendered, "extra_vars without a template are passed through")
+
+	jinja := &runtime.RawExtension{Raw: []byte(`{"host": "{{ inventory_hostname }}"}`)}
+	rendered, err = RenderExtraVars(nil, cc, "Service now App Update", jinja)
+	assert.Nil(t, err)
+	assert.Same(t, jinja, rendered, "extra_vars with only Jinja expressions are passed through")
+
+	rendered, err = RenderExtraVars(nil, cc, "Service now App Update", nil)
+	assert.Nil(t, err)
+	assert.Nil(t, rendered)
+}
+
+func TestCheckExtraVarsTemplates(t *testing.T) {
+	assert.Nil(t, CheckExtraVarsTemplates(map[string]interface{}{
+		"cluster": "[[ .ClusterName ]]",
+		"list":    []interface{}{"[[ .Upgrade.DesiredUpdate ]]", 1},
+		"host":    "{{ inventory_hostname }}",
+		"facts":   "{{ hostvars[inventory_hostname] | to_json }}",
+	}), "Jinja expressions are not checked")
+	assert.NotNil(t, CheckExtraVarsTemplates(map[string]interface{}{
+		"list": []interface{}{"[[ .ClusterName "},
+	}), "err not nil, when a template is not closed")
+}
+
+func TestRunFailureHooksTemplate(t *testing.T) {
+
+	payload := map[string]interface{}{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		assert.Nil(t, json.Unmarshal(body, &payload))
+	}))
+	defer server.Close()
+
+	hook := getWebhookHook()
+	hook.ExtraVars = &runtime.RawExtension{Raw: []byte(`{"summary": "Curation of [[ .ClusterName ]] failed"}`)}
+	s.AddKnownTypes(managedclusterinfov1beta1.SchemeGroupVersion, &managedclusterinfov1beta1.ManagedClusterInfo{})
+	cc, client := getWebhookTestClient(server.URL, "", hook)
+	cc.Spec.Install.OnFailure = []clustercuratorv1.Hook{hook}
+
+	assert.Nil(t, RunFailureHooks(client, cc, "activate-and-monitor", "bad [[ .ClusterName ]]"))
+
+	extraVars := payload["extra_vars"].(map[string]interface{})
+	assert.Equal(t, "Curation of "+ClusterName+" failed", extraVars["summary"])
+	assert.Equal(t, "bad [[ .ClusterName ]]", extraVars["failure_message"], "the failure message is not rendered")
+}
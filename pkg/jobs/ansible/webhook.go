@@ -0,0 +1,317 @@
+// Copyright Contributors to the Open Cluster Management project.
+package ansible
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	clustercuratorv1 "github.com/stolostron/cluster-curator-controller/pkg/api/v1beta1"
+	"github.com/stolostron/cluster-curator-controller/pkg/jobs/utils"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/klog/v2"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// Keys of the webhookSecret of a Webhook hook
+const WebhookURLKey = "url"
+const WebhookHMACKey = "hmacKey"
+
+// WebhookSignatureHeader carries "sha256=" and the hex HMAC-SHA256 of the payload, when the
+// webhookSecret has an hmacKey
+const WebhookSignatureHeader = "X-Curator-Signature"
+
+// WebhookRequestTimeout bounds each request of a Webhook hook, the timeoutMinutes of the hook
+// bounds the whole attempt
+const WebhookRequestTimeout = 30 * time.Second
+
+var webhookClient = &http.Client{Timeout: WebhookRequestTimeout}
+
+// webhookStatus is the JSON body of a webhook response. A statusURL returned by the POST is polled
+// until its status is succeeded or failed.
+type webhookStatus struct {
+	StatusURL string `json:"statusURL,omitempty"`
+	Status    string `json:"status,omitempty"`
+	Message   string `json:"message,omitempty"`
+}
+
+// getWebhookSecret returns the URL and the HMAC key of the webhookSecret of a Webhook hook
+func getWebhookSecret(
+	client client.Client,
+	namespace string,
+	hookToRun clustercuratorv1.Hook) (string, []byte, error) {
+
+	if hookToRun.WebhookSecret == "" {
+		return "", nil, errors.New("The Webhook hook " + hookToRun.Name + " has no webhookSecret")
+	}
+
+	secret := &corev1.Secret{}
+	if err := client.Get(context.Background(), types.NamespacedName{
+		Namespace: namespace,
+		Name:      hookToRun.WebhookSecret,
+	}, secret); err != nil {
+		return "", nil, err
+	}
+
+	webhookURL := string(secret.Data[WebhookURLKey])
+	if webhookURL == "" {
+		return "", nil, errors.New("The Secret " + namespace + "/" + hookToRun.WebhookSecret +
+			" has no " + WebhookURLKey + " key")
+	}
+	return webhookURL, secret.Data[WebhookHMACKey], nil
+}
+
+// getWebhookPayload returns the JSON posted by an attempt of a Webhook hook. Its extra_vars carry
+// the cluster_deployment, install_config, cluster_info, hosted_cluster, node_pools, curation,
+// managed_cluster and hook_outputs an AnsibleJob receives.
+func getWebhookPayload(
+	client client.Client,
+	curator *clustercuratorv1.ClusterCurator,
+	jobtype string,
+	hookToRun clustercuratorv1.Hook,
+	attempt int) ([]byte, error) {
+
+	extraVars := map[string]interface{}{}
+	if hookToRun.ExtraVars != nil {
+		if err := json.Unmarshal(hookToRun.ExtraVars.Raw, &extraVars); err != nil {
+			return nil, err
+		}
+	}
+	if err := addExtraVarsFrom(client, curator.Namespace, hookToRun, extraVars); err != nil {
+		return nil, err
+	}
+	if err := addClusterContext(client, curator, extraVars); err != nil {
+		return nil, err
+	}
+	if err := addCurationContext(client, curator, jobtype, attempt, extraVars); err != nil {
+		return nil, err
+	}
+	if err := addHookOutputs(client, curator, extraVars); err != nil {
+		return nil, err
+	}
+
+	return json.Marshal(map[string]interface{}{
+		"curation":          utils.GetEffectiveCuration(curator),
+		"cluster_name":      curator.Name,
+		"cluster_namespace": curator.Namespace,
+		"hook_type":         jobtype,
+		"hook":              hookToRun.Name,
+		"extra_vars":        extraVars,
+	})
+}
+
+// signWebhookPayload returns the WebhookSignatureHeader value of the payload
+func signWebhookPayload(hmacKey []byte, payload []byte) string {
+	mac := hmac.New(sha256.New, hmacKey)
+	mac.Write(payload)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// doWebhookRequest sends a webhook request and decodes its JSON body. Any response other than 2xx
+// is an error. The error does not include the URL, as the URL of the webhookSecret can hold a
+// token.
+func doWebhookRequest(hookName string, req *http.Request) (*http.Response, *webhookStatus, error) {
+	resp, err := webhookClient.Do(req)
+	if err != nil {
+		var urlErr *url.Error
+		if errors.As(err, &urlErr) {
+			err = urlErr.Err
+		}
+		return nil, nil, errors.New("The request of the Webhook hook " + hookName + " failed: " + err.Error())
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, nil, err
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return nil, nil, errors.New("The Webhook hook " + hookName + " returned " + resp.Status)
+	}
+
+	status := &webhookStatus{}
+	if len(body) > 0 {
+		if err := json.Unmarshal(body, status); err != nil {
+			klog.V(2).Infof("The response of the Webhook hook %v is not JSON: %v", hookName, err.Error())
+		}
+	}
+	return resp, status, nil
+}
+
+// PostWebhook posts the payload of a Webhook hook. It returns the status URL to poll, from the
+// Location header of a 202 Accepted or the statusURL of the response, or an empty string when the
+// hook is done.
+func PostWebhook(webhookURL string, hmacKey []byte, hookName string, payload []byte) (string, error) {
+	req, err := http.NewRequestWithContext(context.Background(), http.MethodPost, webhookURL,
+		bytes.NewReader(payload))
+	if err != nil {
+		return "", errors.New("The webhookSecret URL of the Webhook hook " + hookName + " is not valid")
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if len(hmacKey) > 0 {
+		req.Header.Set(WebhookSignatureHeader, signWebhookPayload(hmacKey, payload))
+	}
+
+	klog.V(0).Info("Posting the Webhook hook " + hookName)
+	resp, status, err := doWebhookRequest(hookName, req)
+	if err != nil {
+		return "", err
+	}
+
+	statusURL := status.StatusURL
+	if resp.StatusCode == http.StatusAccepted && resp.Header.Get("Location") != "" {
+		statusURL = resp.Header.Get("Location")
+	}
+	if statusURL == "" {
+		return "", nil
+	}
+
+	// A relative status URL is relative to the webhook URL
+	location, err := resp.Request.URL.Parse(statusURL)
+	if err != nil {
+		return "", errors.New("The Webhook hook " + hookName + " returned an invalid status URL " + statusURL)
+	}
+	return location.String(), nil
+}
+
+// monitorWebhook polls the status URL of a Webhook hook until its status is succeeded or failed, a
+// timeout of zero waits until it is. A failed request or a response other than 2xx is polled again,
+// the status URL can be briefly unavailable while the remote work runs. It stops once cancel is
+// closed.
+func monitorWebhook(
+	hookName string, statusURL string, start time.Time, timeout time.Duration, cancel <-chan struct{}) error {
+
+	resource := "Webhook hook " + hookName
+	klog.V(0).Info("* Monitoring " + resource + " at " + statusURL)
+
+	for {
+		req, err := http.NewRequestWithContext(context.Background(), http.MethodGet, statusURL, nil)
+		if err != nil {
+			return err
+		}
+		_, status, err := doWebhookRequest(hookName, req)
+		if err != nil {
+			klog.Warningf("Could not read the status of the %v, polling again: %v", resource, err.Error())
+			status = &webhookStatus{}
+		}
+
+		switch strings.ToLower(status.Status) {
+		case "succeeded", "successful":
+			klog.V(2).Infof("%v finished successfully ✓", resource)
+			return nil
+		case "failed", "error":
+			return errors.New(resource + " failed: " + status.Message)
+		}
+
+		klog.V(2).Infof("%v is still running", resource)
+		if err := checkTimeout(resource, start, timeout); err != nil {
+			return err
+		}
+		if err := waitForNextPoll(resource, cancel); err != nil {
+			return err
+		}
+	}
+}
+
+// runWebhookHookAttempt posts the payload of an attempt and, when a status URL is returned, waits
+// for the remote work to finish. The attempt is nil when the webhookSecret or the payload can not
+// be read, as a retry would fail the same way. Once the post succeeded, an error is not retried so
+// the remote work is not started twice. For the same reason, when the attempt was already recorded
+// with a status URL before the curator pod was restarted, that URL is polled again while the
+// attempt is Running and its result is kept once it finished.
+func runWebhookHookAttempt(
+	client client.Client,
+	curator *clustercuratorv1.ClusterCurator,
+	jobType string,
+	stepName string,
+	hook clustercuratorv1.Hook,
+	attempt int,
+	timeout time.Duration,
+	cancel <-chan struct{}) (*clustercuratorv1.HookAttempt, error) {
+
+	if recorded := findWebhookHookAttempt(curator, stepName, hook.Name, attempt); recorded != nil {
+		return resumeWebhookHookAttempt(recorded, timeout, cancel)
+	}
+
+	webhookURL, hmacKey, err := getWebhookSecret(client, curator.Namespace, hook)
+	if err != nil {
+		return nil, err
+	}
+	payload, err := getWebhookPayload(client, curator, jobType, hook, attempt)
+	if err != nil {
+		return nil, err
+	}
+
+	hookAttempt := &clustercuratorv1.HookAttempt{
+		Hook:    hook.Name,
+		Attempt: attempt,
+		Result:  clustercuratorv1.CurationPhaseRunning,
+	}
+
+	start := time.Now()
+	statusURL, err := PostWebhook(webhookURL, hmacKey, hook.Name, payload)
+	if err != nil || statusURL == "" {
+		return hookAttempt, err
+	}
+
+	hookAttempt.URL = statusURL
+	utils.LogWarning(utils.RecordHookAttempt(client, curator.Name, curator.Namespace, stepName, *hookAttempt))
+
+	if err := monitorWebhook(hook.Name, statusURL, start, timeout, cancel); err != nil {
+		return hookAttempt, &noRetryError{err: err}
+	}
+	return hookAttempt, nil
+}
+
+// findWebhookHookAttempt returns the attempt of the Webhook hook recorded under the status.steps
+// entry of stepName with a status URL, nil when there is none
+func findWebhookHookAttempt(
+	curator *clustercuratorv1.ClusterCurator,
+	stepName string,
+	hookName string,
+	attempt int) *clustercuratorv1.HookAttempt {
+
+	for _, step := range curator.Status.Steps {
+		if step.Name != stepName {
+			continue
+		}
+		for _, recorded := range step.HookAttempts {
+			if recorded.Hook == hookName && recorded.Attempt == attempt && recorded.URL != "" {
+				return recorded.DeepCopy()
+			}
+		}
+	}
+	return nil
+}
+
+// resumeWebhookHookAttempt polls the status URL of a Running attempt recorded by the curator pod
+// before it was restarted, a finished attempt returns its recorded result
+func resumeWebhookHookAttempt(
+	hookAttempt *clustercuratorv1.HookAttempt,
+	timeout time.Duration,
+	cancel <-chan struct{}) (*clustercuratorv1.HookAttempt, error) {
+
+	switch hookAttempt.Result {
+	case clustercuratorv1.CurationPhaseSucceeded:
+		return hookAttempt, nil
+	case clustercuratorv1.CurationPhaseRunning:
+	default:
+		return hookAttempt, &noRetryError{err: errors.New(hookAttempt.Message)}
+	}
+
+	klog.V(0).Infof("Resuming attempt %v of Webhook hook %v", hookAttempt.Attempt, hookAttempt.Hook)
+	if err := monitorWebhook(hookAttempt.Hook, hookAttempt.URL, time.Now(), timeout, cancel); err != nil {
+		return hookAttempt, &noRetryError{err: err}
+	}
+	return hookAttempt, nil
+}
@@ -0,0 +1,308 @@
+// Copyright Contributors to the Open Cluster Management project.
+package ansible
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"sync/atomic"
+	"testing"
+
+	hivev1 "github.com/openshift/hive/apis/hive/v1"
+	clustercuratorv1 "github.com/stolostron/cluster-curator-controller/pkg/api/v1beta1"
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	clientfake "sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+const WebhookSecretName = "itsm-webhook"
+
+func getWebhookSecretResource(webhookURL string, hmacKey string) *corev1.Secret {
+	secret := &corev1.Secret{
+		ObjectMeta: v1.ObjectMeta{
+			Name:      WebhookSecretName,
+			Namespace: ClusterName,
+		},
+		Data: map[string][]byte{WebhookURLKey: []byte(webhookURL)},
+	}
+	if hmacKey != "" {
+		secret.Data[WebhookHMACKey] = []byte(hmacKey)
+	}
+	return secret
+}
+
+func getWebhookTestClient(webhookURL string, hmacKey string, hook clustercuratorv1.Hook) (
+	*clustercuratorv1.ClusterCurator, client.Client) {
+
+	cc := getClusterCurator()
+	cc.Spec.Install.Posthook = []clustercuratorv1.Hook{hook}
+	cc.Status.Steps = []clustercuratorv1.CurationStep{{
+		Name:  "posthook-ansiblejob",
+		State: clustercuratorv1.CurationPhaseRunning,
+	}}
+	os.Setenv(EnvJobType, POSTHOOK)
+
+	s.AddKnownTypes(clustercuratorv1.SchemeBuilder.GroupVersion, &clustercuratorv1.ClusterCurator{})
+	s.AddKnownTypes(hivev1.SchemeBuilder.GroupVersion, &hivev1.ClusterDeployment{}, &hivev1.MachinePool{})
+	s.AddKnownTypes(corev1.SchemeGroupVersion, &corev1.Secret{})
+	return cc, clientfake.NewClientBuilder().WithScheme(s).WithRuntimeObjects(
+		cc, genClusterDeployment(), getWebhookSecretResource(webhookURL, hmacKey)).Build()
+}
+
+func getWebhookHook() clustercuratorv1.Hook {
+	return clustercuratorv1.Hook{
+		Name:          "Open change",
+		Type:          clustercuratorv1.HookTypeWebhook,
+		WebhookSecret: WebhookSecretName,
+		ExtraVars: &runtime.RawExtension{
+			Raw: []byte(`{"variable1": "1"}`),
+		},
+	}
+}
+
+func getHookAttempts(t *testing.T, client client.Client) []clustercuratorv1.HookAttempt {
+	curator := &clustercuratorv1.ClusterCurator{}
+	assert.Nil(t, client.Get(context.Background(),
+		types.NamespacedName{Namespace: ClusterName, Name: ClusterName}, curator))
+	return curator.Status.Steps[0].HookAttempts
+}
+
+func TestJobWebhook(t *testing.T) {
+
+	payload := map[string]interface{}{}
+	rawPayload := []byte{}
+	signature := ""
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		rawPayload, _ = io.ReadAll(r.Body)
+		signature = r.Header.Get(WebhookSignatureHeader)
+		assert.Equal(t, "application/json", r.Header.Get("Content-Type"))
+		assert.Nil(t, json.Unmarshal(rawPayload, &payload))
+		w.WriteHeader(http.StatusCreated)
+	}))
+	defer server.Close()
+
+	cc, client := getWebhookTestClient(server.URL, "my-hmac-key", getWebhookHook())
+
+	assert.Nil(t, Job(client, cc), "err nil, when the webhook returns 2xx")
+
+	assert.Equal(t, "install", payload["curation"])
+	assert.Equal(t, ClusterName, payload["cluster_name"])
+	assert.Equal(t, POSTHOOK, payload["hook_type"])
+	assert.Equal(t, "Open change", payload["hook"])
+	extraVars := payload["extra_vars"].(map[string]interface{})
+	assert.Equal(t, "1", extraVars["variable1"], "the hook extra_vars are posted")
+	assert.Contains(t, extraVars, "cluster_deployment", "the cluster context is posted")
+
+	assert.Equal(t, signWebhookPayload([]byte("my-hmac-key"), rawPayload), signature, "the payload is signed")
+
+	attempts := getHookAttempts(t, client)
+	assert.Equal(t, 1, len(attempts))
+	assert.Equal(t, clustercuratorv1.CurationPhaseSucceeded, attempts[0].Result)
+}
+
+func TestSignWebhookPayload(t *testing.T) {
+	// echo -n '{"hook":"Open change"}' | openssl dgst -sha256 -hmac my-hmac-key
+	assert.Equal(t, "sha256=76761ad02c51bf34a69a5a352966600dadf778bcc59eb613efce53311dd43b6f",
+		signWebhookPayload([]byte("my-hmac-key"), []byte(`{"hook":"Open change"}`)))
+}
+
+func TestJobWebhookRetry(t *testing.T) {
+
+	requests := int32(0)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "", r.Header.Get(WebhookSignatureHeader), "the payload is not signed without an hmacKey")
+		if atomic.AddInt32(&requests, 1) == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	hook := getWebhookHook()
+	hook.Retries = 1
+	hook.RetryBackoff = 1
+	cc, client := getWebhookTestClient(server.URL, "", hook)
+
+	assert.Nil(t, Job(client, cc), "err nil, when the retry of the webhook returns 2xx")
+	assert.Equal(t, int32(2), atomic.LoadInt32(&requests))
+
+	attempts := getHookAttempts(t, client)
+	assert.Equal(t, 2, len(attempts), "each attempt is recorded")
+	assert.Equal(t, clustercuratorv1.CurationPhaseFailed, attempts[0].Result)
+	assert.Contains(t, attempts[0].Message, "503")
+	assert.Equal(t, clustercuratorv1.CurationPhaseSucceeded, attempts[1].Result)
+}
+
+func TestJobWebhookNegativeRetries(t *testing.T) {
+
+	requests := int32(0)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	hook := getWebhookHook()
+	hook.Retries = -1
+	cc, client := getWebhookTestClient(server.URL, "", hook)
+
+	assert.NotNil(t, Job(client, cc), "err not nil, the hook is not skipped with negative retries")
+	assert.Equal(t, int32(1), atomic.LoadInt32(&requests), "the hook runs once")
+}
+
+func TestJobWebhookStatusURL(t *testing.T) {
+
+	polls := int32(0)
+	mux := http.NewServeMux()
+	mux.HandleFunc("/changes", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Location", "/changes/CHG0001")
+		w.WriteHeader(http.StatusAccepted)
+	})
+	mux.HandleFunc("/changes/CHG0001", func(w http.ResponseWriter, r *http.Request) {
+		status := "running"
+		if atomic.AddInt32(&polls, 1) > 1 {
+			status = "failed"
+		}
+		_ = json.NewEncoder(w).Encode(webhookStatus{Status: status, Message: "the change was rejected"})
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	cc, client := getWebhookTestClient(server.URL+"/changes", "", getWebhookHook())
+
+	err := Job(client, cc)
+	assert.NotNil(t, err, "err not nil, when the status URL reports a failure")
+	assert.Contains(t, err.Error(), "the change was rejected")
+	assert.Equal(t, int32(2), atomic.LoadInt32(&polls), "the status URL is polled until the work finished")
+
+	attempts := getHookAttempts(t, client)
+	assert.Equal(t, 1, len(attempts))
+	assert.Equal(t, server.URL+"/changes/CHG0001", attempts[0].URL, "the status URL is recorded")
+	assert.Equal(t, clustercuratorv1.CurationPhaseFailed, attempts[0].Result)
+}
+
+func TestJobWebhookStatusURLUnavailable(t *testing.T) {
+
+	posts := int32(0)
+	polls := int32(0)
+	mux := http.NewServeMux()
+	mux.HandleFunc("/changes", func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&posts, 1)
+		w.Header().Set("Location", "/changes/CHG0001")
+		w.WriteHeader(http.StatusAccepted)
+	})
+	mux.HandleFunc("/changes/CHG0001", func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&polls, 1) == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		_ = json.NewEncoder(w).Encode(webhookStatus{Status: "failed", Message: "the change was rejected"})
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	hook := getWebhookHook()
+	hook.Retries = 1
+	hook.RetryBackoff = 1
+	cc, client := getWebhookTestClient(server.URL+"/changes", "", hook)
+
+	err := Job(client, cc)
+	assert.NotNil(t, err, "err not nil, when the status URL reports a failure")
+	assert.Contains(t, err.Error(), "the change was rejected")
+	assert.Equal(t, int32(2), atomic.LoadInt32(&polls), "the status URL is polled again after a 503")
+	assert.Equal(t, int32(1), atomic.LoadInt32(&posts), "the payload is not posted again once accepted")
+	assert.Equal(t, 1, len(getHookAttempts(t, client)))
+}
+
+func TestJobWebhookResumesStatusURL(t *testing.T) {
+
+	posts := int32(0)
+	polls := int32(0)
+	mux := http.NewServeMux()
+	mux.HandleFunc("/changes", func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&posts, 1)
+		w.Header().Set("Location", "/changes/CHG0002")
+		w.WriteHeader(http.StatusAccepted)
+	})
+	mux.HandleFunc("/changes/CHG0001", func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&polls, 1)
+		_ = json.NewEncoder(w).Encode(webhookStatus{Status: "succeeded"})
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	hook := getWebhookHook()
+	cc := getClusterCurator()
+	cc.Spec.Install.Posthook = []clustercuratorv1.Hook{hook}
+	cc.Status.Steps = []clustercuratorv1.CurationStep{{
+		Name:  "posthook-ansiblejob",
+		State: clustercuratorv1.CurationPhaseRunning,
+		HookAttempts: []clustercuratorv1.HookAttempt{{
+			Hook:    hook.Name,
+			Attempt: 1,
+			URL:     server.URL + "/changes/CHG0001",
+			Result:  clustercuratorv1.CurationPhaseRunning,
+		}},
+	}}
+	os.Setenv(EnvJobType, POSTHOOK)
+
+	s.AddKnownTypes(clustercuratorv1.SchemeBuilder.GroupVersion, &clustercuratorv1.ClusterCurator{})
+	s.AddKnownTypes(hivev1.SchemeBuilder.GroupVersion, &hivev1.ClusterDeployment{}, &hivev1.MachinePool{})
+	s.AddKnownTypes(corev1.SchemeGroupVersion, &corev1.Secret{})
+	client := clientfake.NewClientBuilder().WithScheme(s).WithRuntimeObjects(
+		cc, genClusterDeployment(), getWebhookSecretResource(server.URL+"/changes", "")).Build()
+
+	assert.Nil(t, Job(client, cc), "err nil, when the recorded status URL reports success")
+	assert.Equal(t, int32(0), atomic.LoadInt32(&posts), "the payload is not posted again on resume")
+	assert.Equal(t, int32(1), atomic.LoadInt32(&polls), "the recorded status URL is polled")
+
+	attempts := getHookAttempts(t, client)
+	assert.Equal(t, 1, len(attempts))
+	assert.Equal(t, server.URL+"/changes/CHG0001", attempts[0].URL)
+	assert.Equal(t, clustercuratorv1.CurationPhaseSucceeded, attempts[0].Result)
+}
+
+func TestPostWebhookStatusURLInBody(t *testing.T) {
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode(webhookStatus{StatusURL: "https://itsm.example.com/changes/CHG0002"})
+	}))
+	defer server.Close()
+
+	statusURL, err := PostWebhook(server.URL, nil, "Open change", []byte(`{}`))
+	assert.Nil(t, err)
+	assert.Equal(t, "https://itsm.example.com/changes/CHG0002", statusURL)
+}
+
+func TestJobWebhookNoSecret(t *testing.T) {
+
+	hook := getWebhookHook()
+	hook.WebhookSecret = "missing"
+	hook.Retries = 2
+	cc, client := getWebhookTestClient("https://itsm.example.com", "", hook)
+
+	err := Job(client, cc)
+	assert.NotNil(t, err, "err not nil, when the webhookSecret does not exist")
+	assert.Equal(t, 0, len(getHookAttempts(t, client)), "no attempt is made without the webhookSecret")
+
+	hook.WebhookSecret = ""
+	cc.Spec.Install.Posthook = []clustercuratorv1.Hook{hook}
+	err = Job(client, cc)
+	assert.NotNil(t, err, "err not nil, when the hook has no webhookSecret")
+	assert.Contains(t, err.Error(), "has no webhookSecret")
+}
+
+func TestPostWebhookHidesURL(t *testing.T) {
+
+	_, err := PostWebhook("http://127.0.0.1:1/hook?token=secret-token", nil, "Open change", []byte(`{}`))
+	assert.NotNil(t, err, "err not nil, when the webhook can not be reached")
+	assert.NotContains(t, err.Error(), "secret-token", "the URL of the webhookSecret is not in the error")
+}
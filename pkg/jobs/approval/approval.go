@@ -0,0 +1,109 @@
+// Copyright Contributors to the Open Cluster Management project.
+package approval
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"time"
+
+	clustercuratorv1 "github.com/stolostron/cluster-curator-controller/pkg/api/v1beta1"
+	"github.com/stolostron/cluster-curator-controller/pkg/jobs/utils"
+	"k8s.io/klog/v2"
+	clientv1 "sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const ApprovedAnnotation = "cluster.open-cluster-management.io/curation-approved"
+
+const DefaultApprovalTimeout = 60
+
+// IsApprovalRequired returns true when the hooks of the curation ask for an approval before the
+// main curation action runs
+func IsApprovalRequired(curator *clustercuratorv1.ClusterCurator, desiredCuration string) bool {
+	switch desiredCuration {
+	case "install":
+		return curator.Spec.Install.ApprovalRequired
+	case "upgrade":
+		return curator.Spec.Upgrade.ApprovalRequired
+	case "destroy":
+		return curator.Spec.Destroy.ApprovalRequired
+	case "scale":
+		return curator.Spec.Scale.ApprovalRequired
+	}
+	return false
+}
+
+// GetApprovalTimeout returns the approval timeout of the curation in minutes
+func GetApprovalTimeout(curator *clustercuratorv1.ClusterCurator) int {
+	timeout := 0
+	switch utils.GetEffectiveCuration(curator) {
+	case "install":
+		timeout = curator.Spec.Install.ApprovalTimeout
+	case "upgrade":
+		timeout = curator.Spec.Upgrade.ApprovalTimeout
+	case "destroy":
+		timeout = curator.Spec.Destroy.ApprovalTimeout
+	case "scale":
+		timeout = curator.Spec.Scale.ApprovalTimeout
+	}
+	if timeout <= 0 {
+		return DefaultApprovalTimeout
+	}
+	return timeout
+}
+
+// isApproved returns true when operation.approve is set, or the curation-approved annotation holds
+// the name of the running curator Job, so an annotation left from an earlier run does not approve
+func isApproved(curator *clustercuratorv1.ClusterCurator) bool {
+	if curator.Operation != nil && curator.Operation.Approve {
+		return true
+	}
+	return curator.Spec.CuratingJob != "" && curator.GetAnnotations()[ApprovedAnnotation] == curator.Spec.CuratingJob
+}
+
+// WaitForApproval blocks until the curation is approved with operation.approve or the
+// curation-approved annotation. The approval is removed once it is seen, so it only lets the
+// running curation continue.
+func WaitForApproval(client clientv1.Client, clusterName string, clusterNamespace string) error {
+	curator, err := utils.GetClusterCurator(client, clusterName, clusterNamespace)
+	if err != nil {
+		return err
+	}
+
+	timeout := GetApprovalTimeout(curator)
+
+	klog.V(0).Info("=> Waiting for the " + utils.GetEffectiveCuration(curator) + " curation to be approved")
+
+	retries := utils.GetRetryTimes(timeout, DefaultApprovalTimeout, utils.PauseTenSeconds)
+	for i := 0; i < retries; i++ {
+		if i > 0 {
+			time.Sleep(utils.PauseTenSeconds)
+			if curator, err = utils.GetClusterCurator(client, clusterName, clusterNamespace); err != nil {
+				return err
+			}
+		}
+
+		if isApproved(curator) {
+			klog.V(0).Info("The curation was approved ✓")
+			return removeApproval(client, curator)
+		}
+		klog.V(2).Info("Waiting for approval, attempt " + strconv.Itoa(i+1) + " of " + strconv.Itoa(retries))
+	}
+
+	return errors.New("Timed out waiting for the curation to be approved after " + strconv.Itoa(timeout) + " minutes")
+}
+
+func removeApproval(client clientv1.Client, curator *clustercuratorv1.ClusterCurator) error {
+	annotations := curator.GetAnnotations()
+	delete(annotations, ApprovedAnnotation)
+	curator.SetAnnotations(annotations)
+
+	if curator.Operation != nil {
+		curator.Operation.Approve = false
+		if *curator.Operation == (clustercuratorv1.Operation{}) {
+			curator.Operation = nil
+		}
+	}
+
+	return client.Update(context.TODO(), curator)
+}
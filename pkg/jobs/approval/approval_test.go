@@ -0,0 +1,113 @@
+// Copyright Contributors to the Open Cluster Management project.
+package approval
+
+import (
+	"testing"
+
+	clustercuratorv1 "github.com/stolostron/cluster-curator-controller/pkg/api/v1beta1"
+	"github.com/stolostron/cluster-curator-controller/pkg/jobs/utils"
+	"github.com/stretchr/testify/assert"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/scheme"
+	clientfake "sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+const ClusterName = "my-cluster"
+
+var s = scheme.Scheme
+
+func getUpgradeClusterCurator() *clustercuratorv1.ClusterCurator {
+	return &clustercuratorv1.ClusterCurator{
+		ObjectMeta: v1.ObjectMeta{
+			Name:      ClusterName,
+			Namespace: ClusterName,
+		},
+		Spec: clustercuratorv1.ClusterCuratorSpec{
+			DesiredCuration: "upgrade",
+			Upgrade: clustercuratorv1.UpgradeHooks{
+				DesiredUpdate:    "4.14.16",
+				ApprovalRequired: true,
+				ApprovalTimeout:  1,
+			},
+		},
+	}
+}
+
+func TestIsApprovalRequired(t *testing.T) {
+	curator := getUpgradeClusterCurator()
+
+	assert.True(t, IsApprovalRequired(curator, "upgrade"))
+	assert.False(t, IsApprovalRequired(curator, "install"))
+	assert.False(t, IsApprovalRequired(curator, "upgradePosthook"), "a posthook retry does not wait")
+
+	curator.Spec.Scale.ApprovalRequired = true
+	assert.True(t, IsApprovalRequired(curator, "scale"))
+}
+
+func TestGetApprovalTimeout(t *testing.T) {
+	curator := getUpgradeClusterCurator()
+	assert.Equal(t, 1, GetApprovalTimeout(curator))
+
+	curator.Spec.Upgrade.ApprovalTimeout = 0
+	assert.Equal(t, DefaultApprovalTimeout, GetApprovalTimeout(curator), "the default is used")
+}
+
+func TestIsApproved(t *testing.T) {
+	curator := getUpgradeClusterCurator()
+	curator.Spec.CuratingJob = "curator-job-abcde"
+	assert.False(t, isApproved(curator))
+
+	curator.Annotations = map[string]string{ApprovedAnnotation: "true"}
+	assert.False(t, isApproved(curator), "the annotation must name the running curator Job")
+
+	curator.Annotations[ApprovedAnnotation] = "curator-job-12345"
+	assert.False(t, isApproved(curator), "the approval of an earlier run is ignored")
+
+	curator.Annotations[ApprovedAnnotation] = "curator-job-abcde"
+	assert.True(t, isApproved(curator))
+
+	curator.Annotations = nil
+	curator.Operation = &clustercuratorv1.Operation{Approve: true}
+	assert.True(t, isApproved(curator))
+}
+
+func TestWaitForApprovalOperation(t *testing.T) {
+	curator := getUpgradeClusterCurator()
+	curator.Operation = &clustercuratorv1.Operation{Approve: true}
+
+	s.AddKnownTypes(clustercuratorv1.SchemeBuilder.GroupVersion, &clustercuratorv1.ClusterCurator{})
+	client := clientfake.NewClientBuilder().WithScheme(s).WithRuntimeObjects(curator).Build()
+
+	assert.Nil(t, WaitForApproval(client, ClusterName, ClusterName), "err nil, when the curation is approved")
+
+	cc, err := utils.GetClusterCurator(client, ClusterName, ClusterName)
+	assert.Nil(t, err, "err nil, when ClusterCurator is retrieved")
+	assert.Nil(t, cc.Operation, "the approval is removed")
+}
+
+func TestWaitForApprovalAnnotation(t *testing.T) {
+	curator := getUpgradeClusterCurator()
+	curator.Spec.CuratingJob = "curator-job-abcde"
+	curator.Annotations = map[string]string{
+		ApprovedAnnotation: "curator-job-abcde",
+		"other":            "annotation",
+	}
+	curator.Operation = &clustercuratorv1.Operation{RetryPosthook: "upgradePosthook"}
+
+	s.AddKnownTypes(clustercuratorv1.SchemeBuilder.GroupVersion, &clustercuratorv1.ClusterCurator{})
+	client := clientfake.NewClientBuilder().WithScheme(s).WithRuntimeObjects(curator).Build()
+
+	assert.Nil(t, WaitForApproval(client, ClusterName, ClusterName), "err nil, when the curation is approved")
+
+	cc, err := utils.GetClusterCurator(client, ClusterName, ClusterName)
+	assert.Nil(t, err, "err nil, when ClusterCurator is retrieved")
+	assert.Equal(t, map[string]string{"other": "annotation"}, cc.Annotations, "the approval is removed")
+	assert.Equal(t, "upgradePosthook", cc.Operation.RetryPosthook, "other operations are kept")
+}
+
+func TestWaitForApprovalNoResource(t *testing.T) {
+	s.AddKnownTypes(clustercuratorv1.SchemeBuilder.GroupVersion, &clustercuratorv1.ClusterCurator{})
+	client := clientfake.NewClientBuilder().WithScheme(s).Build()
+
+	assert.NotNil(t, WaitForApproval(client, ClusterName, ClusterName), "err not nil, when ClusterCurator is not found")
+}
@@ -0,0 +1,325 @@
+// Copyright Contributors to the Open Cluster Management project.
+package dryrun
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strconv"
+	"strings"
+
+	clustercuratorv1 "github.com/stolostron/cluster-curator-controller/pkg/api/v1beta1"
+	"github.com/stolostron/cluster-curator-controller/pkg/jobs/ansible"
+	"github.com/stolostron/cluster-curator-controller/pkg/jobs/hive"
+	"github.com/stolostron/cluster-curator-controller/pkg/jobs/hypershift"
+	"github.com/stolostron/cluster-curator-controller/pkg/jobs/utils"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/klog/v2"
+	clientv1 "sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const ProviderCredentialCheck = "providerCredential"
+const TowerAuthSecretCheck = "towerAuthSecret"
+const WebhookSecretCheck = "webhookSecret"
+const ExtraVarsFromCheck = "extraVarsFrom"
+const HooksCheck = "hooks"
+const UpgradeVersionCheck = "upgradeVersion"
+
+/*
+ * The dry run is split in two. The cluster-installer ServiceAccount of the curator Job can not
+ * read secrets, so the controller checks the secrets with CheckSecrets before it launches the
+ * dry-run Job, and the dry-run subcommand adds the hook and upgrade checks with Run.
+ */
+
+// CheckSecrets starts a dry run by checking that the provider credential, the Tower auth secret,
+// the webhookSecrets and the extraVarsFrom ConfigMaps and Secrets of the desired curation exist
+func CheckSecrets(kubeset kubernetes.Interface, curator *clustercuratorv1.ClusterCurator) *clustercuratorv1.DryRunResult {
+	result := &clustercuratorv1.DryRunResult{
+		Curation: utils.GetEffectiveCuration(curator),
+		Checks:   []clustercuratorv1.DryRunCheck{},
+	}
+
+	if curator.Spec.ProviderCredentialPath != "" {
+		result.Checks = append(result.Checks, checkProviderCredential(kubeset, curator))
+	}
+
+	prehook, posthook, towerAuthSecret, err := ansible.GetHooks(curator)
+	onFailure := ansible.GetFailureHooks(curator)
+	checked := map[string]bool{}
+	if err == nil && needsTowerAuthSecret(prehook, posthook, onFailure) {
+		if towerAuthSecret == "" {
+			result.Checks = append(result.Checks, clustercuratorv1.DryRunCheck{
+				Name:    TowerAuthSecretCheck,
+				Passed:  false,
+				Message: "towerAuthSecret is required to run the hooks",
+			})
+		} else {
+			checked["tower/"+towerAuthSecret] = true
+			result.Checks = append(result.Checks, checkSecret(kubeset, TowerAuthSecretCheck,
+				curator.Namespace+"/"+towerAuthSecret))
+		}
+	}
+
+	for _, hooks := range [][]clustercuratorv1.Hook{prehook, posthook, onFailure} {
+		for _, hook := range hooks {
+			if !isAnsibleHook(hook) || hook.TowerAuthSecret == "" || checked["tower/"+hook.TowerAuthSecret] {
+				continue
+			}
+			checked["tower/"+hook.TowerAuthSecret] = true
+			result.Checks = append(result.Checks, checkSecret(kubeset, TowerAuthSecretCheck,
+				curator.Namespace+"/"+hook.TowerAuthSecret))
+		}
+	}
+
+	for _, hooks := range [][]clustercuratorv1.Hook{prehook, posthook, onFailure} {
+		for _, hook := range hooks {
+			if hook.Type != clustercuratorv1.HookTypeWebhook || hook.WebhookSecret == "" || checked[hook.WebhookSecret] {
+				continue
+			}
+			checked[hook.WebhookSecret] = true
+			result.Checks = append(result.Checks, checkSecret(kubeset, WebhookSecretCheck,
+				curator.Namespace+"/"+hook.WebhookSecret))
+		}
+	}
+
+	for _, hooks := range [][]clustercuratorv1.Hook{prehook, posthook, onFailure} {
+		for _, hook := range hooks {
+			for _, source := range hook.ExtraVarsFrom {
+				if ref := source.ConfigMapRef; ref != nil && !ref.Optional && !checked["configmap/"+ref.Name] {
+					checked["configmap/"+ref.Name] = true
+					result.Checks = append(result.Checks, checkConfigMap(kubeset, ExtraVarsFromCheck,
+						curator.Namespace, ref.Name))
+				}
+				if ref := source.SecretRef; ref != nil && !ref.Optional && !checked["secret/"+ref.Name] {
+					checked["secret/"+ref.Name] = true
+					result.Checks = append(result.Checks, checkSecret(kubeset, ExtraVarsFromCheck,
+						curator.Namespace+"/"+ref.Name))
+				}
+			}
+		}
+	}
+
+	return result
+}
+
+// needsTowerAuthSecret returns true when a hook runs an AnsibleJob without a towerAuthSecret of its
+// own, Container and Webhook hooks do not need the Tower auth secret
+func needsTowerAuthSecret(hookLists ...[]clustercuratorv1.Hook) bool {
+	for _, hooks := range hookLists {
+		for _, hook := range hooks {
+			if isAnsibleHook(hook) && hook.TowerAuthSecret == "" {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func isAnsibleHook(hook clustercuratorv1.Hook) bool {
+	return hook.Type != clustercuratorv1.HookTypeContainer && hook.Type != clustercuratorv1.HookTypeWebhook
+}
+
+func checkConfigMap(
+	kubeset kubernetes.Interface, name string, namespace string, configMapName string) clustercuratorv1.DryRunCheck {
+
+	check := clustercuratorv1.DryRunCheck{Name: name}
+	if _, err := kubeset.CoreV1().ConfigMaps(namespace).Get(
+		context.TODO(), configMapName, v1.GetOptions{}); err != nil {
+		check.Message = "ConfigMap " + namespace + "/" + configMapName + " could not be read: " + err.Error()
+		return check
+	}
+
+	check.Passed = true
+	check.Message = "ConfigMap " + namespace + "/" + configMapName + " found"
+	return check
+}
+
+// checkProviderCredential only reads the provider credential from the namespace of the curator, the
+// controller must not be used to probe the secrets of other namespaces
+func checkProviderCredential(
+	kubeset kubernetes.Interface, curator *clustercuratorv1.ClusterCurator) clustercuratorv1.DryRunCheck {
+
+	secretNamespace, _, err := utils.PathSplitterFromEnv(curator.Spec.ProviderCredentialPath)
+	if err == nil && secretNamespace != curator.Namespace {
+		return clustercuratorv1.DryRunCheck{
+			Name: ProviderCredentialCheck,
+			Message: "Secret " + curator.Spec.ProviderCredentialPath + " must be in the namespace " +
+				curator.Namespace + " of the ClusterCurator",
+		}
+	}
+	return checkSecret(kubeset, ProviderCredentialCheck, curator.Spec.ProviderCredentialPath)
+}
+
+func checkSecret(kubeset kubernetes.Interface, name string, secretPath string) clustercuratorv1.DryRunCheck {
+	check := clustercuratorv1.DryRunCheck{Name: name}
+
+	secretNamespace, secretName, err := utils.PathSplitterFromEnv(secretPath)
+	if err != nil {
+		check.Message = err.Error()
+		return check
+	}
+
+	if _, err := kubeset.CoreV1().Secrets(secretNamespace).Get(
+		context.TODO(), secretName, v1.GetOptions{}); err != nil {
+		check.Message = "Secret " + secretPath + " could not be read: " + err.Error()
+		return check
+	}
+
+	check.Passed = true
+	check.Message = "Secret " + secretPath + " found"
+	return check
+}
+
+// Run adds the hook checks and, for an upgrade, the upgrade version check to the findings of
+// CheckSecrets and records them in status.dryRun. The cluster is not changed, but the version check
+// of a forced upgrade creates a temporary ManagedClusterView in the cluster namespace to read the
+// ClusterVersion, and deletes it when it is done. An error is returned when a check failed.
+func Run(
+	client clientv1.Client,
+	dc dynamic.Interface,
+	curator *clustercuratorv1.ClusterCurator) error {
+
+	klog.V(0).Info("* Dry run of the " + utils.GetEffectiveCuration(curator) + " curation")
+
+	result := &clustercuratorv1.DryRunResult{}
+	if curator.Status.DryRun != nil {
+		result = curator.Status.DryRun.DeepCopy()
+	}
+	result.Curation = utils.GetEffectiveCuration(curator)
+
+	result.Checks = append(result.Checks, checkHooks(curator))
+
+	if result.Curation == "upgrade" {
+		releaseImage, check := checkUpgrade(client, dc, curator)
+		result.ReleaseImage = releaseImage
+		result.Checks = append(result.Checks, check)
+	}
+
+	now := v1.Now()
+	result.Timestamp = &now
+	if err := utils.RecordDryRunResult(client, curator.Name, curator.Namespace, result); err != nil {
+		return err
+	}
+
+	failed := []string{}
+	for _, check := range result.Checks {
+		klog.V(2).Infof("Dry run check %v passed: %v, %v", check.Name, check.Passed, check.Message)
+		if !check.Passed {
+			failed = append(failed, check.Name+": "+check.Message)
+		}
+	}
+	if len(failed) > 0 {
+		return errors.New("Dry run failed - " + strings.Join(failed, "; "))
+	}
+
+	klog.V(0).Info("Dry run passed ✓")
+	return nil
+}
+
+func checkHooks(curator *clustercuratorv1.ClusterCurator) clustercuratorv1.DryRunCheck {
+	check := clustercuratorv1.DryRunCheck{Name: HooksCheck}
+
+	prehook, posthook, _, err := ansible.GetHooks(curator)
+	if err != nil {
+		check.Message = err.Error()
+		return check
+	}
+
+	onFailure := ansible.GetFailureHooks(curator)
+	hooks := append(append(append([]clustercuratorv1.Hook{}, prehook...), posthook...), onFailure...)
+	for _, hook := range hooks {
+		if hook.Name == "" {
+			check.Message = "A hook is missing the template name"
+			return check
+		}
+		if hook.Type != "" && hook.Type != clustercuratorv1.HookTypeJob &&
+			hook.Type != clustercuratorv1.HookTypeWorkflow && hook.Type != clustercuratorv1.HookTypeContainer &&
+			hook.Type != clustercuratorv1.HookTypeWebhook {
+			check.Message = "Hook " + hook.Name + " has an unsupported type " + string(hook.Type)
+			return check
+		}
+		if hook.Type == clustercuratorv1.HookTypeContainer &&
+			!utils.IsHookImageAllowed(hook.Image, utils.GetHookImageAllowlist()) {
+			check.Message = "Hook " + hook.Name + " image " + hook.Image + " is not allowed by the " +
+				utils.HookImageAllowlistEnv + " of the controller"
+			return check
+		}
+		if hook.Type == clustercuratorv1.HookTypeWebhook && hook.WebhookSecret == "" {
+			check.Message = "Webhook hook " + hook.Name + " has no webhookSecret"
+			return check
+		}
+		if !isAnsibleHook(hook) && (len(hook.Outputs) > 0 || hook.TowerAuthSecret != "" || hook.Inventory != "" ||
+			hook.Limit != "" || hook.Verbosity != nil || hook.JobTTL != 0 || hook.RunnerImage != "" ||
+			hook.RunnerVersion != "") {
+			check.Message = "Hook " + hook.Name + " sets outputs, towerAuthSecret, inventory or AnsibleJob options, " +
+				"these only apply to the AnsibleJob of a Job or Workflow hook"
+			return check
+		}
+		if hook.RunnerImage != "" && !utils.IsHookImageAllowed(hook.RunnerImage, utils.GetHookImageAllowlist()) {
+			check.Message = "Hook " + hook.Name + " runner_image " + hook.RunnerImage + " is not allowed by the " +
+				utils.HookImageAllowlistEnv + " of the controller"
+			return check
+		}
+		if hook.Verbosity != nil && (*hook.Verbosity < 0 || *hook.Verbosity > 5) {
+			check.Message = "Hook " + hook.Name + " verbosity must be between 0 and 5"
+			return check
+		}
+		if hook.JobTTL < 0 {
+			check.Message = "Hook " + hook.Name + " job_ttl can not be negative"
+			return check
+		}
+		if hook.ExtraVars != nil {
+			extraVars := map[string]interface{}{}
+			if err := json.Unmarshal(hook.ExtraVars.Raw, &extraVars); err != nil {
+				check.Message = "Hook " + hook.Name + " extra_vars is not an object: " + err.Error()
+				return check
+			}
+			if err := ansible.CheckExtraVarsTemplates(extraVars); err != nil {
+				check.Message = "Hook " + hook.Name + " extra_vars has an invalid template: " + err.Error()
+				return check
+			}
+		}
+	}
+
+	check.Passed = true
+	check.Message = "Found " + strconv.Itoa(len(prehook)) + " prehooks, " + strconv.Itoa(len(posthook)) + " posthooks and " +
+		strconv.Itoa(len(onFailure)) + " onFailure hooks"
+	return check
+}
+
+func checkUpgrade(
+	client clientv1.Client,
+	dc dynamic.Interface,
+	curator *clustercuratorv1.ClusterCurator) (string, clustercuratorv1.DryRunCheck) {
+
+	check := clustercuratorv1.DryRunCheck{Name: UpgradeVersionCheck}
+
+	clusterType, err := utils.GetClusterType(client, dc, curator.Name, curator.Namespace, true)
+	if err != nil {
+		check.Message = err.Error()
+		return "", check
+	}
+
+	releaseImage := ""
+	if clusterType == utils.HypershiftClusterType {
+		releaseImage, err = hypershift.DryRunUpgrade(client, dc, curator.Name, curator)
+	} else {
+		releaseImage, err = hive.DryRunUpgrade(client, curator.Name, curator)
+	}
+
+	if errors.Is(err, utils.ErrAlreadyAtVersion) {
+		check.Passed = true
+		check.Message = "Cluster is already at version " + curator.Spec.Upgrade.DesiredUpdate + ", the upgrade would be skipped"
+		return "", check
+	}
+	if err != nil {
+		check.Message = err.Error()
+		return "", check
+	}
+
+	check.Passed = true
+	check.Message = "The " + clusterType + " cluster can be upgraded"
+	return releaseImage, check
+}
@@ -0,0 +1,302 @@
+// Copyright Contributors to the Open Cluster Management project.
+package dryrun
+
+import (
+	"testing"
+
+	clustercuratorv1 "github.com/stolostron/cluster-curator-controller/pkg/api/v1beta1"
+	"github.com/stolostron/cluster-curator-controller/pkg/jobs/utils"
+	managedclusterinfov1beta1 "github.com/stolostron/cluster-lifecycle-api/clusterinfo/v1beta1"
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	dynfake "k8s.io/client-go/dynamic/fake"
+	"k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/kubernetes/scheme"
+	clientfake "sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+const ClusterName = "my-cluster"
+const ClusterNamespace = "clusters"
+
+var s = scheme.Scheme
+
+func getUpgradeClusterCurator(desiredUpdate string) *clustercuratorv1.ClusterCurator {
+	return &clustercuratorv1.ClusterCurator{
+		ObjectMeta: v1.ObjectMeta{
+			Name:      ClusterName,
+			Namespace: ClusterNamespace,
+		},
+		Spec: clustercuratorv1.ClusterCuratorSpec{
+			DesiredCuration:        "upgrade",
+			DryRun:                 true,
+			ProviderCredentialPath: ClusterNamespace + "/provider-secret",
+			Upgrade: clustercuratorv1.UpgradeHooks{
+				DesiredUpdate:   desiredUpdate,
+				TowerAuthSecret: "toweraccess",
+				Prehook:         []clustercuratorv1.Hook{{Name: "prehook job"}},
+			},
+		},
+	}
+}
+
+func getManagedClusterInfo() *managedclusterinfov1beta1.ManagedClusterInfo {
+	return &managedclusterinfov1beta1.ManagedClusterInfo{
+		ObjectMeta: v1.ObjectMeta{
+			Name:      ClusterName,
+			Namespace: ClusterName,
+		},
+		Status: managedclusterinfov1beta1.ClusterInfoStatus{
+			DistributionInfo: managedclusterinfov1beta1.DistributionInfo{
+				OCP: managedclusterinfov1beta1.OCPDistributionInfo{
+					Version: "4.13.6",
+				},
+			},
+		},
+	}
+}
+
+func getSecret(name string) *corev1.Secret {
+	return &corev1.Secret{
+		ObjectMeta: v1.ObjectMeta{
+			Name:      name,
+			Namespace: ClusterNamespace,
+		},
+	}
+}
+
+func TestCheckSecrets(t *testing.T) {
+	curator := getUpgradeClusterCurator("4.13.7")
+
+	kubeset := fake.NewSimpleClientset(getSecret("provider-secret"))
+
+	result := CheckSecrets(kubeset, curator)
+	assert.Equal(t, "upgrade", result.Curation)
+	assert.Equal(t, 2, len(result.Checks))
+	assert.Equal(t, ProviderCredentialCheck, result.Checks[0].Name)
+	assert.True(t, result.Checks[0].Passed, "the provider credential exists")
+	assert.Equal(t, TowerAuthSecretCheck, result.Checks[1].Name)
+	assert.False(t, result.Checks[1].Passed, "the Tower auth secret does not exist")
+
+	t.Log("The Tower auth secret is required when there are hooks")
+	curator.Spec.Upgrade.TowerAuthSecret = ""
+	result = CheckSecrets(kubeset, curator)
+	assert.False(t, result.Checks[1].Passed)
+	assert.Contains(t, result.Checks[1].Message, "towerAuthSecret is required")
+
+	t.Log("No Tower auth secret is checked without hooks")
+	curator.Spec.Upgrade.Prehook = nil
+	result = CheckSecrets(kubeset, curator)
+	assert.Equal(t, 1, len(result.Checks))
+
+	t.Log("No Tower auth secret is checked with only Container hooks")
+	curator.Spec.Upgrade.Prehook = []clustercuratorv1.Hook{{
+		Name:  "prehook container",
+		Type:  clustercuratorv1.HookTypeContainer,
+		Image: "quay.io/my-org/cmdb-hook:1.0",
+	}}
+	result = CheckSecrets(kubeset, curator)
+	assert.Equal(t, 1, len(result.Checks))
+
+	t.Log("The webhookSecret of a Webhook hook is checked instead of the Tower auth secret")
+	curator.Spec.Upgrade.Posthook = []clustercuratorv1.Hook{
+		{Name: "open change", Type: clustercuratorv1.HookTypeWebhook, WebhookSecret: "itsm-webhook"},
+		{Name: "close change", Type: clustercuratorv1.HookTypeWebhook, WebhookSecret: "itsm-webhook"},
+	}
+	result = CheckSecrets(kubeset, curator)
+	assert.Equal(t, 2, len(result.Checks), "each webhookSecret is checked once")
+	assert.Equal(t, WebhookSecretCheck, result.Checks[1].Name)
+	assert.False(t, result.Checks[1].Passed, "the webhookSecret does not exist")
+
+	t.Log("The extraVarsFrom ConfigMaps and Secrets are checked, unless they are optional")
+	curator.Spec.Upgrade.Posthook = nil
+	curator.Spec.Upgrade.Prehook[0].ExtraVarsFrom = []clustercuratorv1.ExtraVarsSource{
+		{ConfigMapRef: &clustercuratorv1.ExtraVarsReference{Name: "itsm-config"}},
+		{SecretRef: &clustercuratorv1.ExtraVarsReference{Name: "provider-secret"}},
+		{SecretRef: &clustercuratorv1.ExtraVarsReference{Name: "itsm-credentials", Optional: true}},
+	}
+	result = CheckSecrets(kubeset, curator)
+	assert.Equal(t, 3, len(result.Checks))
+	assert.Equal(t, ExtraVarsFromCheck, result.Checks[1].Name)
+	assert.False(t, result.Checks[1].Passed, "the ConfigMap does not exist")
+	assert.Contains(t, result.Checks[1].Message, "itsm-config")
+	assert.Equal(t, ExtraVarsFromCheck, result.Checks[2].Name)
+	assert.True(t, result.Checks[2].Passed, "the Secret exists")
+
+	t.Log("The towerAuthSecret of a hook is checked, the one of the block only when a hook has none")
+	curator.Spec.Upgrade.TowerAuthSecret = "toweraccess"
+	curator.Spec.Upgrade.Prehook = []clustercuratorv1.Hook{
+		{Name: "network job", TowerAuthSecret: "network-tower"},
+		{Name: "platform job", TowerAuthSecret: "provider-secret"},
+		{Name: "platform job 2", TowerAuthSecret: "provider-secret"},
+	}
+	result = CheckSecrets(kubeset, curator)
+	assert.Equal(t, 3, len(result.Checks), "each towerAuthSecret is checked once")
+	assert.Equal(t, TowerAuthSecretCheck, result.Checks[1].Name)
+	assert.Contains(t, result.Checks[1].Message, "network-tower")
+	assert.False(t, result.Checks[1].Passed, "the towerAuthSecret of the hook does not exist")
+	assert.True(t, result.Checks[2].Passed, "the towerAuthSecret of the hook exists")
+
+	curator.Spec.Upgrade.Posthook = []clustercuratorv1.Hook{{Name: "posthook job"}}
+	result = CheckSecrets(kubeset, curator)
+	assert.Equal(t, 4, len(result.Checks))
+	assert.Contains(t, result.Checks[1].Message, "toweraccess")
+}
+
+func TestCheckSecretsProviderCredentialNamespace(t *testing.T) {
+	curator := getUpgradeClusterCurator("4.13.7")
+	curator.Spec.ProviderCredentialPath = "other-namespace/provider-secret"
+
+	otherSecret := getSecret("provider-secret")
+	otherSecret.Namespace = "other-namespace"
+	kubeset := fake.NewSimpleClientset(otherSecret)
+
+	result := CheckSecrets(kubeset, curator)
+	assert.Equal(t, ProviderCredentialCheck, result.Checks[0].Name)
+	assert.False(t, result.Checks[0].Passed, "the provider credential is in another namespace")
+	assert.Contains(t, result.Checks[0].Message, "must be in the namespace "+ClusterNamespace)
+}
+
+func TestCheckHooks(t *testing.T) {
+	curator := getUpgradeClusterCurator("4.13.7")
+	curator.Spec.Upgrade.Posthook = []clustercuratorv1.Hook{{
+		Name:      "posthook job",
+		Type:      clustercuratorv1.HookTypeWorkflow,
+		ExtraVars: &runtime.RawExtension{Raw: []byte(`{"variable1": "1"}`)},
+	}}
+
+	check := checkHooks(curator)
+	assert.True(t, check.Passed, "the hooks are valid")
+	assert.Equal(t, "Found 1 prehooks, 1 posthooks and 0 onFailure hooks", check.Message)
+
+	curator.Spec.Upgrade.Posthook[0].ExtraVars = &runtime.RawExtension{Raw: []byte(`["variable1"]`)}
+	check = checkHooks(curator)
+	assert.False(t, check.Passed, "extra_vars must be an object")
+
+	curator.Spec.Upgrade.Posthook[0].ExtraVars = &runtime.RawExtension{Raw: []byte(`{"version": "[[ .Upgrade.DesiredUpdate"}`)}
+	check = checkHooks(curator)
+	assert.False(t, check.Passed, "extra_vars templates must be valid")
+	assert.Contains(t, check.Message, "invalid template")
+
+	curator.Spec.Upgrade.Posthook[0].ExtraVars = nil
+	curator.Spec.Upgrade.Posthook[0].Name = ""
+	check = checkHooks(curator)
+	assert.False(t, check.Passed, "a hook needs a template name")
+}
+
+func TestCheckHooksContainer(t *testing.T) {
+	curator := getUpgradeClusterCurator("4.13.7")
+	curator.Spec.Upgrade.Posthook = []clustercuratorv1.Hook{{
+		Name:  "posthook container",
+		Type:  clustercuratorv1.HookTypeContainer,
+		Image: "quay.io/my-org/cmdb-hook:1.0",
+	}}
+
+	t.Setenv(utils.HookImageAllowlistEnv, "")
+	check := checkHooks(curator)
+	assert.False(t, check.Passed, "no image is allowed without an allowlist")
+	assert.Contains(t, check.Message, utils.HookImageAllowlistEnv)
+
+	t.Setenv(utils.HookImageAllowlistEnv, "quay.io/my-org/")
+	check = checkHooks(curator)
+	assert.True(t, check.Passed, "the image is in the allowlist")
+
+	curator.Spec.Upgrade.Posthook[0].Outputs = []string{"ci_id"}
+	check = checkHooks(curator)
+	assert.False(t, check.Passed, "a Container hook has no outputs")
+	assert.Contains(t, check.Message, "outputs")
+}
+
+func TestCheckHooksWebhook(t *testing.T) {
+	curator := getUpgradeClusterCurator("4.13.7")
+	curator.Spec.Upgrade.Posthook = []clustercuratorv1.Hook{{
+		Name: "posthook webhook",
+		Type: clustercuratorv1.HookTypeWebhook,
+	}}
+
+	check := checkHooks(curator)
+	assert.False(t, check.Passed, "a Webhook hook needs a webhookSecret")
+	assert.Contains(t, check.Message, "webhookSecret")
+
+	curator.Spec.Upgrade.Posthook[0].WebhookSecret = "itsm-webhook"
+	check = checkHooks(curator)
+	assert.True(t, check.Passed, "the Webhook hook is valid")
+
+	curator.Spec.Upgrade.Posthook[0].TowerAuthSecret = "network-tower"
+	check = checkHooks(curator)
+	assert.False(t, check.Passed, "a Webhook hook does not use a towerAuthSecret")
+}
+
+func TestCheckHooksAnsibleJobOptions(t *testing.T) {
+	curator := getUpgradeClusterCurator("4.13.7")
+	verbosity := 3
+	curator.Spec.Upgrade.Posthook = []clustercuratorv1.Hook{{
+		Name:        "posthook job",
+		Limit:       "masters",
+		Verbosity:   &verbosity,
+		JobTTL:      600,
+		RunnerImage: "quay.io/my-org/ansible-runner",
+	}}
+
+	t.Setenv(utils.HookImageAllowlistEnv, "quay.io/other-org/")
+	check := checkHooks(curator)
+	assert.False(t, check.Passed, "the runner_image is not in the allowlist")
+	assert.Contains(t, check.Message, "runner_image")
+
+	t.Setenv(utils.HookImageAllowlistEnv, "quay.io/my-org/")
+	check = checkHooks(curator)
+	assert.True(t, check.Passed, "the AnsibleJob options are valid")
+
+	verbosity = 6
+	check = checkHooks(curator)
+	assert.False(t, check.Passed, "the verbosity is at most 5")
+	assert.Contains(t, check.Message, "verbosity")
+
+	verbosity = 3
+	curator.Spec.Upgrade.Posthook[0].Type = clustercuratorv1.HookTypeWebhook
+	curator.Spec.Upgrade.Posthook[0].WebhookSecret = "itsm-webhook"
+	check = checkHooks(curator)
+	assert.False(t, check.Passed, "a Webhook hook has no AnsibleJob options")
+}
+
+func TestRun(t *testing.T) {
+	curator := getUpgradeClusterCurator("4.13.7")
+	curator.Status.DryRun = CheckSecrets(fake.NewSimpleClientset(
+		getSecret("provider-secret"), getSecret("toweraccess")), curator)
+
+	s.AddKnownTypes(clustercuratorv1.SchemeBuilder.GroupVersion, &clustercuratorv1.ClusterCurator{})
+	s.AddKnownTypes(managedclusterinfov1beta1.SchemeGroupVersion, &managedclusterinfov1beta1.ManagedClusterInfo{})
+	client := clientfake.NewClientBuilder().WithScheme(s).WithRuntimeObjects(curator, getManagedClusterInfo()).Build()
+	dynclient := dynfake.NewSimpleDynamicClient(runtime.NewScheme())
+
+	assert.Nil(t, Run(client, dynclient, curator), "err nil, when every check passed")
+
+	cc, err := utils.GetClusterCurator(client, ClusterName, ClusterNamespace)
+	assert.Nil(t, err, "err nil, when ClusterCurator is retrieved")
+	assert.NotNil(t, cc.Status.DryRun.Timestamp, "timestamp is set")
+	assert.Equal(t, "quay.io/openshift-release-dev/ocp-release:4.13.7-multi", cc.Status.DryRun.ReleaseImage)
+	assert.Equal(t, []string{ProviderCredentialCheck, TowerAuthSecretCheck, HooksCheck, UpgradeVersionCheck},
+		[]string{cc.Status.DryRun.Checks[0].Name, cc.Status.DryRun.Checks[1].Name,
+			cc.Status.DryRun.Checks[2].Name, cc.Status.DryRun.Checks[3].Name})
+	assert.Equal(t, "4.13.7", cc.Spec.Upgrade.DesiredUpdate, "the curator spec is not changed")
+}
+
+func TestRunFailedCheck(t *testing.T) {
+	curator := getUpgradeClusterCurator("4.13.7")
+	curator.Status.DryRun = CheckSecrets(fake.NewSimpleClientset(), curator)
+
+	s.AddKnownTypes(clustercuratorv1.SchemeBuilder.GroupVersion, &clustercuratorv1.ClusterCurator{})
+	s.AddKnownTypes(managedclusterinfov1beta1.SchemeGroupVersion, &managedclusterinfov1beta1.ManagedClusterInfo{})
+	client := clientfake.NewClientBuilder().WithScheme(s).WithRuntimeObjects(curator).Build()
+	dynclient := dynfake.NewSimpleDynamicClient(runtime.NewScheme())
+
+	err := Run(client, dynclient, curator)
+	assert.NotNil(t, err, "err not nil, when a check failed")
+	assert.Contains(t, err.Error(), ProviderCredentialCheck)
+	assert.Contains(t, err.Error(), UpgradeVersionCheck, "the ManagedClusterInfo is missing")
+
+	cc, err := utils.GetClusterCurator(client, ClusterName, ClusterNamespace)
+	assert.Nil(t, err, "err nil, when ClusterCurator is retrieved")
+	assert.Equal(t, 4, len(cc.Status.DryRun.Checks), "the findings are recorded when a check fails")
+}
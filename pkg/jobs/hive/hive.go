@@ -359,13 +359,15 @@ func EUSUpgradeCluster(client clientv1.Client, clusterName string, curator *clus
 		return err
 	}
 
-	klog.V(2).Info("Check if managedclusterview exists " + clusterName)
+	mcvName := utils.GetCurationRunName(curator, clusterName)
+	admackName := utils.GetCurationRunName(curator, clusterName+"admack")
+	klog.V(2).Info("Check if managedclusterview exists " + mcvName)
 
 	// For OCP/Kubenetes versions that removed APIs, need to acknowledge this
 	// before upgrading or else upgrade will be blocked.
 	ocpConfigMCV := &managedclusterviewv1beta1.ManagedClusterView{
 		ObjectMeta: v1.ObjectMeta{
-			Name:      clusterName + "admack",
+			Name:      admackName,
 			Namespace: clusterName,
 			Labels: map[string]string{
 				MCVUpgradeLabel: clusterName,
@@ -380,14 +382,15 @@ func EUSUpgradeCluster(client clientv1.Client, clusterName string, curator *clus
 			},
 		},
 	}
+	utils.SetCurationRunOwner(ocpConfigMCV, curator)
 
 	ocpConfigView := managedclusterviewv1beta1.ManagedClusterView{}
 	if err := client.Get(context.TODO(), types.NamespacedName{
-		Name:      clusterName + "admack",
+		Name:      admackName,
 		Namespace: clusterName,
 	}, &ocpConfigView); err != nil && k8serrors.IsNotFound(err) {
 		// check if mcv exists before creating
-		klog.V(2).Info("Create managedclusterview " + clusterName + "admack")
+		klog.V(2).Info("Create managedclusterview " + admackName)
 		if err := client.Create(context.TODO(), ocpConfigMCV); err != nil {
 			return err
 		}
@@ -397,7 +400,7 @@ func EUSUpgradeCluster(client clientv1.Client, clusterName string, curator *clus
 
 	ocpConfigGetErr := errors.New("Failed to get remote admin-ack configmap")
 	resultOCPConfigMCV := managedclusterviewv1beta1.ManagedClusterView{}
-	if err := waitForMCV(client, clusterName+"admack", clusterName, &resultOCPConfigMCV, ocpConfigGetErr); err != nil {
+	if err := waitForMCV(client, admackName, clusterName, &resultOCPConfigMCV, ocpConfigGetErr); err != nil {
 		return err
 	}
 
@@ -414,7 +417,7 @@ func EUSUpgradeCluster(client clientv1.Client, clusterName string, curator *clus
 	// Get clusterversion from managed cluster to initiate upgrade
 	managedclusterview := &managedclusterviewv1beta1.ManagedClusterView{
 		ObjectMeta: v1.ObjectMeta{
-			Name:      clusterName,
+			Name:      mcvName,
 			Namespace: clusterName,
 			Labels: map[string]string{
 				MCVUpgradeLabel: clusterName,
@@ -430,13 +433,14 @@ func EUSUpgradeCluster(client clientv1.Client, clusterName string, curator *clus
 			},
 		},
 	}
+	utils.SetCurationRunOwner(managedclusterview, curator)
 
 	mcview := managedclusterviewv1beta1.ManagedClusterView{}
 	if err := client.Get(context.TODO(), types.NamespacedName{
 		Namespace: clusterName,
-		Name:      clusterName,
+		Name:      mcvName,
 	}, &mcview); err != nil && k8serrors.IsNotFound(err) {
-		klog.V(2).Info("Create managedclusterview " + clusterName)
+		klog.V(2).Info("Create managedclusterview " + mcvName)
 		if err := client.Create(context.TODO(), managedclusterview); err != nil {
 			return err
 		}
@@ -445,7 +449,7 @@ func EUSUpgradeCluster(client clientv1.Client, clusterName string, curator *clus
 	}
 
 	resultmcview := managedclusterviewv1beta1.ManagedClusterView{}
-	if err := waitForMCV(client, clusterName, clusterName, &resultmcview, GetErrConst); err != nil {
+	if err := waitForMCV(client, mcvName, clusterName, &resultmcview, GetErrConst); err != nil {
 		return err
 	}
 
@@ -486,10 +490,10 @@ func EUSUpgradeCluster(client clientv1.Client, clusterName string, curator *clus
 		utils.CheckError(err)
 		updateConfigMap.Raw = b
 	}
-	klog.V(2).Info("Create managedclusteraction to update configmap " + clusterName + "admack")
+	klog.V(2).Info("Create managedclusteraction to update configmap " + admackName)
 	ocpConfigMCA := &managedclusteractionv1beta1.ManagedClusterAction{
 		ObjectMeta: v1.ObjectMeta{
-			Name:      clusterName + "admack",
+			Name:      admackName,
 			Namespace: clusterName,
 		},
 		Spec: managedclusteractionv1beta1.ActionSpec{
@@ -502,6 +506,7 @@ func EUSUpgradeCluster(client clientv1.Client, clusterName string, curator *clus
 			},
 		},
 	}
+	utils.SetCurationRunOwner(ocpConfigMCA, curator)
 	if err := client.Create(context.TODO(), ocpConfigMCA); err != nil {
 		return err
 	}
@@ -511,7 +516,7 @@ func EUSUpgradeCluster(client clientv1.Client, clusterName string, curator *clus
 	for i := 1; i <= 5; i++ {
 		time.Sleep(utils.PauseFiveSeconds)
 		if err := client.Get(context.TODO(), types.NamespacedName{
-			Name:      clusterName + "admack",
+			Name:      admackName,
 			Namespace: clusterName,
 		}, &ocpConfigMCAStatus); err != nil {
 			if i == 5 {
@@ -529,7 +534,7 @@ func EUSUpgradeCluster(client clientv1.Client, clusterName string, curator *clus
 		condition := meta.FindStatusCondition(ocpConfigMCAStatus.Status.Conditions, managedclusteractionv1beta1.ConditionActionCompleted)
 		if condition != nil {
 			if condition.Status == v1.ConditionTrue {
-				klog.V(2).Info("Remote configmap updated successfully " + clusterName + "admack")
+				klog.V(2).Info("Remote configmap updated successfully " + admackName)
 			} else if condition.Status == v1.ConditionFalse {
 				klog.Warning("ManagedClusterAction failed to update remote clusterversion", ocpConfigMCAStatus.Status.Conditions)
 				return errors.New("Remote confimap update failed")
@@ -568,7 +573,7 @@ func EUSUpgradeCluster(client clientv1.Client, clusterName string, curator *clus
 		klog.V(2).Info("Update clusterversion attempt " + strconv.Itoa(i))
 
 		mcaStatus, err := eusRetreiveAndUpdateClusterVersion(client,
-			clusterName, updateVersion, managedclusterview, isInterVersion)
+			clusterName, curator, updateVersion, managedclusterview, isInterVersion)
 		if err != nil {
 			return err
 		}
@@ -619,7 +624,7 @@ func MonitorUpgradeStatus(client clientv1.Client, clusterName string, curator *c
 
 		if getErr = client.Get(context.TODO(), types.NamespacedName{
 			Namespace: clusterName,
-			Name:      clusterName,
+			Name:      utils.GetCurationRunName(curator, clusterName),
 		}, &resultmcview); getErr != nil {
 			// sleep and keep on retrying
 			time.Sleep(utils.PauseSixtySeconds)
@@ -699,6 +704,155 @@ func MonitorUpgradeStatus(client clientv1.Client, clusterName string, curator *c
 	return timeoutErr
 }
 
+func ScaleCluster(client clientv1.Client, clusterName string, curator *clustercuratorv1.ClusterCurator) error {
+	klog.V(0).Info("* Initiate Scale")
+
+	targets := curator.Spec.Scale.MachinePools
+	if len(targets) == 0 {
+		return errors.New("Provide at least one MachinePool in spec.scale.machinePools")
+	}
+
+	for _, target := range targets {
+		klog.V(2).Info("Looking up machinepool " + clusterName + "/" + target.Name)
+
+		err := retry.RetryOnConflict(retry.DefaultBackoff, func() error {
+			machinePool := &hivev1.MachinePool{}
+			if err := client.Get(context.TODO(), types.NamespacedName{
+				Namespace: clusterName,
+				Name:      target.Name,
+			}, machinePool); err != nil {
+				return err
+			}
+
+			if machinePool.Spec.ClusterDeploymentRef.Name != clusterName {
+				return errors.New("MachinePool " + target.Name + " does not belong to cluster " + clusterName)
+			}
+			if machinePool.Spec.Autoscaling != nil {
+				return errors.New("MachinePool " + target.Name + " uses autoscaling and cannot be scaled to a fixed replica count")
+			}
+
+			replicas := int64(target.Replicas)
+			originalMachinePool := machinePool.DeepCopy()
+			machinePool.Spec.Replicas = &replicas
+			return client.Patch(context.TODO(), machinePool, clientv1.MergeFrom(originalMachinePool))
+		})
+		if err != nil {
+			return err
+		}
+		klog.V(0).Infof("Scaled MachinePool %v to %v replicas ✓", target.Name, target.Replicas)
+	}
+
+	return nil
+}
+
+func MonitorScaleStatus(client clientv1.Client, clusterName string, curator *clustercuratorv1.ClusterCurator) error {
+	scaleAttempts := utils.GetRetryTimes(curator.Spec.Scale.MonitorTimeout, 30, utils.PauseTenSeconds)
+	klog.V(0).Info("Monitoring up to " + strconv.Itoa(scaleAttempts) + " attempts for MachinePool scaling")
+
+	for i := 0; i < scaleAttempts; i++ {
+		ready, message, err := areMachinePoolsScaled(client, clusterName, curator.Spec.Scale.MachinePools)
+		if err != nil {
+			return err
+		}
+
+		if ready {
+			klog.V(2).Info("Scale succeeded ✓")
+			return nil
+		}
+
+		if i%6 == 0 {
+			klog.V(0).Info("Scale status - " + message)
+			utils.CheckError(utils.RecordCurrentStatusCondition(
+				client,
+				clusterName,
+				clusterName,
+				"monitor-scale",
+				v1.ConditionFalse,
+				"Scale status - "+message))
+		}
+		time.Sleep(utils.PauseTenSeconds)
+	}
+
+	return errors.New("Timed out waiting for MachinePools to scale")
+}
+
+// areMachinePoolsScaled reports whether every target MachinePool has reached its
+// desired replica count with all of its machines ready. When a pool is not ready
+// yet, the returned message describes the first pool that is still scaling.
+func areMachinePoolsScaled(
+	client clientv1.Client, clusterName string, targets []clustercuratorv1.ScaleTarget) (bool, string, error) {
+
+	for _, target := range targets {
+		machinePool := &hivev1.MachinePool{}
+		if err := client.Get(context.TODO(), types.NamespacedName{
+			Namespace: clusterName,
+			Name:      target.Name,
+		}, machinePool); err != nil {
+			return false, "", err
+		}
+
+		var readyReplicas int32
+		for _, machineSet := range machinePool.Status.MachineSets {
+			readyReplicas += machineSet.ReadyReplicas
+		}
+
+		if machinePool.Status.Replicas != target.Replicas || readyReplicas != target.Replicas {
+			return false, fmt.Sprintf("MachinePool %v has %v/%v ready replicas",
+				target.Name, readyReplicas, target.Replicas), nil
+		}
+		klog.V(4).Infof("MachinePool %v is at %v replicas", target.Name, target.Replicas)
+	}
+
+	return true, "", nil
+}
+
+// DryRunUpgrade runs the upgrade validation of UpgradeCluster, or EUSUpgradeCluster for an EUS to
+// EUS upgrade, without updating the cluster. It returns the release image with digest when the
+// cluster lists the desired version in its updates. A forced upgrade creates a temporary
+// ManagedClusterView to read the ClusterVersion, it is deleted before DryRunUpgrade returns.
+func DryRunUpgrade(client clientv1.Client, clusterName string, curator *clustercuratorv1.ClusterCurator) (string, error) {
+	if curator.Spec.Upgrade.IntermediateUpdate != "" {
+		return "", validateEUSUpgradeVersion(client, clusterName, curator, true)
+	}
+	imageWithDigest, err := validateUpgradeVersion(client, clusterName, curator)
+
+	// A forced upgrade reads the ClusterVersion through a temporary ManagedClusterView
+	if deleteErr := client.Delete(context.TODO(), &managedclusterviewv1beta1.ManagedClusterView{
+		ObjectMeta: v1.ObjectMeta{Name: utils.GetCurationRunName(curator, clusterName), Namespace: clusterName},
+	}); deleteErr != nil && !k8serrors.IsNotFound(deleteErr) {
+		klog.Warningf("Could not remove the dry run ManagedClusterView: %v", deleteErr)
+	}
+	return imageWithDigest, err
+}
+
+// DeleteUpgradeResources removes the ManagedClusterViews and ManagedClusterActions an upgrade
+// creates in the cluster namespace, used when the upgrade is cancelled before it cleans them up.
+// The objects of the running curation are removed, with those named after the cluster only.
+func DeleteUpgradeResources(client clientv1.Client, clusterName string, curator *clustercuratorv1.ClusterCurator) error {
+	names := []string{clusterName, clusterName + "admack"}
+	if curator.Spec.CuratingJob != "" {
+		names = append(names, utils.GetCurationRunName(curator, clusterName),
+			utils.GetCurationRunName(curator, clusterName+"admack"))
+	}
+	for _, name := range names {
+		objs := []clientv1.Object{
+			&managedclusterviewv1beta1.ManagedClusterView{
+				ObjectMeta: v1.ObjectMeta{Name: name, Namespace: clusterName},
+			},
+			&managedclusteractionv1beta1.ManagedClusterAction{
+				ObjectMeta: v1.ObjectMeta{Name: name, Namespace: clusterName},
+			},
+		}
+		for _, obj := range objs {
+			if err := client.Delete(context.TODO(), obj); err != nil && !k8serrors.IsNotFound(err) {
+				return err
+			}
+		}
+	}
+	klog.V(2).Info("Removed upgrade ManagedClusterViews and ManagedClusterActions for " + clusterName)
+	return nil
+}
+
 func validateUpgradeVersion(client clientv1.Client, clusterName string, curator *clustercuratorv1.ClusterCurator) (string, error) {
 
 	desiredUpdate := curator.Spec.Upgrade.DesiredUpdate
@@ -738,15 +892,16 @@ func validateUpgradeVersion(client clientv1.Client, clusterName string, curator
 		isValidVersion = true
 		// Get clusterversion from managed cluster to check conditionalUpdates
 		// this info is not in ManagedClusterInfo
+		mcvName := utils.GetCurationRunName(curator, clusterName)
 		mcview := managedclusterviewv1beta1.ManagedClusterView{}
 		if err := client.Get(context.TODO(), types.NamespacedName{
 			Namespace: clusterName,
-			Name:      clusterName,
+			Name:      mcvName,
 		}, &mcview); err != nil && k8serrors.IsNotFound(err) {
-			klog.V(2).Info("Create managedclusterview " + clusterName)
+			klog.V(2).Info("Create managedclusterview " + mcvName)
 			mcviewobj := &managedclusterviewv1beta1.ManagedClusterView{
 				ObjectMeta: v1.ObjectMeta{
-					Name:      clusterName,
+					Name:      mcvName,
 					Namespace: clusterName,
 					Labels: map[string]string{
 						MCVUpgradeLabel: clusterName,
@@ -762,6 +917,7 @@ func validateUpgradeVersion(client clientv1.Client, clusterName string, curator
 					},
 				},
 			}
+			utils.SetCurationRunOwner(mcviewobj, curator)
 			if err := client.Create(context.TODO(), mcviewobj); err != nil {
 				return "", err
 			}
@@ -770,7 +926,7 @@ func validateUpgradeVersion(client clientv1.Client, clusterName string, curator
 		}
 
 		resultmcview := managedclusterviewv1beta1.ManagedClusterView{}
-		if err := waitForMCV(client, clusterName, clusterName, &resultmcview, GetErrConst); err != nil {
+		if err := waitForMCV(client, mcvName, clusterName, &resultmcview, GetErrConst); err != nil {
 			return "", err
 		}
 
@@ -874,6 +1030,49 @@ func waitForMCV(client clientv1.Client, clusterName string, clusterNamespace str
 	return nil
 }
 
+// ValidateEUSUpgradeSpec checks the EUS to EUS upgrade rules that do not depend on the current
+// cluster version, so a bad IntermediateUpdate and DesiredUpdate pair is rejected before a Job runs
+func ValidateEUSUpgradeSpec(curator *clustercuratorv1.ClusterCurator) error {
+	if curator.Spec.Upgrade.IntermediateUpdate == "" {
+		return nil
+	}
+	if curator.Spec.Upgrade.DesiredUpdate == "" {
+		return errors.New(fmt.Sprintf("DesiredUpdate is required to run EUS to EUS upgrade for Curator %q", curator.Name))
+	}
+	desiredVersion, err := semver.Make(curator.Spec.Upgrade.DesiredUpdate)
+	if err != nil {
+		return errors.New(fmt.Sprintf("DesiredUpdate %q is not a valid version: %v", curator.Spec.Upgrade.DesiredUpdate, err))
+	}
+
+	intermediateVersion, err := semver.Make(curator.Spec.Upgrade.IntermediateUpdate)
+	if err != nil {
+		return errors.New(fmt.Sprintf("IntermediateUpdate %q is not a valid version: %v", curator.Spec.Upgrade.IntermediateUpdate, err))
+	}
+
+	return validateEUSUpgradeRules(curator, desiredVersion, intermediateVersion)
+}
+
+// validateEUSUpgradeRules checks the DesiredUpdate is greater than the IntermediateUpdate, in the
+// same major version and the next minor version
+func validateEUSUpgradeRules(
+	curator *clustercuratorv1.ClusterCurator, desiredVersion, intermediateVersion semver.Version) error {
+
+	if desiredVersion.Compare(intermediateVersion) <= 0 {
+		return errors.New(fmt.Sprintf("DesiredUpdate %s must be greater than IntermediateUpdate %s to run EUS to EUS upgrade for Curator %q",
+			desiredVersion, intermediateVersion, curator.Name))
+	}
+
+	if desiredVersion.Major != intermediateVersion.Major {
+		return errors.New(fmt.Sprintf("Major version EUS to EUS upgrade in not supported for Curator %q", curator.Name))
+	}
+
+	if desiredVersion.Minor != intermediateVersion.Minor+1 {
+		return errors.New(fmt.Sprintf("Minor version EUS to EUS upgrade must be continuous for Curator %q", curator.Name))
+	}
+
+	return nil
+}
+
 func validateEUSUpgradeVersion(client clientv1.Client, clusterName string, curator *clustercuratorv1.ClusterCurator, isInterVersion bool) error {
 	if curator.Spec.Upgrade.DesiredUpdate == "" {
 		return errors.New(fmt.Sprintf("DesiredUpdate is required to run EUS to EUS upgrade for Curator %q", curator.Name))
@@ -913,16 +1112,15 @@ func validateEUSUpgradeVersion(client clientv1.Client, clusterName string, curat
 	}
 
 	// desiredVersion == targeted final EUS version
-	if desiredVersion.Compare(intermediateVersion) == 0 || desiredVersion.Compare(intermediateVersion) == -1 {
-		return errors.New(fmt.Sprintf("DesiredUpdate %s must be greater than IntermediateUpdate %s to run EUS to EUS upgrade for Curator %q",
-			desiredVersion, intermediateVersion, curator.Name))
+	if err := validateEUSUpgradeRules(curator, desiredVersion, intermediateVersion); err != nil {
+		return err
 	}
 
-	if intermediateVersion.Major != currentVersion.Major || desiredVersion.Major != currentVersion.Major {
+	if intermediateVersion.Major != currentVersion.Major {
 		return errors.New(fmt.Sprintf("Major version EUS to EUS upgrade in not supported for Curator %q", curator.Name))
 	}
 
-	if isInterVersion && (intermediateVersion.Minor != (currentVersion.Minor+1) || desiredVersion.Minor != (currentVersion.Minor+2)) {
+	if isInterVersion && intermediateVersion.Minor != currentVersion.Minor+1 {
 		return errors.New(fmt.Sprintf("Minor version EUS to EUS upgrade must be continuous for Curator %q", curator.Name))
 	}
 
@@ -936,9 +1134,10 @@ func retreiveAndUpdateClusterVersion(
 	desiredUpdate, imageWithDigest string) (managedclusteractionv1beta1.ManagedClusterAction, error) {
 
 	mcaStatus := managedclusteractionv1beta1.ManagedClusterAction{}
+	name := utils.GetCurationRunName(curator, clusterName)
 	managedclusterview := &managedclusterviewv1beta1.ManagedClusterView{
 		ObjectMeta: v1.ObjectMeta{
-			Name:      clusterName,
+			Name:      name,
 			Namespace: clusterName,
 			Labels: map[string]string{
 				MCVUpgradeLabel: clusterName,
@@ -955,13 +1154,15 @@ func retreiveAndUpdateClusterVersion(
 		},
 	}
 
+	utils.SetCurationRunOwner(managedclusterview, curator)
+
 	mcview := managedclusterviewv1beta1.ManagedClusterView{}
 	if err := client.Get(context.TODO(), types.NamespacedName{
 		Namespace: clusterName,
-		Name:      clusterName,
+		Name:      name,
 	}, &mcview); err != nil {
 
-		klog.V(2).Info("Create managedclusterview " + clusterName)
+		klog.V(2).Info("Create managedclusterview " + name)
 		if err := client.Create(context.TODO(), managedclusterview); err != nil {
 			return mcaStatus, err
 		}
@@ -972,7 +1173,7 @@ func retreiveAndUpdateClusterVersion(
 		time.Sleep(utils.PauseFiveSeconds)
 		if err := client.Get(context.TODO(), types.NamespacedName{
 			Namespace: clusterName,
-			Name:      clusterName,
+			Name:      name,
 		}, &resultmcview); err != nil {
 			if i == 5 {
 				klog.Warning(err)
@@ -1067,10 +1268,10 @@ func retreiveAndUpdateClusterVersion(
 		utils.CheckError(err)
 		updateClusterVersion.Raw = b
 	}
-	klog.V(2).Info("Create managedclusteraction to update clusterversion " + clusterName)
+	klog.V(2).Info("Create managedclusteraction to update clusterversion " + name)
 	managedclusteraction := &managedclusteractionv1beta1.ManagedClusterAction{
 		ObjectMeta: v1.ObjectMeta{
-			Name:      clusterName,
+			Name:      name,
 			Namespace: clusterName,
 		},
 		Spec: managedclusteractionv1beta1.ActionSpec{
@@ -1083,6 +1284,7 @@ func retreiveAndUpdateClusterVersion(
 			},
 		},
 	}
+	utils.SetCurationRunOwner(managedclusteraction, curator)
 	if err := client.Create(context.TODO(), managedclusteraction); err != nil {
 		return mcaStatus, err
 	}
@@ -1091,7 +1293,7 @@ func retreiveAndUpdateClusterVersion(
 		time.Sleep(utils.PauseFiveSeconds)
 		if err := client.Get(context.TODO(), types.NamespacedName{
 			Namespace: clusterName,
-			Name:      clusterName,
+			Name:      name,
 		}, &mcaStatus); err != nil {
 			if i == 5 {
 				return mcaStatus, err
@@ -1110,6 +1312,7 @@ func retreiveAndUpdateClusterVersion(
 func eusRetreiveAndUpdateClusterVersion(
 	client clientv1.Client,
 	clusterName string,
+	curator *clustercuratorv1.ClusterCurator,
 	updateVersion string,
 	managedclusterview *managedclusterviewv1beta1.ManagedClusterView,
 	isInterVersion bool) (managedclusteractionv1beta1.ManagedClusterAction, error) {
@@ -1119,12 +1322,13 @@ func eusRetreiveAndUpdateClusterVersion(
 	mcaStatus := managedclusteractionv1beta1.ManagedClusterAction{}
 	resultmcview := managedclusterviewv1beta1.ManagedClusterView{}
 	clusterVersion := map[string]interface{}{}
+	name := managedclusterview.Name
 
 	if err := client.Get(context.TODO(), types.NamespacedName{
 		Namespace: clusterName,
-		Name:      clusterName,
+		Name:      name,
 	}, &mcview); err != nil && k8serrors.IsNotFound(err) {
-		klog.V(2).Info("Create managedclusterview " + clusterName)
+		klog.V(2).Info("Create managedclusterview " + name)
 		if err := client.Create(context.TODO(), managedclusterview); err != nil {
 			return mcaStatus, err
 		}
@@ -1132,7 +1336,7 @@ func eusRetreiveAndUpdateClusterVersion(
 		return mcaStatus, err
 	}
 
-	if err := waitForMCV(client, clusterName, clusterName, &resultmcview, GetErrConst); err != nil {
+	if err := waitForMCV(client, name, clusterName, &resultmcview, GetErrConst); err != nil {
 		return mcaStatus, err
 	}
 	resultClusterVersion := resultmcview.Status.Result
@@ -1179,10 +1383,10 @@ func eusRetreiveAndUpdateClusterVersion(
 		utils.CheckError(err)
 		updateClusterVersion.Raw = b
 	}
-	klog.V(2).Info("Create managedclusteraction to update clusterversion " + clusterName)
+	klog.V(2).Info("Create managedclusteraction to update clusterversion " + name)
 	managedclusteraction := &managedclusteractionv1beta1.ManagedClusterAction{
 		ObjectMeta: v1.ObjectMeta{
-			Name:      clusterName,
+			Name:      name,
 			Namespace: clusterName,
 		},
 		Spec: managedclusteractionv1beta1.ActionSpec{
@@ -1195,6 +1399,7 @@ func eusRetreiveAndUpdateClusterVersion(
 			},
 		},
 	}
+	utils.SetCurationRunOwner(managedclusteraction, curator)
 	if err := client.Create(context.TODO(), managedclusteraction); err != nil {
 		return mcaStatus, err
 	}
@@ -1203,7 +1408,7 @@ func eusRetreiveAndUpdateClusterVersion(
 		time.Sleep(utils.PauseFiveSeconds)
 		if err := client.Get(context.TODO(), types.NamespacedName{
 			Namespace: clusterName,
-			Name:      clusterName,
+			Name:      name,
 		}, &mcaStatus); err != nil {
 			if i == 5 {
 				return mcaStatus, err
@@ -18,6 +18,7 @@ import (
 	"github.com/stretchr/testify/assert"
 	batchv1 "k8s.io/api/batch/v1"
 	corev1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
 	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
@@ -2203,3 +2204,184 @@ func TestUpgradeClusterForceUpgradeWithImageDigestInAvailableList(t *testing.T)
 	assert.Nil(t, UpgradeCluster(client, ClusterName, clustercurator),
 		"Upgrade started successfully to non-recommended version with image digest in available list")
 }
+
+func getScaleClusterCurator() *clustercuratorv1.ClusterCurator {
+	return &clustercuratorv1.ClusterCurator{
+		ObjectMeta: v1.ObjectMeta{
+			Name:      ClusterName,
+			Namespace: ClusterName,
+		},
+		Spec: clustercuratorv1.ClusterCuratorSpec{
+			DesiredCuration: "scale",
+			Scale: clustercuratorv1.ScaleHooks{
+				MachinePools: []clustercuratorv1.ScaleTarget{
+					{
+						Name:     ClusterName + "-worker",
+						Replicas: 5,
+					},
+				},
+			},
+		},
+	}
+}
+
+func getMachinePool(replicas int32) *hivev1.MachinePool {
+	specReplicas := int64(3)
+	return &hivev1.MachinePool{
+		ObjectMeta: v1.ObjectMeta{
+			Name:      ClusterName + "-worker",
+			Namespace: ClusterName,
+		},
+		Spec: hivev1.MachinePoolSpec{
+			ClusterDeploymentRef: corev1.LocalObjectReference{Name: ClusterName},
+			Name:                 "worker",
+			Replicas:             &specReplicas,
+		},
+		Status: hivev1.MachinePoolStatus{
+			Replicas: replicas,
+			MachineSets: []hivev1.MachineSetStatus{
+				{
+					Name:          ClusterName + "-worker-us-east-1a",
+					Replicas:      replicas,
+					ReadyReplicas: replicas,
+				},
+			},
+		},
+	}
+}
+
+func TestDryRunUpgradeRemovesManagedClusterView(t *testing.T) {
+
+	s := scheme.Scheme
+	s.AddKnownTypes(clustercuratorv1.SchemeBuilder.GroupVersion, &clustercuratorv1.ClusterCurator{})
+	s.AddKnownTypes(managedclusterinfov1beta1.SchemeGroupVersion, &managedclusterinfov1beta1.ManagedClusterInfo{})
+	s.AddKnownTypes(managedclusterviewv1beta1.SchemeGroupVersion, &managedclusterviewv1beta1.ManagedClusterView{})
+
+	clustercurator := &clustercuratorv1.ClusterCurator{
+		ObjectMeta: v1.ObjectMeta{
+			Name:      ClusterName,
+			Namespace: ClusterName,
+			Annotations: map[string]string{
+				ForceUpgradeAnnotation: "true",
+			},
+		},
+		Spec: clustercuratorv1.ClusterCuratorSpec{
+			DesiredCuration: "upgrade",
+			DryRun:          true,
+			CuratingJob:     "dry-run-job",
+			Upgrade: clustercuratorv1.UpgradeHooks{
+				DesiredUpdate: "4.5.10",
+			},
+		},
+	}
+	mcvName := utils.GetCurationRunName(clustercurator, ClusterName)
+
+	b, _ := json.Marshal(&clusterversionv1.ClusterVersion{
+		Status: clusterversionv1.ClusterVersionStatus{
+			AvailableUpdates: []clusterversionv1.Release{
+				{
+					Version: "4.5.10",
+					Image:   "quay.io/openshift-release-dev/ocp-release@sha256:71e158c6173ad6aa6e356c119a87459196bbe70e89c0db1e35c1f63a87d90676",
+				},
+			},
+		},
+	})
+
+	client := clientfake.NewClientBuilder().WithRuntimeObjects(clustercurator, getManagedClusterInfo()).WithScheme(s).Build()
+
+	go func() {
+		for i := 0; i < 60; i++ {
+			time.Sleep(500 * time.Millisecond)
+			resultmcview := managedclusterviewv1beta1.ManagedClusterView{}
+			if err := client.Get(context.TODO(), types.NamespacedName{
+				Namespace: ClusterName,
+				Name:      mcvName,
+			}, &resultmcview); err != nil {
+				continue
+			}
+			resultmcview.Status.Result.Raw = b
+			if err := client.Update(context.TODO(), &resultmcview); err == nil {
+				break
+			}
+		}
+	}()
+
+	releaseImage, err := DryRunUpgrade(client, ClusterName, clustercurator)
+	assert.Nil(t, err, "err nil when the forced upgrade is valid")
+	assert.Contains(t, releaseImage, "@sha256:", "the image digest is resolved")
+
+	err = client.Get(context.TODO(), types.NamespacedName{Namespace: ClusterName, Name: mcvName},
+		&managedclusterviewv1beta1.ManagedClusterView{})
+	assert.True(t, k8serrors.IsNotFound(err), "the ManagedClusterView is removed by the dry run")
+}
+
+func TestScaleClusterNoMachinePools(t *testing.T) {
+	s := scheme.Scheme
+	hivev1.AddToScheme(s)
+	client := clientfake.NewClientBuilder().WithScheme(s).Build()
+
+	curator := getScaleClusterCurator()
+	curator.Spec.Scale.MachinePools = nil
+
+	assert.NotNil(t, ScaleCluster(client, ClusterName, curator),
+		"err NotNil when no MachinePools are listed")
+}
+
+func TestScaleClusterMissingMachinePool(t *testing.T) {
+	s := scheme.Scheme
+	hivev1.AddToScheme(s)
+	client := clientfake.NewClientBuilder().WithScheme(s).Build()
+
+	assert.NotNil(t, ScaleCluster(client, ClusterName, getScaleClusterCurator()),
+		"err NotNil when the MachinePool does not exist")
+}
+
+func TestScaleClusterAutoscaling(t *testing.T) {
+	s := scheme.Scheme
+	hivev1.AddToScheme(s)
+
+	machinePool := getMachinePool(3)
+	machinePool.Spec.Replicas = nil
+	machinePool.Spec.Autoscaling = &hivev1.MachinePoolAutoscaling{MinReplicas: 2, MaxReplicas: 6}
+	client := clientfake.NewClientBuilder().WithRuntimeObjects(machinePool).WithScheme(s).Build()
+
+	err := ScaleCluster(client, ClusterName, getScaleClusterCurator())
+	assert.NotNil(t, err, "err NotNil when the MachinePool uses autoscaling")
+	assert.Contains(t, err.Error(), "autoscaling")
+}
+
+func TestScaleCluster(t *testing.T) {
+	s := scheme.Scheme
+	hivev1.AddToScheme(s)
+	client := clientfake.NewClientBuilder().WithRuntimeObjects(getMachinePool(3)).WithScheme(s).Build()
+
+	assert.Nil(t, ScaleCluster(client, ClusterName, getScaleClusterCurator()),
+		"err Nil when the MachinePool is scaled")
+
+	machinePool := &hivev1.MachinePool{}
+	assert.Nil(t, client.Get(context.TODO(), types.NamespacedName{
+		Namespace: ClusterName,
+		Name:      ClusterName + "-worker",
+	}, machinePool))
+	assert.Equal(t, int64(5), *machinePool.Spec.Replicas, "spec.replicas is set to the desired count")
+}
+
+func TestMonitorScaleStatus(t *testing.T) {
+	s := scheme.Scheme
+	hivev1.AddToScheme(s)
+	client := clientfake.NewClientBuilder().WithRuntimeObjects(getMachinePool(5)).WithScheme(s).Build()
+
+	assert.Nil(t, MonitorScaleStatus(client, ClusterName, getScaleClusterCurator()),
+		"err Nil when the MachinePool has reached the desired replicas")
+}
+
+func TestMonitorScaleStatusNotReady(t *testing.T) {
+	s := scheme.Scheme
+	hivev1.AddToScheme(s)
+	client := clientfake.NewClientBuilder().WithRuntimeObjects(getMachinePool(3)).WithScheme(s).Build()
+
+	ready, message, err := areMachinePoolsScaled(client, ClusterName, getScaleClusterCurator().Spec.Scale.MachinePools)
+	assert.Nil(t, err)
+	assert.False(t, ready, "MachinePool is still scaling")
+	assert.Contains(t, message, "3/5")
+}
@@ -396,7 +396,7 @@ func UpgradeCluster(
 
 	// Handle version upgrade (if desiredUpdate is specified)
 	if desiredUpdate != "" {
-		image := "quay.io/openshift-release-dev/ocp-release:" + desiredUpdate + "-multi"
+		image := getReleaseImage(desiredUpdate)
 
 		// Upgrade control plane (HostedCluster) if upgradeType is ControlPlane or empty (default)
 		if upgradeType == clustercuratorv1.UpgradeTypeControlPlane || upgradeType == "" {
@@ -440,6 +440,28 @@ func UpgradeCluster(
 	return nil
 }
 
+// DryRunUpgrade runs the upgrade validation of UpgradeCluster without patching the HostedCluster
+// or NodePools, and returns the release image the upgrade would use
+func DryRunUpgrade(
+	client clientv1.Client,
+	dc dynamic.Interface,
+	clusterName string,
+	curator *clustercuratorv1.ClusterCurator) (string, error) {
+
+	desiredUpdate := curator.Spec.Upgrade.DesiredUpdate
+	if err := validateUpgradeVersion(client, dc, clusterName, curator, desiredUpdate, curator.Spec.Upgrade.Channel); err != nil {
+		return "", err
+	}
+	if desiredUpdate == "" {
+		return "", nil
+	}
+	return getReleaseImage(desiredUpdate), nil
+}
+
+func getReleaseImage(desiredUpdate string) string {
+	return "quay.io/openshift-release-dev/ocp-release:" + desiredUpdate + "-multi"
+}
+
 func MonitorUpgradeStatus(
 	dc dynamic.Interface,
 	client clientv1.Client,
@@ -687,6 +709,129 @@ func areNodePoolsReady(
 	return true, nil
 }
 
+func ScaleCluster(dc dynamic.Interface, clusterName string, curator *clustercuratorv1.ClusterCurator) error {
+	klog.V(0).Info("* Initiate Hypershift Scale")
+
+	targets := curator.Spec.Scale.NodePools
+	if len(targets) == 0 {
+		return errors.New("Provide at least one NodePool in spec.scale.nodePools")
+	}
+
+	for _, target := range targets {
+		klog.V(2).Info("Looking up nodepool " + curator.Namespace + "/" + target.Name)
+		np, err := dc.Resource(utils.NPGVR).Namespace(curator.Namespace).Get(
+			context.TODO(), target.Name, v1.GetOptions{})
+		if err != nil {
+			return err
+		}
+
+		npClusterName, _, _ := unstructured.NestedString(np.Object, "spec", "clusterName")
+		if npClusterName != clusterName {
+			return errors.New("NodePool " + target.Name + " does not belong to HostedCluster " + clusterName)
+		}
+		if _, found, _ := unstructured.NestedMap(np.Object, "spec", "autoScaling"); found {
+			return errors.New("NodePool " + target.Name + " uses autoScaling and cannot be scaled to a fixed replica count")
+		}
+
+		if err := patchReplicas(dc, target.Name, curator.Namespace, target.Replicas); err != nil {
+			return err
+		}
+		klog.V(0).Infof("Scaled NodePool %v to %v replicas ✓", target.Name, target.Replicas)
+	}
+
+	return nil
+}
+
+func MonitorScaleStatus(
+	dc dynamic.Interface,
+	client clientv1.Client,
+	clusterName string,
+	curator *clustercuratorv1.ClusterCurator) error {
+	scaleAttempts := utils.GetRetryTimes(curator.Spec.Scale.MonitorTimeout, 30, utils.PauseTenSeconds)
+	klog.V(0).Info("Monitoring up to " + strconv.Itoa(scaleAttempts) + " attempts for NodePool scaling")
+
+	for i := 0; i < scaleAttempts; i++ {
+		ready, message, err := areNodePoolsScaled(dc, curator.Namespace, curator.Spec.Scale.NodePools)
+		if err != nil {
+			return err
+		}
+
+		if ready {
+			klog.V(2).Info("Scale succeeded ✓")
+			return nil
+		}
+
+		if i%6 == 0 {
+			klog.V(0).Info("Scale status - " + message)
+			utils.CheckError(utils.RecordCurrentStatusCondition(
+				client,
+				clusterName,
+				curator.Namespace,
+				"monitor-scale",
+				v1.ConditionFalse,
+				"Scale status - "+message))
+		}
+		time.Sleep(utils.PauseTenSeconds) // 10s
+	}
+
+	return errors.New("Timed out waiting for NodePools to scale")
+}
+
+// areNodePoolsScaled reports whether every target NodePool has reached its desired
+// replica count. When a NodePool is not ready yet, the returned message describes
+// the first NodePool that is still scaling.
+func areNodePoolsScaled(
+	dc dynamic.Interface,
+	namespace string,
+	targets []clustercuratorv1.ScaleTarget) (bool, string, error) {
+
+	for _, target := range targets {
+		np, err := dc.Resource(utils.NPGVR).Namespace(namespace).Get(context.TODO(), target.Name, v1.GetOptions{})
+		if err != nil {
+			return false, "", err
+		}
+
+		replicas, _, _ := unstructured.NestedInt64(np.Object, "status", "replicas")
+		if replicas != int64(target.Replicas) {
+			return false, fmt.Sprintf("NodePool %v has %v/%v replicas", target.Name, replicas, target.Replicas), nil
+		}
+		klog.V(4).Infof("NodePool %v is at %v replicas", target.Name, target.Replicas)
+	}
+
+	return true, "", nil
+}
+
+func patchReplicas(
+	dc dynamic.Interface,
+	nodePoolName string,
+	namespace string,
+	replicas int32) error {
+	err := retry.RetryOnConflict(retry.DefaultBackoff, func() error {
+		// A merge patch sets spec.replicas even when the NodePool does not define it yet
+		patch := map[string]interface{}{
+			"spec": map[string]interface{}{
+				"replicas": replicas,
+			},
+		}
+
+		patchInBytes, err := json.Marshal(patch)
+		if err != nil {
+			return err
+		}
+
+		klog.V(2).Infof("Patching NodePool %v replicas in namespace %v ✓", nodePoolName, namespace)
+		_, err = dc.Resource(utils.NPGVR).Namespace(namespace).Patch(
+			context.TODO(), nodePoolName, types.MergePatchType, patchInBytes, v1.PatchOptions{})
+		if err != nil {
+			return err
+		}
+		klog.V(2).Info("Updated NodePool " + nodePoolName + " replicas ✓")
+		return nil
+	})
+
+	return err
+}
+
 func patchUpgradeVersion(
 	dc dynamic.Interface,
 	clusterName string,
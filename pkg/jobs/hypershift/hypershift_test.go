@@ -2331,3 +2331,117 @@ func TestGetHostedClusterVersionHistoryNoVersion(t *testing.T) {
 	assert.Nil(t, err, "should not return error - fallback to desired")
 	assert.Equal(t, "4.14.0", version, "should return desired.version as fallback")
 }
+
+func getScaleClusterCurator(replicas int32) *clustercuratorv1.ClusterCurator {
+	return &clustercuratorv1.ClusterCurator{
+		ObjectMeta: v1.ObjectMeta{
+			Name:      ClusterName,
+			Namespace: ClusterNamespace,
+		},
+		Spec: clustercuratorv1.ClusterCuratorSpec{
+			DesiredCuration: "scale",
+			Scale: clustercuratorv1.ScaleHooks{
+				NodePools: []clustercuratorv1.ScaleTarget{
+					{
+						Name:     NodepoolName,
+						Replicas: replicas,
+					},
+				},
+			},
+		},
+	}
+}
+
+func getNodepoolWithReplicas(npClusterName string, replicas int64) *unstructured.Unstructured {
+	np := getNodepool(NodepoolName, ClusterNamespace, npClusterName)
+	np.Object["spec"].(map[string]interface{})["replicas"] = replicas
+	np.Object["status"] = map[string]interface{}{
+		"replicas": replicas,
+	}
+	return np
+}
+
+func TestScaleClusterNodePools(t *testing.T) {
+	dynfake := dynfake.NewSimpleDynamicClient(runtime.NewScheme(), getNodepoolWithReplicas(ClusterName, 2))
+
+	assert.Nil(t, ScaleCluster(dynfake, ClusterName, getScaleClusterCurator(4)),
+		"err is nil, when the NodePool is scaled")
+
+	np, err := dynfake.Resource(utils.NPGVR).Namespace(ClusterNamespace).Get(context.TODO(), NodepoolName, v1.GetOptions{})
+	assert.Nil(t, err, "should be able to get NodePool")
+	replicas, _, _ := unstructured.NestedInt64(np.Object, "spec", "replicas")
+	assert.Equal(t, int64(4), replicas, "NodePool spec.replicas should be updated")
+}
+
+func TestScaleClusterNoNodePools(t *testing.T) {
+	dynfake := dynfake.NewSimpleDynamicClient(runtime.NewScheme())
+
+	clusterCurator := getScaleClusterCurator(4)
+	clusterCurator.Spec.Scale.NodePools = nil
+
+	assert.NotNil(t, ScaleCluster(dynfake, ClusterName, clusterCurator),
+		"err is not nil, when no NodePools are listed")
+}
+
+func TestScaleClusterNodePoolOtherCluster(t *testing.T) {
+	dynfake := dynfake.NewSimpleDynamicClient(runtime.NewScheme(), getNodepoolWithReplicas("other-cluster", 2))
+
+	assert.NotNil(t, ScaleCluster(dynfake, ClusterName, getScaleClusterCurator(4)),
+		"err is not nil, when the NodePool belongs to another HostedCluster")
+}
+
+func TestScaleClusterNodePoolAutoScaling(t *testing.T) {
+	np := getNodepool(NodepoolName, ClusterNamespace, ClusterName)
+	np.Object["spec"].(map[string]interface{})["autoScaling"] = map[string]interface{}{
+		"min": int64(1),
+		"max": int64(5),
+	}
+	dynfake := dynfake.NewSimpleDynamicClient(runtime.NewScheme(), np)
+
+	err := ScaleCluster(dynfake, ClusterName, getScaleClusterCurator(4))
+	assert.NotNil(t, err, "err is not nil, when the NodePool uses autoScaling")
+	assert.Contains(t, err.Error(), "autoScaling")
+}
+
+func TestMonitorScaleStatusNodePools(t *testing.T) {
+	clusterCurator := getScaleClusterCurator(4)
+	dynfake := dynfake.NewSimpleDynamicClient(runtime.NewScheme(), getNodepoolWithReplicas(ClusterName, 4))
+	s.AddKnownTypes(clustercuratorv1.SchemeBuilder.GroupVersion, &clustercuratorv1.ClusterCurator{})
+	client := clientfake.NewClientBuilder().WithScheme(s).WithRuntimeObjects(clusterCurator).Build()
+
+	assert.Nil(t, MonitorScaleStatus(dynfake, client, ClusterName, clusterCurator),
+		"err is nil, when the NodePool has reached the desired replicas")
+}
+
+func TestAreNodePoolsScaledNotReady(t *testing.T) {
+	dynfake := dynfake.NewSimpleDynamicClient(runtime.NewScheme(), getNodepoolWithReplicas(ClusterName, 2))
+
+	ready, message, err := areNodePoolsScaled(dynfake, ClusterNamespace, getScaleClusterCurator(4).Spec.Scale.NodePools)
+	assert.Nil(t, err)
+	assert.False(t, ready, "NodePool is still scaling")
+	assert.Contains(t, message, "2/4")
+}
+
+func TestDryRunUpgrade(t *testing.T) {
+	clusterCurator := getUpgradeClusterCurator("4.13.7")
+	managedClusterInfo := getManagedClusterInfo()
+	dynfake := dynfake.NewSimpleDynamicClient(
+		runtime.NewScheme(),
+		getHostedCluster("AWS", []interface{}{}),
+		getNodepool(NodepoolName, ClusterNamespace, ClusterName),
+	)
+	s.AddKnownTypes(clustercuratorv1.SchemeBuilder.GroupVersion, &clustercuratorv1.ClusterCurator{})
+	s.AddKnownTypes(managedclusterinfov1beta1.SchemeGroupVersion, &managedclusterinfov1beta1.ManagedClusterInfo{})
+	client := clientfake.NewClientBuilder().WithScheme(s).WithRuntimeObjects(clusterCurator, managedClusterInfo).Build()
+
+	releaseImage, err := DryRunUpgrade(client, dynfake, ClusterName, clusterCurator)
+	assert.Nil(t, err, "err is nil, when the upgrade is valid")
+	assert.Equal(t, "quay.io/openshift-release-dev/ocp-release:4.13.7-multi", releaseImage)
+
+	for _, action := range dynfake.Actions() {
+		assert.Contains(t, []string{"get", "list", "watch"}, action.GetVerb(), "the dry run does not change the cluster")
+	}
+
+	_, err = DryRunUpgrade(client, dynfake, ClusterName, getUpgradeClusterCurator("4.13.6"))
+	assert.Equal(t, utils.ErrAlreadyAtVersion, err, "the cluster is already at the desired version")
+}
@@ -11,6 +11,7 @@ import (
 
 	corev1 "k8s.io/api/core/v1"
 	rbacv1 "k8s.io/api/rbac/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
 	k8serrors "k8s.io/apimachinery/pkg/api/errors"
 	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/client-go/kubernetes"
@@ -27,11 +28,36 @@ func getRole(clusterName string) *rbacv1.Role {
 				Resources: []string{"ansiblejobs", "secrets", "serviceaccounts"},
 				Verbs:     []string{"create"},
 			},
+			// To run Container hooks and remove their Jobs. The admission webhook rejects the hook images
+			// the HOOK_IMAGE_ALLOWLIST does not allow, and the curator checks them again before it runs one
+			rbacv1.PolicyRule{
+				APIGroups: []string{"batch"},
+				Resources: []string{"jobs"},
+				Verbs:     []string{"create", "delete"},
+			},
+			// To resume the AnsibleJob of a hook after the curator pod restarted, and to delete the
+			// AnsibleJob of a timed out hook attempt
+			rbacv1.PolicyRule{
+				APIGroups: []string{"tower.ansible.com"},
+				Resources: []string{"ansiblejobs"},
+				Verbs:     []string{"list", "delete"},
+			},
+			// To record the runner pod logs of a failed AnsibleJob in status.lastFailure
+			rbacv1.PolicyRule{
+				APIGroups: []string{""},
+				Resources: []string{"pods", "pods/log"},
+				Verbs:     []string{"list", "get"},
+			},
 			rbacv1.PolicyRule{
 				APIGroups: []string{"hive.openshift.io"},
 				Resources: []string{"clusterdeployments"},
 				Verbs:     []string{"patch", "delete", "update"},
 			},
+			rbacv1.PolicyRule{
+				APIGroups: []string{"hive.openshift.io"},
+				Resources: []string{"machinepools"},
+				Verbs:     []string{"patch", "update"},
+			},
 			rbacv1.PolicyRule{
 				APIGroups: []string{"hypershift.openshift.io"},
 				Resources: []string{"hostedclusters", "nodepools"},
@@ -88,11 +114,36 @@ func getClusterRole(clusterName string) *rbacv1.ClusterRole {
 				Resources: []string{"ansiblejobs", "secrets", "serviceaccounts"},
 				Verbs:     []string{"create"},
 			},
+			// To run Container hooks and remove their Jobs. The admission webhook rejects the hook images
+			// the HOOK_IMAGE_ALLOWLIST does not allow, and the curator checks them again before it runs one
+			rbacv1.PolicyRule{
+				APIGroups: []string{"batch"},
+				Resources: []string{"jobs"},
+				Verbs:     []string{"create", "delete"},
+			},
+			// To resume the AnsibleJob of a hook after the curator pod restarted, and to delete the
+			// AnsibleJob of a timed out hook attempt
+			rbacv1.PolicyRule{
+				APIGroups: []string{"tower.ansible.com"},
+				Resources: []string{"ansiblejobs"},
+				Verbs:     []string{"list", "delete"},
+			},
+			// To record the runner pod logs of a failed AnsibleJob in status.lastFailure
+			rbacv1.PolicyRule{
+				APIGroups: []string{""},
+				Resources: []string{"pods", "pods/log"},
+				Verbs:     []string{"list", "get"},
+			},
 			rbacv1.PolicyRule{
 				APIGroups: []string{"hive.openshift.io"},
 				Resources: []string{"clusterdeployments"},
 				Verbs:     []string{"patch", "delete", "update"},
 			},
+			rbacv1.PolicyRule{
+				APIGroups: []string{"hive.openshift.io"},
+				Resources: []string{"machinepools"},
+				Verbs:     []string{"patch", "update"},
+			},
 			rbacv1.PolicyRule{
 				APIGroups: []string{"hypershift.openshift.io"},
 				Resources: []string{"hostedclusters", "nodepools"},
@@ -244,6 +295,33 @@ func getServiceAccount() *corev1.ServiceAccount {
 	return serviceAccount
 }
 
+// applyClusterRole creates the ClusterRole, or updates its rules when they differ from the desired
+// ones, so a curator upgrade grants the rules its hooks need to the clusters curated before
+func applyClusterRole(kubeset kubernetes.Interface, clusterRole *rbacv1.ClusterRole) error {
+
+	klog.V(2).Info("Check if ClusterRole " + clusterRole.Name + " exists")
+	existing, err := kubeset.RbacV1().ClusterRoles().Get(context.TODO(), clusterRole.Name, v1.GetOptions{})
+	if k8serrors.IsNotFound(err) {
+		klog.V(2).Info(" Creating ClusterRole " + clusterRole.Name)
+		_, err = kubeset.RbacV1().ClusterRoles().Create(context.TODO(), clusterRole, v1.CreateOptions{})
+		if err != nil {
+			return err
+		}
+		klog.V(0).Info(" Created ClusterRole " + clusterRole.Name + " ✓")
+	} else if err != nil {
+		return err
+	} else if !equality.Semantic.DeepEqual(existing.Rules, clusterRole.Rules) {
+		klog.V(2).Info(" Updating the rules of ClusterRole " + clusterRole.Name)
+		existing.Rules = clusterRole.Rules
+		_, err = kubeset.RbacV1().ClusterRoles().Update(context.TODO(), existing, v1.UpdateOptions{})
+		if err != nil {
+			return err
+		}
+		klog.V(0).Info(" Updated ClusterRole " + clusterRole.Name + " ✓")
+	}
+	return nil
+}
+
 func ApplyRBAC(kubeset kubernetes.Interface, namespace string) error {
 
 	klog.V(2).Info("Check if serviceAccount cluster-installer exists")
@@ -260,14 +338,8 @@ func ApplyRBAC(kubeset kubernetes.Interface, namespace string) error {
 		klog.V(0).Info(" Created serviceAccount ✓")
 	}
 
-	klog.V(2).Info("Check if ClusterRole curator exists")
-	if _, err := kubeset.RbacV1().ClusterRoles().Get(context.TODO(), "curator", v1.GetOptions{}); err != nil {
-		klog.V(2).Info(" Creating ClusterRole curator")
-		_, err = kubeset.RbacV1().ClusterRoles().Create(context.TODO(), getClusterRole(namespace), v1.CreateOptions{})
-		if err != nil {
-			return err
-		}
-		klog.V(0).Info(" Created ClusterRole ✓")
+	if err := applyClusterRole(kubeset, getClusterRole(namespace)); err != nil {
+		return err
 	}
 
 	klog.V(2).Info("Check if RoleBinding cluster-installer exists")
@@ -298,17 +370,7 @@ func ApplyRBACHypershift(kubeset kubernetes.Interface, namespace string, curator
 		return err
 	}
 
-	klog.V(2).Info("Check if ClusterRole curator-cluster-scoped exists")
-	if _, err = kubeset.RbacV1().ClusterRoles().Get(
-		context.TODO(), "curator-cluster-scoped", v1.GetOptions{}); k8serrors.IsNotFound(err) {
-		klog.V(2).Info(" Creating ClusterRole curator-cluster-scoped")
-		_, err = kubeset.RbacV1().ClusterRoles().Create(
-			context.TODO(), getClusterScopedRole(), v1.CreateOptions{})
-		if err != nil {
-			return err
-		}
-		klog.V(0).Info(" Created ClusterRole curator-cluster-scoped ✓")
-	} else if err != nil {
+	if err = applyClusterRole(kubeset, getClusterScopedRole()); err != nil {
 		return err
 	}
 
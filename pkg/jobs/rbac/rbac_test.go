@@ -23,11 +23,36 @@ func getRules(clusterName string) []rbacv1.PolicyRule {
 			Resources: []string{"ansiblejobs", "secrets", "serviceaccounts"},
 			Verbs:     []string{"create"},
 		},
+		// To run Container hooks and remove their Jobs. The admission webhook rejects the hook images
+		// the HOOK_IMAGE_ALLOWLIST does not allow, and the curator checks them again before it runs one
+		rbacv1.PolicyRule{
+			APIGroups: []string{"batch"},
+			Resources: []string{"jobs"},
+			Verbs:     []string{"create", "delete"},
+		},
+		// To resume the AnsibleJob of a hook after the curator pod restarted, and to delete the
+		// AnsibleJob of a timed out hook attempt
+		rbacv1.PolicyRule{
+			APIGroups: []string{"tower.ansible.com"},
+			Resources: []string{"ansiblejobs"},
+			Verbs:     []string{"list", "delete"},
+		},
+		// To record the runner pod logs of a failed AnsibleJob in status.lastFailure
+		rbacv1.PolicyRule{
+			APIGroups: []string{""},
+			Resources: []string{"pods", "pods/log"},
+			Verbs:     []string{"list", "get"},
+		},
 		rbacv1.PolicyRule{
 			APIGroups: []string{"hive.openshift.io"},
 			Resources: []string{"clusterdeployments"},
 			Verbs:     []string{"patch", "delete", "update"},
 		},
+		rbacv1.PolicyRule{
+			APIGroups: []string{"hive.openshift.io"},
+			Resources: []string{"machinepools"},
+			Verbs:     []string{"patch", "update"},
+		},
 		rbacv1.PolicyRule{
 			APIGroups: []string{"hypershift.openshift.io"},
 			Resources: []string{"hostedclusters", "nodepools"},
@@ -176,6 +201,27 @@ func TestExtendClusterInstallerRole(t *testing.T) {
 	assert.ElementsMatch(t, role.Rules, getCombinedCIRules(), "Rules should be equal")
 }
 
+func TestApplyRBACUpdatesRules(t *testing.T) {
+
+	staleRole := getClusterRole(ClusterName)
+	staleRole.Rules = staleRole.Rules[:1]
+	staleScopedRole := getClusterScopedRole()
+	staleScopedRole.Rules[0].Verbs = []string{"get"}
+	kubeset := fake.NewSimpleClientset(staleRole, staleScopedRole)
+
+	assert.Nil(t, ApplyRBAC(kubeset, ClusterName), "err nil, when the ClusterRole is updated")
+
+	role, err := kubeset.RbacV1().ClusterRoles().Get(context.TODO(), "curator", v1.GetOptions{})
+	assert.Nil(t, err)
+	assert.ElementsMatch(t, getRules(ClusterName), role.Rules, "the missing rules are added")
+
+	assert.Nil(t, ApplyRBACHypershift(kubeset, ClusterName, ClusterName))
+
+	role, err = kubeset.RbacV1().ClusterRoles().Get(context.TODO(), "curator-cluster-scoped", v1.GetOptions{})
+	assert.Nil(t, err)
+	assert.Equal(t, getClusterScopedRole().Rules, role.Rules, "the changed rule is updated")
+}
+
 func TestExtendClusterInstallerRoleTimeout(t *testing.T) {
 
 	kubeset := fake.NewSimpleClientset()
@@ -2,12 +2,14 @@
 package utils
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
 	"errors"
 	"flag"
 	"fmt"
 	"os"
+	"sort"
 	"strconv"
 	"strings"
 	"time"
@@ -30,11 +32,13 @@ import (
 	k8serrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/client-go/dynamic"
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/rest"
 	"k8s.io/client-go/tools/clientcmd"
+	"k8s.io/client-go/util/retry"
 	clientv1 "sigs.k8s.io/controller-runtime/pkg/client"
 )
 
@@ -48,8 +52,16 @@ const CurrentCuratorContainer = "curating-with-container"
 const CurrentCuratorJob = "curatorJob"
 const DefaultImageURI = "registry.ci.openshift.org/open-cluster-management/cluster-curator-controller:latest"
 
+// HookImageAllowlistEnv is the controller environment variable with the comma-separated images
+// Container hooks may run, it is passed on to the curator Job
+const HookImageAllowlistEnv = "HOOK_IMAGE_ALLOWLIST"
+
 const JobHasFinished = "Job_has_finished"
 const JobFailed = "Job_failed"
+const JobCancelled = "Job_cancelled"
+
+// CuratorJobCondition is the condition type tracking the curator Job as a whole
+const CuratorJobCondition = "clustercurator-job"
 
 var ErrAlreadyAtVersion = errors.New("cluster is already at the desired version")
 
@@ -160,6 +172,50 @@ func RecordCuratorJobName(
 	return client.Update(context.Background(), cc)
 }
 
+// RecordCuratorJobStarted sets the curator Job name and resets the status phase, timestamps and
+// steps for the new curator Job. When resuming, the steps that already succeeded are kept.
+func RecordCuratorJobStarted(
+	client clientv1.Client,
+	clusterName string,
+	clusterNamespace string,
+	curatorJobName string,
+	curationType string,
+	steps []string) error {
+	cc, err := GetClusterCurator(client, clusterName, clusterNamespace)
+	if err != nil {
+		return err
+	}
+
+	cc.Spec.CuratingJob = curatorJobName
+
+	// A resumed curation keeps the record of the steps it skipped
+	previousSteps := []clustercuratorv1.CurationStep{}
+	if cc.Operation != nil && cc.Operation.ResumeFailed {
+		for _, step := range cc.Status.Steps {
+			if step.State != clustercuratorv1.CurationPhaseSucceeded {
+				break
+			}
+			previousSteps = append(previousSteps, step)
+		}
+	}
+
+	now := v1.Now()
+	cc.Status.Phase = clustercuratorv1.CurationPhasePending
+	cc.Status.CurationType = curationType
+	cc.Status.JobName = curatorJobName
+	cc.Status.StartTime = &now
+	cc.Status.CompletionTime = nil
+	cc.Status.Steps = append(make([]clustercuratorv1.CurationStep, 0, len(previousSteps)+len(steps)), previousSteps...)
+	for _, step := range steps {
+		cc.Status.Steps = append(cc.Status.Steps, clustercuratorv1.CurationStep{
+			Name:  step,
+			State: clustercuratorv1.CurationPhasePending,
+		})
+	}
+
+	return client.Update(context.Background(), cc)
+}
+
 func patchDyn(dynset dynamic.Interface, clusterName string, containerName string, specKey string) error {
 
 	patch := []PatchStringValue{{
@@ -263,11 +319,6 @@ func recordCuratedStatusCondition(
 	reason string,
 	message string) error {
 
-	curator, err := GetClusterCurator(client, clusterName, clusterNamespace)
-	if err != nil {
-		return err
-	}
-
 	var newCondition = metav1.Condition{
 		Type:    containerName,
 		Status:  conditionStatus,
@@ -275,15 +326,99 @@ func recordCuratedStatusCondition(
 		Message: message,
 	}
 
-	meta.SetStatusCondition(&curator.Status.Conditions, newCondition)
+	// Hooks of a parallel group record their conditions at the same time
+	err := retry.RetryOnConflict(retry.DefaultBackoff, func() error {
+		curator, err := GetClusterCurator(client, clusterName, clusterNamespace)
+		if err != nil {
+			return err
+		}
+
+		meta.SetStatusCondition(&curator.Status.Conditions, newCondition)
+		updateCurationStatus(&curator.Status, containerName, conditionStatus, reason, message)
 
-	if err := client.Update(context.TODO(), curator); err != nil {
+		return client.Update(context.TODO(), curator)
+	})
+	if err != nil {
 		return err
 	}
 	klog.V(4).Infof("newCondition: %v", newCondition)
 	return nil
 }
 
+// updateCurationStatus moves the phase and the matching step forward based on a newly recorded
+// condition. Conditions that do not belong to the curator Job or one of its steps are ignored.
+func updateCurationStatus(
+	status *clustercuratorv1.ClusterCuratorStatus,
+	containerName string,
+	conditionStatus v1.ConditionStatus,
+	reason string,
+	message string) {
+
+	now := v1.Now()
+
+	if containerName == CuratorJobCondition {
+		switch {
+		case conditionStatus == v1.ConditionFalse:
+			if status.Phase == "" || status.Phase == clustercuratorv1.CurationPhasePending {
+				status.Phase = clustercuratorv1.CurationPhaseRunning
+			}
+			if status.StartTime == nil {
+				status.StartTime = &now
+			}
+		case reason == JobFailed:
+			status.Phase = clustercuratorv1.CurationPhaseFailed
+			status.CompletionTime = &now
+			// A step can fail without recording its own condition, e.g. on a CheckError panic
+			for i := range status.Steps {
+				if status.Steps[i].State == clustercuratorv1.CurationPhaseRunning {
+					status.Steps[i].State = clustercuratorv1.CurationPhaseFailed
+					status.Steps[i].CompletionTime = &now
+					status.Steps[i].FailureReason = message
+				}
+			}
+		case reason == JobCancelled:
+			status.Phase = clustercuratorv1.CurationPhaseCancelled
+			status.CompletionTime = &now
+			for i := range status.Steps {
+				if status.Steps[i].State == clustercuratorv1.CurationPhasePending ||
+					status.Steps[i].State == clustercuratorv1.CurationPhaseRunning {
+					status.Steps[i].State = clustercuratorv1.CurationPhaseCancelled
+					status.Steps[i].CompletionTime = &now
+				}
+			}
+		default:
+			status.Phase = clustercuratorv1.CurationPhaseSucceeded
+			status.CompletionTime = &now
+		}
+		return
+	}
+
+	for i := range status.Steps {
+		step := &status.Steps[i]
+		if step.Name != containerName {
+			continue
+		}
+		switch {
+		case conditionStatus == v1.ConditionFalse:
+			// Monitors record progress with repeated False conditions, keep the original start time
+			if step.State != clustercuratorv1.CurationPhaseRunning {
+				step.State = clustercuratorv1.CurationPhaseRunning
+				step.StartTime = &now
+				step.CompletionTime = nil
+				step.FailureReason = ""
+			}
+		case reason == JobFailed:
+			step.State = clustercuratorv1.CurationPhaseFailed
+			step.CompletionTime = &now
+			step.FailureReason = message
+		default:
+			step.State = clustercuratorv1.CurationPhaseSucceeded
+			step.CompletionTime = &now
+		}
+		return
+	}
+}
+
 func RecordFailedCuratorStatusCondition(
 	client clientv1.Client,
 	clusterName string,
@@ -302,6 +437,82 @@ func RecordFailedCuratorStatusCondition(
 		message)
 }
 
+// RecordCancelledCuration records the clustercurator-job condition as cancelled, marks the steps
+// that did not finish as Cancelled and removes the curator Job and the operation. As with a failed
+// upgrade, desiredCuration is kept for an upgrade so it is not retried until the settings change.
+func RecordCancelledCuration(
+	client clientv1.Client,
+	clusterName string,
+	clusterNamespace string) error {
+
+	curator, err := GetClusterCurator(client, clusterName, clusterNamespace)
+	if err != nil {
+		return err
+	}
+
+	curationType := curator.Status.CurationType
+	if curationType == "" {
+		curationType = curator.Spec.DesiredCuration
+	}
+
+	message := curator.Spec.CuratingJob + " DesiredCuration: " + curationType
+	if curationType == "upgrade" {
+		curator.Status.LastUpgrade = NewUpgradeRecord(curator, clustercuratorv1.CurationPhaseCancelled)
+	}
+	message = message + " Cancelled"
+
+	meta.SetStatusCondition(&curator.Status.Conditions, metav1.Condition{
+		Type:    CuratorJobCondition,
+		Status:  v1.ConditionTrue,
+		Reason:  JobCancelled,
+		Message: message,
+	})
+	updateCurationStatus(&curator.Status, CuratorJobCondition, v1.ConditionTrue, JobCancelled, message)
+
+	curator.Spec.CuratingJob = ""
+	if curator.Spec.DesiredCuration != "upgrade" {
+		curator.Spec.DesiredCuration = ""
+	}
+	curator.Operation = nil
+
+	return client.Update(context.TODO(), curator)
+}
+
+// GetEffectiveCuration returns the curation the curator Job runs: the retryPosthook operation when
+// one is requested, the curation in status.curationType when resuming it, otherwise
+// spec.desiredCuration
+func GetEffectiveCuration(curator *clustercuratorv1.ClusterCurator) string {
+	if curator.Operation != nil && curator.Operation.RetryPosthook != "" {
+		return curator.Operation.RetryPosthook
+	}
+	if curator.Operation != nil && curator.Operation.ResumeFailed && curator.Status.CurationType != "" {
+		return curator.Status.CurationType
+	}
+	return curator.Spec.DesiredCuration
+}
+
+// GetResumeStep returns the first step in status.steps that did not succeed, this is where a
+// resumed curation starts. An empty string means every step succeeded.
+func GetResumeStep(curator *clustercuratorv1.ClusterCurator) string {
+	for _, step := range curator.Status.Steps {
+		if step.State != clustercuratorv1.CurationPhaseSucceeded {
+			return step.Name
+		}
+	}
+	return ""
+}
+
+// IsStepSucceeded returns true when stepName succeeded in status.steps, a curator pod that is
+// started again in the same curator Job runs the steps it already ran
+func IsStepSucceeded(curator *clustercuratorv1.ClusterCurator, stepName string) bool {
+	for _, step := range curator.Status.Steps {
+		if step.Name == stepName {
+			return step.State == clustercuratorv1.CurationPhaseSucceeded
+		}
+	}
+	return false
+}
+
 func GetClusterCurator(
 	client clientv1.Client,
 	clusterName string,
@@ -403,8 +614,8 @@ func GetRetryTimes(timeout, defaultTimeout int, interval time.Duration) int {
 // controller's RBAC only grants "get" on managedclusterinfos (not "list"/"watch"), that
 // informer's initial sync fails and the background reflector retries (and logs) forever.
 func NeedToUpgrade(reader clientv1.Reader, curator clustercuratorv1.ClusterCurator) (bool, error) {
-	jobCondtion := meta.FindStatusCondition(curator.Status.Conditions, "clustercurator-job")
-	if jobCondtion == nil {
+	jobCondtion := meta.FindStatusCondition(curator.Status.Conditions, CuratorJobCondition)
+	if jobCondtion == nil && curator.Status.LastUpgrade == nil {
 		klog.V(2).Info(fmt.Sprintf("No ClusterCuratorJob for curator %q", curator.Name))
 
 		if reader != nil && curator.Spec.Upgrade.DesiredUpdate != "" {
@@ -425,45 +636,33 @@ func NeedToUpgrade(reader clientv1.Reader, curator clustercuratorv1.ClusterCurat
 		return true, nil
 	}
 
-	if jobCondtion.Status == metav1.ConditionFalse {
+	if jobCondtion != nil && jobCondtion.Status == metav1.ConditionFalse {
 		// job is not done, do nothing
 		klog.V(2).Info(fmt.Sprintf("The ClusterCuratorJob of the curator %q is not done, do nothing", curator.Name))
 		return false, nil
 	}
 
-	if !strings.Contains(jobCondtion.Message, "upgrade") {
-		klog.V(2).Info(fmt.Sprintf("Previous curator %q is not for upgrade, %q)", curator.Name, jobCondtion.Message))
+	if !lastCurationWasUpgrade(curator, jobCondtion) {
+		klog.V(2).Info(fmt.Sprintf("Previous curator %q is not for upgrade", curator.Name))
 		// last job is not for upgrade, run the upgrade
 		return true, nil
 	}
 
-	if strings.Contains(jobCondtion.Message, "Failed") {
-		klog.V(2).Info(fmt.Sprintf("Previous curator %q is failed, %q", curator.Name, jobCondtion.Message))
-
-		// Check if the basic version info matches (for backward compatibility with old messages)
-		basicVersionInfo := fmt.Sprintf("%s;%s;%s", curator.Spec.Upgrade.DesiredUpdate, curator.Spec.Upgrade.Channel, curator.Spec.Upgrade.Upstream)
-		if strings.Contains(jobCondtion.Message, basicVersionInfo) {
-			// Also check if upgradeType and nodePoolNames changed (for new messages)
-			currentUpgradeType, currentNodePoolNames := parseExtendedVersionInfo(jobCondtion.Message)
-			desiredNodePoolNames := strings.Join(curator.Spec.Upgrade.NodePoolNames, ",")
-
-			if string(curator.Spec.Upgrade.UpgradeType) == currentUpgradeType && desiredNodePoolNames == currentNodePoolNames {
-				// last job failed and all upgrade params are unchanged, do nothing
-				klog.V(2).Info(fmt.Sprintf("last job failed and desired version is unchanged, do not need to upgrade"))
-				return false, nil
-			}
-			// upgradeType or nodePoolNames changed, need to upgrade
-			klog.V(2).Info(fmt.Sprintf("last job failed but upgradeType or nodePoolNames changed, need to upgrade"))
-			return true, nil
-		}
+	lastUpgrade, lastUpgradeErr := GetLastUpgrade(curator)
+	if lastUpgradeErr == nil && (lastUpgrade.Result == clustercuratorv1.CurationPhaseFailed ||
+		lastUpgrade.Result == clustercuratorv1.CurationPhaseCancelled) {
+		klog.V(2).Info(fmt.Sprintf("Previous curator %q is %s", curator.Name, strings.ToLower(string(lastUpgrade.Result))))
 
-		// To be compatible with 2.2.0 version
-		if strings.Contains(jobCondtion.Message, fmt.Sprintf("Version (%s)", curator.Spec.Upgrade.DesiredUpdate)) {
+		if lastUpgrade.DesiredUpdate == curator.Spec.Upgrade.DesiredUpdate &&
+			lastUpgrade.Channel == curator.Spec.Upgrade.Channel &&
+			lastUpgrade.Upstream == curator.Spec.Upgrade.Upstream &&
+			sameHostedUpgrade(curator, lastUpgrade) {
+			// last job failed or was cancelled and all upgrade params are unchanged, do nothing
 			klog.V(2).Info(fmt.Sprintf("last job failed and desired version is unchanged, do not need to upgrade"))
 			return false, nil
 		}
 
-		// last job failed and desired version is changed, upgrade
+		// last job failed or was cancelled and the upgrade params are changed, upgrade
 		return true, nil
 	}
 
@@ -477,14 +676,25 @@ func NeedToUpgrade(reader clientv1.Reader, curator clustercuratorv1.ClusterCurat
 		return false, err
 	}
 
-	currentChannel, currentUpstream, currentVersion, err := parseVersionInfo(jobCondtion.Message)
+	if lastUpgradeErr != nil {
+		klog.V(2).Info(fmt.Sprintf("Previous curator has a wrong clustercurator-job condition message, %v", lastUpgradeErr))
+		return true, nil
+	}
+
+	lastDesiredUpdate := lastUpgrade.DesiredUpdate
+	// there are only channel or upstream
+	if lastDesiredUpdate == "" {
+		lastDesiredUpdate = "0.0.0"
+	}
+
+	currentVersion, err := semver.Make(lastDesiredUpdate)
 	if err != nil {
-		klog.V(2).Info(fmt.Sprintf("Previous curator has a wrong clustercurator-job condition message, %v", err))
+		klog.V(2).Info(fmt.Sprintf("Previous curator has a wrong upgrade version %q, %v", lastDesiredUpdate, err))
 		return true, nil
 	}
 
-	klog.V(2).Info(fmt.Sprintf("Curator %q channel, current=%v desired=%v", curator.Name, currentChannel, curator.Spec.Upgrade.Channel))
-	klog.V(2).Info(fmt.Sprintf("Curator %q upstream, current=%v desired=%v", curator.Name, currentUpstream, curator.Spec.Upgrade.Upstream))
+	klog.V(2).Info(fmt.Sprintf("Curator %q channel, current=%v desired=%v", curator.Name, lastUpgrade.Channel, curator.Spec.Upgrade.Channel))
+	klog.V(2).Info(fmt.Sprintf("Curator %q upstream, current=%v desired=%v", curator.Name, lastUpgrade.Upstream, curator.Spec.Upgrade.Upstream))
 	klog.V(2).Info(fmt.Sprintf("Curator %q version, current=%v desired=%v", curator.Name, currentVersion, desiredVersion))
 
 	if desiredVersion.Compare(currentVersion) == 1 {
@@ -492,26 +702,19 @@ func NeedToUpgrade(reader clientv1.Reader, curator clustercuratorv1.ClusterCurat
 		return true, nil
 	}
 
-	if curator.Spec.Upgrade.Channel != "" && curator.Spec.Upgrade.Channel != currentChannel {
+	if curator.Spec.Upgrade.Channel != "" && curator.Spec.Upgrade.Channel != lastUpgrade.Channel {
 		return true, nil
 	}
 
-	if curator.Spec.Upgrade.Upstream != "" && curator.Spec.Upgrade.Upstream != currentUpstream {
+	if curator.Spec.Upgrade.Upstream != "" && curator.Spec.Upgrade.Upstream != lastUpgrade.Upstream {
 		return true, nil
 	}
 
 	// Check if upgradeType or nodePoolNames changed (for hosted cluster upgrades)
-	// Parse the extended version info if present in the message
-	currentUpgradeType, currentNodePoolNames := parseExtendedVersionInfo(jobCondtion.Message)
-
-	if string(curator.Spec.Upgrade.UpgradeType) != currentUpgradeType {
-		klog.V(2).Info(fmt.Sprintf("Curator %q upgradeType changed, current=%v desired=%v", curator.Name, currentUpgradeType, curator.Spec.Upgrade.UpgradeType))
-		return true, nil
-	}
-
-	desiredNodePoolNames := strings.Join(curator.Spec.Upgrade.NodePoolNames, ",")
-	if desiredNodePoolNames != currentNodePoolNames {
-		klog.V(2).Info(fmt.Sprintf("Curator %q nodePoolNames changed, current=%v desired=%v", curator.Name, currentNodePoolNames, desiredNodePoolNames))
+	if !sameHostedUpgrade(curator, lastUpgrade) {
+		klog.V(2).Info(fmt.Sprintf("Curator %q upgradeType or nodePoolNames changed, current=%v;%v desired=%v;%v",
+			curator.Name, lastUpgrade.UpgradeType, lastUpgrade.NodePoolNames,
+			curator.Spec.Upgrade.UpgradeType, curator.Spec.Upgrade.NodePoolNames))
 		return true, nil
 	}
 
@@ -519,68 +722,337 @@ func NeedToUpgrade(reader clientv1.Reader, curator clustercuratorv1.ClusterCurat
 	return false, nil
 }
 
-func GetCurrentVersionInfo(curator *clustercuratorv1.ClusterCurator) string {
-	nodePoolNames := strings.Join(curator.Spec.Upgrade.NodePoolNames, ",")
-	return fmt.Sprintf("%s;%s;%s;%s;%s", curator.Spec.Upgrade.DesiredUpdate, curator.Spec.Upgrade.Channel, curator.Spec.Upgrade.Upstream, curator.Spec.Upgrade.UpgradeType, nodePoolNames)
+// lastCurationWasUpgrade reports whether the most recent curator Job ran an upgrade. Curators
+// without status.curationType fall back to the clustercurator-job condition message.
+func lastCurationWasUpgrade(curator clustercuratorv1.ClusterCurator, jobCondition *metav1.Condition) bool {
+	if curator.Status.CurationType != "" {
+		return curator.Status.CurationType == "upgrade" || curator.Status.CurationType == "upgradePosthook"
+	}
+	if jobCondition != nil {
+		return strings.Contains(jobCondition.Message, "upgrade")
+	}
+	return curator.Status.LastUpgrade != nil
 }
 
-// parseExtendedVersionInfo extracts upgradeType and nodePoolNames from the condition message
-// Message format: "... Version (desiredUpdate;channel;upstream;upgradeType;nodePoolNames)"
-// For backward compatibility, returns empty strings if the extended fields are not present
-func parseExtendedVersionInfo(msg string) (upgradeType string, nodePoolNames string) {
-	index := strings.Index(msg, "(")
-	if index == -1 {
-		return "", ""
+func sameHostedUpgrade(curator clustercuratorv1.ClusterCurator, lastUpgrade *clustercuratorv1.UpgradeRecord) bool {
+	return curator.Spec.Upgrade.UpgradeType == lastUpgrade.UpgradeType &&
+		strings.Join(curator.Spec.Upgrade.NodePoolNames, ",") == strings.Join(lastUpgrade.NodePoolNames, ",")
+}
+
+// GetLastUpgrade returns status.lastUpgrade. Curators upgraded by an older curator only record the
+// upgrade in the clustercurator-job condition message, so it is read from there when missing.
+func GetLastUpgrade(curator clustercuratorv1.ClusterCurator) (*clustercuratorv1.UpgradeRecord, error) {
+	if curator.Status.LastUpgrade != nil {
+		return curator.Status.LastUpgrade, nil
 	}
-	versionInfo := msg[index:]
-	lastIndex := strings.Index(versionInfo, ")")
-	if lastIndex == -1 {
-		return "", ""
+
+	jobCondition := meta.FindStatusCondition(curator.Status.Conditions, CuratorJobCondition)
+	if jobCondition == nil {
+		return nil, fmt.Errorf("no upgrade recorded for curator %q", curator.Name)
 	}
+	return parseUpgradeRecord(*jobCondition, curator.Spec.Upgrade)
+}
 
-	infos := strings.Split(versionInfo[1:lastIndex], ";")
-	// Extended format: desiredUpdate;channel;upstream;upgradeType;nodePoolNames
-	if len(infos) >= 4 {
-		upgradeType = infos[3]
+// NewUpgradeRecord returns the record of an upgrade with the curator's upgrade settings
+func NewUpgradeRecord(
+	curator *clustercuratorv1.ClusterCurator,
+	result clustercuratorv1.CurationPhase) *clustercuratorv1.UpgradeRecord {
+
+	now := metav1.Now()
+	record := &clustercuratorv1.UpgradeRecord{
+		DesiredUpdate: curator.Spec.Upgrade.DesiredUpdate,
+		Channel:       curator.Spec.Upgrade.Channel,
+		Upstream:      curator.Spec.Upgrade.Upstream,
+		UpgradeType:   curator.Spec.Upgrade.UpgradeType,
+		Result:        result,
+		Timestamp:     &now,
+	}
+	if len(curator.Spec.Upgrade.NodePoolNames) > 0 {
+		record.NodePoolNames = append([]string{}, curator.Spec.Upgrade.NodePoolNames...)
+	}
+	return record
+}
+
+// RecordLastUpgrade writes status.lastUpgrade with the curator's upgrade settings and the result
+func RecordLastUpgrade(
+	client clientv1.Client,
+	clusterName string,
+	clusterNamespace string,
+	result clustercuratorv1.CurationPhase) error {
+
+	curator, err := GetClusterCurator(client, clusterName, clusterNamespace)
+	if err != nil {
+		return err
+	}
+
+	curator.Status.LastUpgrade = NewUpgradeRecord(curator, result)
+
+	return client.Update(context.TODO(), curator)
+}
+
+// GetHookImageAllowlist returns the images Container hooks may run, from HOOK_IMAGE_ALLOWLIST
+func GetHookImageAllowlist() []string {
+	allowlist := []string{}
+	for _, entry := range strings.Split(os.Getenv(HookImageAllowlistEnv), ",") {
+		if entry = strings.TrimSpace(entry); entry != "" {
+			allowlist = append(allowlist, entry)
+		}
+	}
+	return allowlist
+}
+
+// IsHookImageAllowed returns true when the image matches an allowlist entry. An entry ending with
+// "/" allows every image under that registry path, any other entry allows the image with any tag
+// or digest.
+func IsHookImageAllowed(image string, allowlist []string) bool {
+	for _, entry := range allowlist {
+		if strings.HasSuffix(entry, "/") {
+			if strings.HasPrefix(image, entry) {
+				return true
+			}
+			continue
+		}
+		if image == entry || strings.HasPrefix(image, entry+":") || strings.HasPrefix(image, entry+"@") {
+			return true
+		}
 	}
-	if len(infos) >= 5 {
-		nodePoolNames = infos[4]
+	return false
+}
+
+// IsDryRun returns true when spec.dryRun is set and no operation asks to run part of a real curation
+func IsDryRun(curator *clustercuratorv1.ClusterCurator) bool {
+	if curator.Operation != nil && (curator.Operation.RetryPosthook != "" || curator.Operation.ResumeFailed) {
+		return false
 	}
-	return upgradeType, nodePoolNames
+	return curator.Spec.DryRun
+}
+
+// RecordDryRunResult writes the findings of a dry run to status.dryRun
+func RecordDryRunResult(
+	client clientv1.Client,
+	clusterName string,
+	clusterNamespace string,
+	result *clustercuratorv1.DryRunResult) error {
+
+	curator, err := GetClusterCurator(client, clusterName, clusterNamespace)
+	if err != nil {
+		return err
+	}
+
+	curator.Status.DryRun = result
+
+	return client.Update(context.TODO(), curator)
+}
+
+// RecordLastFailure writes the details of a failed AnsibleJob to status.lastFailure
+func RecordLastFailure(
+	client clientv1.Client,
+	clusterName string,
+	clusterNamespace string,
+	failure *clustercuratorv1.AnsibleJobFailure) error {
+
+	return retry.RetryOnConflict(retry.DefaultBackoff, func() error {
+		curator, err := GetClusterCurator(client, clusterName, clusterNamespace)
+		if err != nil {
+			return err
+		}
+
+		curator.Status.LastFailure = failure
+		return client.Update(context.TODO(), curator)
+	})
+}
+
+// RecordHookAttempt records an attempt of a hook under the status.steps entry of stepName, an
+// attempt already recorded for the same hook, attempt number and AnsibleJob or Job is replaced
+func RecordHookAttempt(
+	client clientv1.Client,
+	clusterName string,
+	clusterNamespace string,
+	stepName string,
+	attempt clustercuratorv1.HookAttempt) error {
+
+	return retry.RetryOnConflict(retry.DefaultBackoff, func() error {
+		curator, err := GetClusterCurator(client, clusterName, clusterNamespace)
+		if err != nil {
+			return err
+		}
+
+		for i := range curator.Status.Steps {
+			step := &curator.Status.Steps[i]
+			if step.Name != stepName {
+				continue
+			}
+			for j := range step.HookAttempts {
+				recorded := step.HookAttempts[j]
+				if recorded.Hook == attempt.Hook && recorded.Attempt == attempt.Attempt &&
+					recorded.AnsibleJob == attempt.AnsibleJob && recorded.Job == attempt.Job {
+					step.HookAttempts[j] = attempt
+					return client.Update(context.TODO(), curator)
+				}
+			}
+			step.HookAttempts = append(step.HookAttempts, attempt)
+			return client.Update(context.TODO(), curator)
+		}
+
+		klog.V(2).Info("No step " + stepName + " found to record the hook attempt")
+		return nil
+	})
 }
 
-func parseVersionInfo(msg string) (channel, upstream string, semversion semver.Version, err error) {
+// RecordStepWarning adds a warning to the status.steps entry of stepName, it is used for failures
+// that do not fail the step
+func RecordStepWarning(
+	client clientv1.Client,
+	clusterName string,
+	clusterNamespace string,
+	stepName string,
+	warning string) error {
+
+	return retry.RetryOnConflict(retry.DefaultBackoff, func() error {
+		curator, err := GetClusterCurator(client, clusterName, clusterNamespace)
+		if err != nil {
+			return err
+		}
+
+		for i := range curator.Status.Steps {
+			if curator.Status.Steps[i].Name == stepName {
+				curator.Status.Steps[i].Warnings = append(curator.Status.Steps[i].Warnings, warning)
+				return client.Update(context.TODO(), curator)
+			}
+		}
+
+		klog.V(2).Info("No step " + stepName + " found to record the warning")
+		return nil
+	})
+}
+
+// RecordStepOutputs adds the outputs of a hook to the status.steps entry of stepName, replacing
+// outputs with the same key
+func RecordStepOutputs(
+	client clientv1.Client,
+	clusterName string,
+	clusterNamespace string,
+	stepName string,
+	outputs map[string]interface{}) error {
+
+	return retry.RetryOnConflict(retry.DefaultBackoff, func() error {
+		curator, err := GetClusterCurator(client, clusterName, clusterNamespace)
+		if err != nil {
+			return err
+		}
+
+		for i := range curator.Status.Steps {
+			step := &curator.Status.Steps[i]
+			if step.Name != stepName {
+				continue
+			}
+			stepOutputs, err := decodeStepOutputs(*step)
+			if err != nil {
+				return err
+			}
+			for key, value := range outputs {
+				stepOutputs[key] = value
+			}
+			raw, err := json.Marshal(stepOutputs)
+			if err != nil {
+				return err
+			}
+			step.Outputs = &runtime.RawExtension{Raw: raw}
+			return client.Update(context.TODO(), curator)
+		}
+
+		klog.V(2).Info("No step " + stepName + " found to record the hook outputs")
+		return nil
+	})
+}
+
+// GetHookOutputs returns the outputs recorded by the steps of the curation, the outputs of a later
+// step override those of an earlier one
+func GetHookOutputs(curator *clustercuratorv1.ClusterCurator) (map[string]interface{}, error) {
+	outputs := map[string]interface{}{}
+	for _, step := range curator.Status.Steps {
+		stepOutputs, err := decodeStepOutputs(step)
+		if err != nil {
+			return nil, err
+		}
+		for key, value := range stepOutputs {
+			outputs[key] = value
+		}
+	}
+	return outputs, nil
+}
+
+// decodeStepOutputs decodes the outputs of a step, UseNumber keeps large integers as they are
+func decodeStepOutputs(step clustercuratorv1.CurationStep) (map[string]interface{}, error) {
+	stepOutputs := map[string]interface{}{}
+	if step.Outputs == nil || len(step.Outputs.Raw) == 0 {
+		return stepOutputs, nil
+	}
+	decoder := json.NewDecoder(bytes.NewReader(step.Outputs.Raw))
+	decoder.UseNumber()
+	if err := decoder.Decode(&stepOutputs); err != nil {
+		return nil, errors.New("The outputs of step " + step.Name + " could not be read: " + err.Error())
+	}
+	return stepOutputs, nil
+}
+
+// parseUpgradeRecord reads an upgrade from a clustercurator-job condition message written by
+// an older curator, the formats are:
+//
+//	"... Version (desiredUpdate;channel;upstream;upgradeType;nodePoolNames)"
+//	"... Version (desiredUpdate;channel;upstream)"
+//	"... Version (desiredUpdate) Failed - <error>"
+//
+// the others are followed by " Failed - <error>" when the upgrade failed. The last one is the
+// 2.2.0 format, which only recorded the version of a failed upgrade and was compared on the
+// version alone, so the other settings are taken from the curator's upgrade.
+func parseUpgradeRecord(
+	condition metav1.Condition,
+	upgrade clustercuratorv1.UpgradeHooks) (*clustercuratorv1.UpgradeRecord, error) {
+
+	msg := condition.Message
 	index := strings.Index(msg, "(")
 	if index == -1 {
-		return channel, upstream, semversion, fmt.Errorf("missing '(' in the message")
+		return nil, fmt.Errorf("missing '(' in the message")
 	}
 	versionInfo := msg[index:]
 	lastIndex := strings.Index(versionInfo, ")")
 	if lastIndex == -1 {
-		return channel, upstream, semversion, fmt.Errorf("missing ')' in the message")
+		return nil, fmt.Errorf("missing ')' in the message")
 	}
 
+	failed := strings.Contains(msg[index+lastIndex:], "Failed")
 	infos := strings.Split(versionInfo[1:lastIndex], ";")
-	// Support both old format (3 fields) and new format (5 fields)
-	if len(infos) < 3 {
-		return channel, upstream, semversion, fmt.Errorf("wrong split message")
+	if len(infos) == 2 || (len(infos) == 1 && (infos[0] == "" || !failed)) {
+		return nil, fmt.Errorf("wrong split message")
 	}
 
-	version := infos[0]
-	channel = infos[1]
-	upstream = infos[2]
-
-	// there are only channel or upstream
-	if version == "" {
-		version = "0.0.0"
+	record := &clustercuratorv1.UpgradeRecord{
+		DesiredUpdate: infos[0],
+		Result:        clustercuratorv1.CurationPhaseSucceeded,
+		Timestamp:     condition.LastTransitionTime.DeepCopy(),
 	}
-
-	semversion, err = semver.Make(version)
-	if err != nil {
-		return channel, upstream, semversion, fmt.Errorf("")
+	if failed {
+		record.Result = clustercuratorv1.CurationPhaseFailed
 	}
-
-	return channel, upstream, semversion, nil
+	if len(infos) == 1 {
+		record.Channel = upgrade.Channel
+		record.Upstream = upgrade.Upstream
+		record.UpgradeType = upgrade.UpgradeType
+		if len(upgrade.NodePoolNames) > 0 {
+			record.NodePoolNames = append([]string{}, upgrade.NodePoolNames...)
+		}
+		return record, nil
+	}
+	record.Channel = infos[1]
+	record.Upstream = infos[2]
+	if len(infos) >= 4 {
+		record.UpgradeType = clustercuratorv1.UpgradeType(infos[3])
+	}
+	if len(infos) >= 5 && infos[4] != "" {
+		record.NodePoolNames = strings.Split(infos[4], ",")
+	}
+	return record, nil
 }
 
 func GetClusterType(
@@ -670,3 +1142,134 @@ func configFromFile(kubeconfig string) (*rest.Config, error) {
 		*config,
 		&clientcmd.ConfigOverrides{}).ClientConfig()
 }
+
+// CurationRunLabel is set to the name of the curator Job on the objects a curation creates, it
+// identifies the curation run they belong to
+const CurationRunLabel = "cluster.open-cluster-management.io/curation-run"
+
+// SetCurationRunOwner adds an ownerReference to the curator and the CurationRunLabel of its curator
+// Job to an object the curation creates, so the object is garbage collected with the curator and
+// removed by the cleanupPolicy
+func SetCurationRunOwner(obj v1.Object, curator *clustercuratorv1.ClusterCurator) {
+	if curator.UID != "" {
+		obj.SetOwnerReferences(append(obj.GetOwnerReferences(), v1.OwnerReference{
+			APIVersion: clustercuratorv1.GroupVersion.String(),
+			Kind:       "ClusterCurator",
+			Name:       curator.Name,
+			UID:        curator.UID,
+		}))
+	}
+
+	if curator.Spec.CuratingJob != "" {
+		labels := obj.GetLabels()
+		if labels == nil {
+			labels = map[string]string{}
+		}
+		labels[CurationRunLabel] = curator.Spec.CuratingJob
+		obj.SetLabels(labels)
+	}
+}
+
+// GetCurationRunName returns the name of an object of the running curation. The curator Job name
+// is appended, so each run uses its own object, name is returned as is when no curator Job runs.
+func GetCurationRunName(curator *clustercuratorv1.ClusterCurator, name string) string {
+	if curator.Spec.CuratingJob == "" {
+		return name
+	}
+	return name + "-" + curator.Spec.CuratingJob
+}
+
+// CleanupCurationRuns removes the AnsibleJobs, hook Jobs, ManagedClusterViews and
+// ManagedClusterActions the curator owns that belong to curation runs expired by its
+// cleanupPolicy. The runs are ordered by their newest object, the running curation is kept. It
+// returns the time until the next run expires by ttlMinutes, zero when no run is waiting on it.
+func CleanupCurationRuns(
+	reader clientv1.Reader, client clientv1.Client, curator *clustercuratorv1.ClusterCurator) (time.Duration, error) {
+
+	policy := curator.Spec.CleanupPolicy
+	if policy == nil || (policy.RetainRuns == nil && policy.TTLMinutes == 0) {
+		return 0, nil
+	}
+
+	ansibleJobs := &unstructured.UnstructuredList{}
+	ansibleJobs.SetAPIVersion("tower.ansible.com/v1alpha1")
+	ansibleJobs.SetKind("AnsibleJobList")
+	jobs := &batchv1.JobList{}
+	views := &managedclusterviewv1beta1.ManagedClusterViewList{}
+	actions := &managedclusteractionv1beta1.ManagedClusterActionList{}
+
+	objs := []clientv1.Object{}
+	for _, list := range []clientv1.ObjectList{ansibleJobs, jobs, views, actions} {
+		err := reader.List(context.TODO(), list, clientv1.InNamespace(curator.Namespace),
+			clientv1.HasLabels{CurationRunLabel})
+		if err != nil && !meta.IsNoMatchError(err) {
+			return 0, err
+		}
+		items, err := meta.ExtractList(list)
+		if err != nil {
+			return 0, err
+		}
+		for _, item := range items {
+			if obj, ok := item.(clientv1.Object); ok && isOwnedBy(obj, curator) {
+				objs = append(objs, obj)
+			}
+		}
+	}
+
+	runObjs := map[string][]clientv1.Object{}
+	newest := map[string]time.Time{}
+	for _, obj := range objs {
+		run := obj.GetLabels()[CurationRunLabel]
+		if run == curator.Spec.CuratingJob {
+			continue
+		}
+		runObjs[run] = append(runObjs[run], obj)
+		if created := obj.GetCreationTimestamp().Time; created.After(newest[run]) {
+			newest[run] = created
+		}
+	}
+
+	runs := make([]string, 0, len(runObjs))
+	for run := range runObjs {
+		runs = append(runs, run)
+	}
+	sort.Slice(runs, func(i, j int) bool {
+		return newest[runs[i]].After(newest[runs[j]])
+	})
+
+	requeueAfter := time.Duration(0)
+	ttl := time.Duration(policy.TTLMinutes) * time.Minute
+	for i, run := range runs {
+		expired := policy.RetainRuns != nil && i >= *policy.RetainRuns
+		if !expired && policy.TTLMinutes > 0 {
+			remaining := ttl - time.Since(newest[run])
+			if remaining <= 0 {
+				expired = true
+			} else if requeueAfter == 0 || remaining < requeueAfter {
+				requeueAfter = remaining
+			}
+		}
+		if !expired {
+			continue
+		}
+
+		klog.V(0).Info("Removing the objects of curation run " + run + " of " + curator.Namespace + "/" + curator.Name)
+		for _, obj := range runObjs[run] {
+			err := client.Delete(context.TODO(), obj, clientv1.PropagationPolicy(v1.DeletePropagationBackground))
+			if err != nil && !k8serrors.IsNotFound(err) {
+				return 0, err
+			}
+		}
+	}
+
+	return requeueAfter, nil
+}
+
+func isOwnedBy(obj v1.Object, curator *clustercuratorv1.ClusterCurator) bool {
+	for _, owner := range obj.GetOwnerReferences() {
+		if owner.UID == curator.UID && owner.Kind == "ClusterCurator" {
+			return true
+		}
+	}
+	return false
+}
@@ -5,16 +5,24 @@ import (
 	"context"
 	"errors"
 	"testing"
+	"time"
 
 	clustercuratorv1 "github.com/stolostron/cluster-curator-controller/pkg/api/v1beta1"
+	managedclusteractionv1beta1 "github.com/stolostron/cluster-lifecycle-api/action/v1beta1"
 	managedclusterinfov1beta1 "github.com/stolostron/cluster-lifecycle-api/clusterinfo/v1beta1"
+	managedclusterviewv1beta1 "github.com/stolostron/cluster-lifecycle-api/view/v1beta1"
 	"github.com/stretchr/testify/assert"
+	batchv1 "k8s.io/api/batch/v1"
 	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
 	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
 	dynfake "k8s.io/client-go/dynamic/fake"
 	"k8s.io/client-go/kubernetes/fake"
 	"k8s.io/client-go/kubernetes/scheme"
+	clientv1 "sigs.k8s.io/controller-runtime/pkg/client"
 	clientfake "sigs.k8s.io/controller-runtime/pkg/client/fake"
 )
 
@@ -249,6 +257,242 @@ func TestRecordCuratorJobNameInvalidCurator(t *testing.T) {
 
 }
 
+func TestRecordCuratorJobStarted(t *testing.T) {
+
+	cc := getClusterCurator()
+	cc.Status.Phase = clustercuratorv1.CurationPhaseFailed
+	cc.Status.CompletionTime = &v1.Time{}
+
+	s := scheme.Scheme
+	s.AddKnownTypes(CCGVR.GroupVersion(), &clustercuratorv1.ClusterCurator{})
+	client := clientfake.NewClientBuilder().WithScheme(s).WithRuntimeObjects(cc).Build()
+
+	err := RecordCuratorJobStarted(client, ClusterName, ClusterName, "my-job-ABCDE", "install",
+		[]string{"prehook-ansiblejob", "activate-and-monitor"})
+	assert.Nil(t, err, "err nil, when Job start recorded in the ClusterCurator")
+
+	ccNew, err := GetClusterCurator(client, ClusterName, ClusterName)
+	assert.Nil(t, err, "err nil, when ClusterCurator is retrieved")
+	assert.Equal(t, "my-job-ABCDE", ccNew.Spec.CuratingJob)
+	assert.Equal(t, clustercuratorv1.CurationPhasePending, ccNew.Status.Phase)
+	assert.Equal(t, "install", ccNew.Status.CurationType)
+	assert.Equal(t, "my-job-ABCDE", ccNew.Status.JobName)
+	assert.NotNil(t, ccNew.Status.StartTime, "start time is set")
+	assert.Nil(t, ccNew.Status.CompletionTime, "completion time of the previous job is cleared")
+	assert.Equal(t, 2, len(ccNew.Status.Steps))
+	assert.Equal(t, "prehook-ansiblejob", ccNew.Status.Steps[0].Name)
+	assert.Equal(t, clustercuratorv1.CurationPhasePending, ccNew.Status.Steps[1].State)
+}
+
+func TestRecordCuratorJobStartedResume(t *testing.T) {
+
+	cc := getClusterCurator()
+	cc.Operation = &clustercuratorv1.Operation{ResumeFailed: true}
+	cc.Status.Phase = clustercuratorv1.CurationPhaseFailed
+	cc.Status.Steps = []clustercuratorv1.CurationStep{
+		{Name: "prehook-ansiblejob", State: clustercuratorv1.CurationPhaseSucceeded},
+		{Name: "activate-and-monitor", State: clustercuratorv1.CurationPhaseSucceeded},
+		{Name: "monitor-import", State: clustercuratorv1.CurationPhaseFailed, FailureReason: "timed out"},
+	}
+
+	s := scheme.Scheme
+	s.AddKnownTypes(CCGVR.GroupVersion(), &clustercuratorv1.ClusterCurator{})
+	client := clientfake.NewClientBuilder().WithScheme(s).WithRuntimeObjects(cc).Build()
+
+	err := RecordCuratorJobStarted(client, ClusterName, ClusterName, "my-job-ABCDE", "install",
+		[]string{"monitor-import"})
+	assert.Nil(t, err, "err nil, when Job start recorded in the ClusterCurator")
+
+	ccNew, err := GetClusterCurator(client, ClusterName, ClusterName)
+	assert.Nil(t, err, "err nil, when ClusterCurator is retrieved")
+	assert.Equal(t, 3, len(ccNew.Status.Steps), "the skipped steps are kept")
+	assert.Equal(t, clustercuratorv1.CurationPhaseSucceeded, ccNew.Status.Steps[1].State)
+	assert.Equal(t, "monitor-import", ccNew.Status.Steps[2].Name)
+	assert.Equal(t, clustercuratorv1.CurationPhasePending, ccNew.Status.Steps[2].State)
+	assert.Equal(t, "", ccNew.Status.Steps[2].FailureReason, "the failure of the previous job is cleared")
+}
+
+func TestGetEffectiveCuration(t *testing.T) {
+	cc := getClusterCurator()
+	cc.Spec.DesiredCuration = "upgrade"
+	assert.Equal(t, "upgrade", GetEffectiveCuration(cc))
+
+	cc.Spec.DesiredCuration = ""
+	cc.Status.CurationType = "install"
+	cc.Operation = &clustercuratorv1.Operation{ResumeFailed: true}
+	assert.Equal(t, "install", GetEffectiveCuration(cc), "a resumed curation runs status.curationType")
+
+	cc.Operation.RetryPosthook = "installPosthook"
+	assert.Equal(t, "installPosthook", GetEffectiveCuration(cc), "retryPosthook wins over resumeFailed")
+}
+
+func TestGetResumeStep(t *testing.T) {
+	cc := getClusterCurator()
+	cc.Status.Steps = []clustercuratorv1.CurationStep{
+		{Name: "upgrade-cluster", State: clustercuratorv1.CurationPhaseSucceeded},
+		{Name: "monitor-upgrade", State: clustercuratorv1.CurationPhaseFailed},
+		{Name: "posthook-ansiblejob", State: clustercuratorv1.CurationPhasePending},
+	}
+	assert.Equal(t, "monitor-upgrade", GetResumeStep(cc))
+
+	cc.Status.Steps[1].State = clustercuratorv1.CurationPhaseSucceeded
+	cc.Status.Steps[2].State = clustercuratorv1.CurationPhaseSucceeded
+	assert.Equal(t, "", GetResumeStep(cc), "empty when every step succeeded")
+}
+
+func TestIsStepSucceeded(t *testing.T) {
+	cc := getClusterCurator()
+	cc.Status.Steps = []clustercuratorv1.CurationStep{
+		{Name: "wait-for-approval", State: clustercuratorv1.CurationPhaseSucceeded},
+		{Name: "prehook-ansiblejob", State: clustercuratorv1.CurationPhaseRunning},
+	}
+	assert.True(t, IsStepSucceeded(cc, "wait-for-approval"))
+	assert.False(t, IsStepSucceeded(cc, "prehook-ansiblejob"), "false, when the step is running")
+	assert.False(t, IsStepSucceeded(cc, "upgrade-cluster"), "false, when the step is not in status.steps")
+}
+
+func TestRecordCurrentStatusConditionUpdatesSteps(t *testing.T) {
+
+	cc := getClusterCurator()
+	cc.Status.Steps = []clustercuratorv1.CurationStep{
+		{Name: "activate-and-monitor", State: clustercuratorv1.CurationPhasePending},
+		{Name: "monitor-import", State: clustercuratorv1.CurationPhasePending},
+	}
+
+	s := scheme.Scheme
+	s.AddKnownTypes(CCGVR.GroupVersion(), &clustercuratorv1.ClusterCurator{})
+	client := clientfake.NewClientBuilder().WithScheme(s).WithRuntimeObjects(cc).Build()
+
+	getStatus := func() clustercuratorv1.ClusterCuratorStatus {
+		ccNew, err := GetClusterCurator(client, ClusterName, ClusterName)
+		assert.Nil(t, err, "err nil, when ClusterCurator is retrieved")
+		return ccNew.Status
+	}
+
+	assert.Nil(t, RecordCurrentStatusCondition(client, ClusterName, ClusterName, CuratorJobCondition,
+		v1.ConditionFalse, "curator-job-12345 DesiredCuration: install"))
+	status := getStatus()
+	assert.Equal(t, clustercuratorv1.CurationPhaseRunning, status.Phase)
+	assert.NotNil(t, status.StartTime, "start time is set when missing")
+
+	assert.Nil(t, RecordCurrentStatusCondition(client, ClusterName, ClusterName, "activate-and-monitor",
+		v1.ConditionFalse, "Executing init container activate-and-monitor"))
+	status = getStatus()
+	assert.Equal(t, clustercuratorv1.CurationPhaseRunning, status.Steps[0].State)
+	assert.NotNil(t, status.Steps[0].StartTime, "step start time is set")
+	assert.Equal(t, clustercuratorv1.CurationPhasePending, status.Steps[1].State)
+
+	t.Log("Conditions that are not steps do not change the steps")
+	assert.Nil(t, RecordCurrentStatusCondition(client, ClusterName, ClusterName, "hive-provisioning-job",
+		v1.ConditionTrue, "my-provision-job"))
+	assert.Equal(t, status.Steps, getStatus().Steps)
+
+	assert.Nil(t, RecordCurrentStatusCondition(client, ClusterName, ClusterName, "activate-and-monitor",
+		v1.ConditionTrue, "Completed executing init container"))
+	status = getStatus()
+	assert.Equal(t, clustercuratorv1.CurationPhaseSucceeded, status.Steps[0].State)
+	assert.NotNil(t, status.Steps[0].CompletionTime, "step completion time is set")
+
+	assert.Nil(t, RecordCurrentStatusCondition(client, ClusterName, ClusterName, "monitor-import",
+		v1.ConditionFalse, "Executing init container monitor-import"))
+	assert.Nil(t, RecordFailedCuratorStatusCondition(client, ClusterName, ClusterName, CuratorJobCondition,
+		v1.ConditionTrue, "curator-job-12345 DesiredCuration: install Failed - timed out"))
+	status = getStatus()
+	assert.Equal(t, clustercuratorv1.CurationPhaseFailed, status.Phase)
+	assert.NotNil(t, status.CompletionTime, "completion time is set")
+	assert.Equal(t, clustercuratorv1.CurationPhaseFailed, status.Steps[1].State, "running step is failed")
+	assert.Equal(t, "curator-job-12345 DesiredCuration: install Failed - timed out", status.Steps[1].FailureReason)
+}
+
+func TestRecordFailedCuratorStatusConditionStep(t *testing.T) {
+
+	cc := getClusterCurator()
+	cc.Status.Steps = []clustercuratorv1.CurationStep{
+		{Name: "upgrade-cluster", State: clustercuratorv1.CurationPhaseRunning},
+	}
+
+	s := scheme.Scheme
+	s.AddKnownTypes(CCGVR.GroupVersion(), &clustercuratorv1.ClusterCurator{})
+	client := clientfake.NewClientBuilder().WithScheme(s).WithRuntimeObjects(cc).Build()
+
+	assert.Nil(t, RecordFailedCuratorStatusCondition(client, ClusterName, ClusterName, "upgrade-cluster",
+		v1.ConditionTrue, "ClusterVersion not found"))
+
+	ccNew, err := GetClusterCurator(client, ClusterName, ClusterName)
+	assert.Nil(t, err, "err nil, when ClusterCurator is retrieved")
+	assert.Equal(t, clustercuratorv1.CurationPhaseFailed, ccNew.Status.Steps[0].State)
+	assert.Equal(t, "ClusterVersion not found", ccNew.Status.Steps[0].FailureReason)
+	assert.NotNil(t, ccNew.Status.Steps[0].CompletionTime, "step completion time is set")
+}
+
+func TestRecordCancelledCuration(t *testing.T) {
+
+	cc := getClusterCurator()
+	cc.Spec.DesiredCuration = "install"
+	cc.Spec.CuratingJob = "curator-job-12345"
+	cc.Operation = &clustercuratorv1.Operation{Cancel: true}
+	cc.Status.CurationType = "install"
+	cc.Status.Steps = []clustercuratorv1.CurationStep{
+		{Name: "prehook-ansiblejob", State: clustercuratorv1.CurationPhaseSucceeded},
+		{Name: "activate-and-monitor", State: clustercuratorv1.CurationPhaseRunning},
+		{Name: "posthook-ansiblejob", State: clustercuratorv1.CurationPhasePending},
+	}
+
+	s := scheme.Scheme
+	s.AddKnownTypes(CCGVR.GroupVersion(), &clustercuratorv1.ClusterCurator{})
+	client := clientfake.NewClientBuilder().WithScheme(s).WithRuntimeObjects(cc).Build()
+
+	assert.Nil(t, RecordCancelledCuration(client, ClusterName, ClusterName))
+
+	ccNew, err := GetClusterCurator(client, ClusterName, ClusterName)
+	assert.Nil(t, err, "err nil, when ClusterCurator is retrieved")
+	assert.Equal(t, "", ccNew.Spec.CuratingJob, "curatorJob is removed")
+	assert.Equal(t, "", ccNew.Spec.DesiredCuration, "desiredCuration is removed")
+	assert.Nil(t, ccNew.Operation, "operation is removed")
+	assert.Equal(t, clustercuratorv1.CurationPhaseCancelled, ccNew.Status.Phase)
+	assert.NotNil(t, ccNew.Status.CompletionTime, "completion time is set")
+	assert.Equal(t, clustercuratorv1.CurationPhaseSucceeded, ccNew.Status.Steps[0].State)
+	assert.Equal(t, clustercuratorv1.CurationPhaseCancelled, ccNew.Status.Steps[1].State)
+	assert.Equal(t, clustercuratorv1.CurationPhaseCancelled, ccNew.Status.Steps[2].State)
+
+	condition := meta.FindStatusCondition(ccNew.Status.Conditions, CuratorJobCondition)
+	assert.NotNil(t, condition, "clustercurator-job condition is recorded")
+	assert.Equal(t, v1.ConditionTrue, condition.Status)
+	assert.Equal(t, JobCancelled, condition.Reason)
+	assert.Equal(t, "curator-job-12345 DesiredCuration: install Cancelled", condition.Message)
+}
+
+func TestRecordCancelledCurationUpgrade(t *testing.T) {
+
+	cc := getClusterCurator()
+	cc.Spec.DesiredCuration = "upgrade"
+	cc.Spec.CuratingJob = "curator-job-12345"
+	cc.Spec.Upgrade.DesiredUpdate = "4.14.1"
+	cc.Operation = &clustercuratorv1.Operation{Cancel: true}
+	cc.Status.CurationType = "upgrade"
+
+	s := scheme.Scheme
+	s.AddKnownTypes(CCGVR.GroupVersion(), &clustercuratorv1.ClusterCurator{})
+	client := clientfake.NewClientBuilder().WithScheme(s).WithRuntimeObjects(cc).Build()
+
+	assert.Nil(t, RecordCancelledCuration(client, ClusterName, ClusterName))
+
+	ccNew, err := GetClusterCurator(client, ClusterName, ClusterName)
+	assert.Nil(t, err, "err nil, when ClusterCurator is retrieved")
+	assert.Equal(t, "upgrade", ccNew.Spec.DesiredCuration, "desiredCuration is kept for an upgrade")
+	assert.Equal(t, "", ccNew.Spec.CuratingJob, "curatorJob is removed")
+	assert.NotNil(t, ccNew.Status.LastUpgrade, "lastUpgrade is recorded")
+	assert.Equal(t, clustercuratorv1.CurationPhaseCancelled, ccNew.Status.LastUpgrade.Result)
+
+	condition := meta.FindStatusCondition(ccNew.Status.Conditions, CuratorJobCondition)
+	assert.NotNil(t, condition, "clustercurator-job condition is recorded")
+	assert.Equal(t, "curator-job-12345 DesiredCuration: upgrade Cancelled", condition.Message)
+
+	needed, err := NeedToUpgrade(nil, *ccNew)
+	assert.Nil(t, err, "err nil, when NeedToUpgrade runs")
+	assert.False(t, needed, "a cancelled upgrade is not retried until the settings change")
+}
+
 func getClusterNamespace() *corev1.Namespace {
 	return &corev1.Namespace{
 		ObjectMeta: v1.ObjectMeta{
@@ -326,19 +570,24 @@ func TestGetRetryTimes(t *testing.T) {
 	assert.Equal(t, 120, retryTimes)
 }
 
-func TestParseVersionInfo(t *testing.T) {
+func TestParseUpgradeRecord(t *testing.T) {
 	cases := []struct {
 		name             string
 		msg              string
+		upgrade          clustercuratorv1.UpgradeHooks
 		expectedVersion  string
 		expectedChannel  string
 		expectedUpstream string
+		expectedType     clustercuratorv1.UpgradeType
+		expectedPools    []string
+		expectedResult   clustercuratorv1.CurationPhase
 		expectedErr      bool
 	}{
 		{
 			name:            "done msg",
 			msg:             "curator-job-xxxx DesiredCuration: upgrade Version (4.11.4;;)",
 			expectedVersion: "4.11.4",
+			expectedResult:  clustercuratorv1.CurationPhaseSucceeded,
 			expectedErr:     false,
 		},
 		{
@@ -347,8 +596,44 @@ func TestParseVersionInfo(t *testing.T) {
 			expectedVersion:  "4.11.4",
 			expectedChannel:  "stable-4.10",
 			expectedUpstream: "upstream",
+			expectedResult:   clustercuratorv1.CurationPhaseSucceeded,
 			expectedErr:      false,
 		},
+		{
+			name:            "done msg - hosted",
+			msg:             "curator-job-xxxx DesiredCuration: upgrade Version (4.14.0;;;NodePools;np-1,np-2)",
+			expectedVersion: "4.14.0",
+			expectedType:    clustercuratorv1.UpgradeTypeNodePools,
+			expectedPools:   []string{"np-1", "np-2"},
+			expectedResult:  clustercuratorv1.CurationPhaseSucceeded,
+			expectedErr:     false,
+		},
+		{
+			name:            "failed msg - 2.2.0 version",
+			msg:             "curator-job-xxxx DesiredCuration: upgrade Version (4.11.4) Failed - error",
+			expectedVersion: "4.11.4",
+			expectedResult:  clustercuratorv1.CurationPhaseFailed,
+			expectedErr:     false,
+		},
+		{
+			name: "failed msg - 2.2.0 version takes the curator's settings",
+			msg:  "curator-job-xxxx DesiredCuration: upgrade Version (4.11.4) Failed - error",
+			upgrade: clustercuratorv1.UpgradeHooks{
+				DesiredUpdate: "4.11.5",
+				Channel:       "stable-4.11",
+				Upstream:      "upstream",
+			},
+			expectedVersion:  "4.11.4",
+			expectedChannel:  "stable-4.11",
+			expectedUpstream: "upstream",
+			expectedResult:   clustercuratorv1.CurationPhaseFailed,
+			expectedErr:      false,
+		},
+		{
+			name:        "done msg - 2.2.0 version",
+			msg:         "curator-job-xxxx DesiredCuration: upgrade Version (4.11.4)",
+			expectedErr: true,
+		},
 		{
 			name:        "broken msg",
 			msg:         "curator-job-xxxx DesiredCuration: upgrade Version )",
@@ -367,26 +652,22 @@ func TestParseVersionInfo(t *testing.T) {
 	}
 
 	for _, c := range cases {
-		actualChannel, actualUpstream, actualVersion, err := parseVersionInfo(c.msg)
+		record, err := parseUpgradeRecord(v1.Condition{Message: c.msg}, c.upgrade)
 		if err != nil && !c.expectedErr {
 			t.Errorf("unexpected error %v", err)
 		}
 
 		if c.expectedErr {
+			assert.NotNil(t, err, c.name)
 			continue
 		}
 
-		if actualChannel != c.expectedChannel {
-			t.Errorf("expected %s, but %s", c.expectedChannel, actualChannel)
-		}
-
-		if actualUpstream != c.expectedUpstream {
-			t.Errorf("expected %s, but %s", c.expectedUpstream, actualUpstream)
-		}
-
-		if actualVersion.String() != c.expectedVersion {
-			t.Errorf("expected %s, but %s", c.expectedVersion, actualVersion)
-		}
+		assert.Equal(t, c.expectedVersion, record.DesiredUpdate, c.name)
+		assert.Equal(t, c.expectedChannel, record.Channel, c.name)
+		assert.Equal(t, c.expectedUpstream, record.Upstream, c.name)
+		assert.Equal(t, c.expectedType, record.UpgradeType, c.name)
+		assert.Equal(t, c.expectedPools, record.NodePoolNames, c.name)
+		assert.Equal(t, c.expectedResult, record.Result, c.name)
 	}
 }
 
@@ -476,6 +757,29 @@ func TestNeedToUpgrade(t *testing.T) {
 			expectedUpgrade: false,
 			expectedErr:     false,
 		},
+		{
+			name: "failed job - desired version is unchanged in old version with a channel",
+			curator: clustercuratorv1.ClusterCurator{
+				Spec: clustercuratorv1.ClusterCuratorSpec{
+					Upgrade: clustercuratorv1.UpgradeHooks{
+						DesiredUpdate: "4.11.4",
+						Channel:       "stable-4.11",
+						Upstream:      "upstream",
+					},
+				},
+				Status: clustercuratorv1.ClusterCuratorStatus{
+					Conditions: []v1.Condition{
+						{
+							Message: "curator-job-xxxx DesiredCuration: upgrade Version (4.11.4) Failed - error",
+							Status:  v1.ConditionTrue,
+							Type:    "clustercurator-job",
+						},
+					},
+				},
+			},
+			expectedUpgrade: false,
+			expectedErr:     false,
+		},
 		{
 			name: "failed job - desired version is changed",
 			curator: clustercuratorv1.ClusterCurator{
@@ -757,6 +1061,152 @@ func TestNeedToUpgrade(t *testing.T) {
 			expectedUpgrade: true,
 			expectedErr:     false,
 		},
+		{
+			name: "lastUpgrade - desired version is not changed",
+			curator: clustercuratorv1.ClusterCurator{
+				Spec: clustercuratorv1.ClusterCuratorSpec{
+					Upgrade: clustercuratorv1.UpgradeHooks{
+						DesiredUpdate: "4.14.0",
+						UpgradeType:   clustercuratorv1.UpgradeTypeNodePools,
+						NodePoolNames: []string{"nodepool-1"},
+					},
+				},
+				Status: clustercuratorv1.ClusterCuratorStatus{
+					CurationType: "upgrade",
+					LastUpgrade: &clustercuratorv1.UpgradeRecord{
+						DesiredUpdate: "4.14.0",
+						UpgradeType:   clustercuratorv1.UpgradeTypeNodePools,
+						NodePoolNames: []string{"nodepool-1"},
+						Result:        clustercuratorv1.CurationPhaseSucceeded,
+					},
+				},
+			},
+			expectedUpgrade: false,
+			expectedErr:     false,
+		},
+		{
+			name: "lastUpgrade - takes precedence over the condition message",
+			curator: clustercuratorv1.ClusterCurator{
+				Spec: clustercuratorv1.ClusterCuratorSpec{
+					Upgrade: clustercuratorv1.UpgradeHooks{
+						DesiredUpdate: "4.14.1",
+					},
+				},
+				Status: clustercuratorv1.ClusterCuratorStatus{
+					Conditions: []v1.Condition{
+						{
+							Message: "curator-job-xxxx DesiredCuration: upgrade Version (4.14.0;;;;)",
+							Status:  v1.ConditionTrue,
+							Type:    "clustercurator-job",
+						},
+					},
+					CurationType: "upgrade",
+					LastUpgrade: &clustercuratorv1.UpgradeRecord{
+						DesiredUpdate: "4.14.1",
+						Result:        clustercuratorv1.CurationPhaseSucceeded,
+					},
+				},
+			},
+			expectedUpgrade: false,
+			expectedErr:     false,
+		},
+		{
+			name: "lastUpgrade - desired version is changed",
+			curator: clustercuratorv1.ClusterCurator{
+				Spec: clustercuratorv1.ClusterCuratorSpec{
+					Upgrade: clustercuratorv1.UpgradeHooks{
+						DesiredUpdate: "4.14.1",
+					},
+				},
+				Status: clustercuratorv1.ClusterCuratorStatus{
+					CurationType: "upgrade",
+					LastUpgrade: &clustercuratorv1.UpgradeRecord{
+						DesiredUpdate: "4.14.0",
+						Result:        clustercuratorv1.CurationPhaseSucceeded,
+					},
+				},
+			},
+			expectedUpgrade: true,
+			expectedErr:     false,
+		},
+		{
+			name: "lastUpgrade - failed job with unchanged settings",
+			curator: clustercuratorv1.ClusterCurator{
+				Spec: clustercuratorv1.ClusterCuratorSpec{
+					Upgrade: clustercuratorv1.UpgradeHooks{
+						DesiredUpdate: "4.14.1",
+						Channel:       "stable-4.14",
+					},
+				},
+				Status: clustercuratorv1.ClusterCuratorStatus{
+					CurationType: "upgrade",
+					LastUpgrade: &clustercuratorv1.UpgradeRecord{
+						DesiredUpdate: "4.14.1",
+						Channel:       "stable-4.14",
+						Result:        clustercuratorv1.CurationPhaseFailed,
+					},
+				},
+			},
+			expectedUpgrade: false,
+			expectedErr:     false,
+		},
+		{
+			name: "lastUpgrade - cancelled job with unchanged settings",
+			curator: clustercuratorv1.ClusterCurator{
+				Spec: clustercuratorv1.ClusterCuratorSpec{
+					Upgrade: clustercuratorv1.UpgradeHooks{
+						DesiredUpdate: "4.14.1",
+					},
+				},
+				Status: clustercuratorv1.ClusterCuratorStatus{
+					CurationType: "upgrade",
+					LastUpgrade: &clustercuratorv1.UpgradeRecord{
+						DesiredUpdate: "4.14.1",
+						Result:        clustercuratorv1.CurationPhaseCancelled,
+					},
+				},
+			},
+			expectedUpgrade: false,
+			expectedErr:     false,
+		},
+		{
+			name: "lastUpgrade - cancelled job with a new desiredUpdate",
+			curator: clustercuratorv1.ClusterCurator{
+				Spec: clustercuratorv1.ClusterCuratorSpec{
+					Upgrade: clustercuratorv1.UpgradeHooks{
+						DesiredUpdate: "4.14.2",
+					},
+				},
+				Status: clustercuratorv1.ClusterCuratorStatus{
+					CurationType: "upgrade",
+					LastUpgrade: &clustercuratorv1.UpgradeRecord{
+						DesiredUpdate: "4.14.1",
+						Result:        clustercuratorv1.CurationPhaseCancelled,
+					},
+				},
+			},
+			expectedUpgrade: true,
+			expectedErr:     false,
+		},
+		{
+			name: "lastUpgrade - last curation was not an upgrade",
+			curator: clustercuratorv1.ClusterCurator{
+				Spec: clustercuratorv1.ClusterCuratorSpec{
+					Upgrade: clustercuratorv1.UpgradeHooks{
+						DesiredUpdate: "4.14.1",
+					},
+				},
+				Status: clustercuratorv1.ClusterCuratorStatus{
+					CurationType: "scale",
+					LastUpgrade: &clustercuratorv1.UpgradeRecord{
+						DesiredUpdate: "4.14.1",
+						Result:        clustercuratorv1.CurationPhaseSucceeded,
+					},
+				},
+			},
+			expectedUpgrade: true,
+			expectedErr:     false,
+		},
 	}
 
 	for _, c := range cases {
@@ -772,6 +1222,166 @@ func TestNeedToUpgrade(t *testing.T) {
 	}
 }
 
+func TestRecordLastUpgrade(t *testing.T) {
+
+	cc := getClusterCurator()
+	cc.Spec.Upgrade = clustercuratorv1.UpgradeHooks{
+		DesiredUpdate: "4.14.1",
+		Channel:       "stable-4.14",
+		UpgradeType:   clustercuratorv1.UpgradeTypeNodePools,
+		NodePoolNames: []string{"nodepool-1"},
+	}
+
+	s := scheme.Scheme
+	s.AddKnownTypes(CCGVR.GroupVersion(), &clustercuratorv1.ClusterCurator{})
+	client := clientfake.NewClientBuilder().WithScheme(s).WithRuntimeObjects(cc).Build()
+
+	assert.Nil(t, RecordLastUpgrade(client, ClusterName, ClusterName, clustercuratorv1.CurationPhaseFailed))
+
+	ccNew, err := GetClusterCurator(client, ClusterName, ClusterName)
+	assert.Nil(t, err, "err nil, when ClusterCurator is retrieved")
+	assert.NotNil(t, ccNew.Status.LastUpgrade, "lastUpgrade is recorded")
+	assert.Equal(t, "4.14.1", ccNew.Status.LastUpgrade.DesiredUpdate)
+	assert.Equal(t, "stable-4.14", ccNew.Status.LastUpgrade.Channel)
+	assert.Equal(t, clustercuratorv1.UpgradeTypeNodePools, ccNew.Status.LastUpgrade.UpgradeType)
+	assert.Equal(t, []string{"nodepool-1"}, ccNew.Status.LastUpgrade.NodePoolNames)
+	assert.Equal(t, clustercuratorv1.CurationPhaseFailed, ccNew.Status.LastUpgrade.Result)
+	assert.NotNil(t, ccNew.Status.LastUpgrade.Timestamp, "timestamp is set")
+}
+
+func TestIsDryRun(t *testing.T) {
+	cc := getClusterCurator()
+	assert.False(t, IsDryRun(cc))
+
+	cc.Spec.DryRun = true
+	assert.True(t, IsDryRun(cc))
+
+	cc.Operation = &clustercuratorv1.Operation{ResumeFailed: true}
+	assert.False(t, IsDryRun(cc), "an operation runs part of a real curation")
+}
+
+func TestRecordDryRunResult(t *testing.T) {
+
+	cc := getClusterCurator()
+
+	s := scheme.Scheme
+	s.AddKnownTypes(CCGVR.GroupVersion(), &clustercuratorv1.ClusterCurator{})
+	client := clientfake.NewClientBuilder().WithScheme(s).WithRuntimeObjects(cc).Build()
+
+	assert.Nil(t, RecordDryRunResult(client, ClusterName, ClusterName, &clustercuratorv1.DryRunResult{
+		Curation: "upgrade",
+		Checks:   []clustercuratorv1.DryRunCheck{{Name: "hooks", Passed: true}},
+	}))
+
+	ccNew, err := GetClusterCurator(client, ClusterName, ClusterName)
+	assert.Nil(t, err, "err nil, when ClusterCurator is retrieved")
+	assert.NotNil(t, ccNew.Status.DryRun, "dryRun is recorded")
+	assert.Equal(t, "upgrade", ccNew.Status.DryRun.Curation)
+	assert.True(t, ccNew.Status.DryRun.Checks[0].Passed)
+}
+
+func TestRecordHookAttempt(t *testing.T) {
+
+	cc := getClusterCurator()
+	cc.Status.Steps = []clustercuratorv1.CurationStep{{Name: "prehook-ansiblejob"}}
+
+	s := scheme.Scheme
+	s.AddKnownTypes(CCGVR.GroupVersion(), &clustercuratorv1.ClusterCurator{})
+	client := clientfake.NewClientBuilder().WithScheme(s).WithRuntimeObjects(cc).Build()
+
+	attempt := clustercuratorv1.HookAttempt{
+		Hook:       "Service now App Update",
+		Attempt:    1,
+		AnsibleJob: "prehookjob-abcde",
+		Result:     clustercuratorv1.CurationPhaseRunning,
+	}
+	assert.Nil(t, RecordHookAttempt(client, ClusterName, ClusterName, "prehook-ansiblejob", attempt))
+
+	attempt.Result = clustercuratorv1.CurationPhaseSucceeded
+	attempt.URL = "https://tower/#/jobs/1"
+	assert.Nil(t, RecordHookAttempt(client, ClusterName, ClusterName, "prehook-ansiblejob", attempt))
+	assert.Nil(t, RecordHookAttempt(client, ClusterName, ClusterName, "posthook-ansiblejob", attempt),
+		"err nil, when the step is not found")
+
+	ccNew, err := GetClusterCurator(client, ClusterName, ClusterName)
+	assert.Nil(t, err, "err nil, when ClusterCurator is retrieved")
+	assert.Equal(t, 1, len(ccNew.Status.Steps[0].HookAttempts), "the attempt is updated in place")
+	assert.Equal(t, clustercuratorv1.CurationPhaseSucceeded, ccNew.Status.Steps[0].HookAttempts[0].Result)
+	assert.Equal(t, "https://tower/#/jobs/1", ccNew.Status.Steps[0].HookAttempts[0].URL)
+
+	t.Log("Attempts without an AnsibleJob or Job are told apart by their hook and number")
+	webhookAttempt := clustercuratorv1.HookAttempt{Hook: "Open change", Attempt: 1, Result: clustercuratorv1.CurationPhaseFailed}
+	assert.Nil(t, RecordHookAttempt(client, ClusterName, ClusterName, "prehook-ansiblejob", webhookAttempt))
+	webhookAttempt.Attempt = 2
+	webhookAttempt.Result = clustercuratorv1.CurationPhaseSucceeded
+	assert.Nil(t, RecordHookAttempt(client, ClusterName, ClusterName, "prehook-ansiblejob", webhookAttempt))
+
+	ccNew, err = GetClusterCurator(client, ClusterName, ClusterName)
+	assert.Nil(t, err, "err nil, when ClusterCurator is retrieved")
+	assert.Equal(t, 3, len(ccNew.Status.Steps[0].HookAttempts), "each webhook attempt is recorded")
+}
+
+func TestRecordStepWarning(t *testing.T) {
+
+	cc := getClusterCurator()
+	cc.Status.Steps = []clustercuratorv1.CurationStep{{Name: "posthook-ansiblejob"}}
+
+	s := scheme.Scheme
+	s.AddKnownTypes(CCGVR.GroupVersion(), &clustercuratorv1.ClusterCurator{})
+	client := clientfake.NewClientBuilder().WithScheme(s).WithRuntimeObjects(cc).Build()
+
+	assert.Nil(t, RecordStepWarning(client, ClusterName, ClusterName, "posthook-ansiblejob", "Hook failed"))
+	assert.Nil(t, RecordStepWarning(client, ClusterName, ClusterName, "prehook-ansiblejob", "Hook failed"),
+		"err nil, when the step is not found")
+
+	ccNew, err := GetClusterCurator(client, ClusterName, ClusterName)
+	assert.Nil(t, err, "err nil, when ClusterCurator is retrieved")
+	assert.Equal(t, []string{"Hook failed"}, ccNew.Status.Steps[0].Warnings)
+}
+
+func TestRecordStepOutputs(t *testing.T) {
+
+	cc := getClusterCurator()
+	cc.Status.Steps = []clustercuratorv1.CurationStep{{Name: "prehook-ansiblejob"}, {Name: "posthook-ansiblejob"}}
+
+	s := scheme.Scheme
+	s.AddKnownTypes(CCGVR.GroupVersion(), &clustercuratorv1.ClusterCurator{})
+	client := clientfake.NewClientBuilder().WithScheme(s).WithRuntimeObjects(cc).Build()
+
+	assert.Nil(t, RecordStepOutputs(client, ClusterName, ClusterName, "prehook-ansiblejob",
+		map[string]interface{}{"api_vip": "10.0.0.5", "change_id": "CHG001"}))
+	assert.Nil(t, RecordStepOutputs(client, ClusterName, ClusterName, "prehook-ansiblejob",
+		map[string]interface{}{"change_id": "CHG002"}))
+	assert.Nil(t, RecordStepOutputs(client, ClusterName, ClusterName, "posthook-ansiblejob",
+		map[string]interface{}{"api_vip": "10.0.0.6"}))
+	assert.Nil(t, RecordStepOutputs(client, ClusterName, ClusterName, "monitor-import",
+		map[string]interface{}{"api_vip": "10.0.0.7"}), "err nil, when the step is not found")
+
+	ccNew, err := GetClusterCurator(client, ClusterName, ClusterName)
+	assert.Nil(t, err, "err nil, when ClusterCurator is retrieved")
+	assert.JSONEq(t, `{"api_vip": "10.0.0.5", "change_id": "CHG002"}`, string(ccNew.Status.Steps[0].Outputs.Raw),
+		"the outputs of a step are merged")
+
+	outputs, err := GetHookOutputs(ccNew)
+	assert.Nil(t, err)
+	assert.Equal(t, map[string]interface{}{"api_vip": "10.0.0.6", "change_id": "CHG002"}, outputs,
+		"the outputs of a later step override those of an earlier one")
+
+	ccNew.Status.Steps[0].Outputs = &runtime.RawExtension{Raw: []byte(`["api_vip"]`)}
+	_, err = GetHookOutputs(ccNew)
+	assert.NotNil(t, err, "err not nil, when the outputs of a step are not an object")
+}
+
+func TestRecordLastUpgradeNoResource(t *testing.T) {
+
+	s := scheme.Scheme
+	s.AddKnownTypes(CCGVR.GroupVersion(), &clustercuratorv1.ClusterCurator{})
+	client := clientfake.NewClientBuilder().WithScheme(s).Build()
+
+	assert.NotNil(t, RecordLastUpgrade(client, ClusterName, ClusterName, clustercuratorv1.CurationPhaseSucceeded),
+		"err not nil, when ClusterCurator is not found")
+}
+
 func TestNeedToUpgradeAlreadyAtVersion(t *testing.T) {
 	s := scheme.Scheme
 	s.AddKnownTypes(managedclusterinfov1beta1.SchemeGroupVersion, &managedclusterinfov1beta1.ManagedClusterInfo{})
@@ -902,3 +1512,134 @@ func TestGetMonitorAttempts(t *testing.T) {
 	})
 	assert.Equal(t, 450, attempts)
 }
+
+func TestGetHookImageAllowlist(t *testing.T) {
+	t.Setenv(HookImageAllowlistEnv, "")
+	assert.Empty(t, GetHookImageAllowlist(), "no image is allowed by default")
+
+	t.Setenv(HookImageAllowlistEnv, " quay.io/my-org/ ,,registry.example.com/cmdb-hook")
+	assert.Equal(t, []string{"quay.io/my-org/", "registry.example.com/cmdb-hook"}, GetHookImageAllowlist())
+}
+
+func TestIsHookImageAllowed(t *testing.T) {
+	allowlist := []string{"quay.io/my-org/", "registry.example.com/cmdb-hook"}
+
+	for image, allowed := range map[string]bool{
+		"quay.io/my-org/cmdb-hook:1.0":                   true,
+		"quay.io/my-org/team/notify@sha256:abc":          true,
+		"quay.io/my-org-evil/cmdb-hook:1.0":              false,
+		"registry.example.com/cmdb-hook":                 true,
+		"registry.example.com/cmdb-hook:2.1":             true,
+		"registry.example.com/cmdb-hook@sha256:abc":      true,
+		"registry.example.com/cmdb-hook-evil:2.1":        false,
+		"registry.example.com/cmdb-hook/../other:latest": false,
+	} {
+		assert.Equal(t, allowed, IsHookImageAllowed(image, allowlist), image)
+	}
+
+	assert.False(t, IsHookImageAllowed("quay.io/my-org/cmdb-hook:1.0", []string{}),
+		"no image is allowed with an empty allowlist")
+}
+
+func TestSetCurationRunOwner(t *testing.T) {
+	curator := &clustercuratorv1.ClusterCurator{
+		ObjectMeta: v1.ObjectMeta{Name: ClusterName, Namespace: ClusterName, UID: "curator-uid"},
+		Spec:       clustercuratorv1.ClusterCuratorSpec{CuratingJob: "curator-job-d8sk2"},
+	}
+
+	job := &batchv1.Job{ObjectMeta: v1.ObjectMeta{Labels: map[string]string{"open-cluster-management": "curator-hook"}}}
+	SetCurationRunOwner(job, curator)
+	assert.Equal(t, "curator-job-d8sk2", job.Labels[CurationRunLabel])
+	assert.Equal(t, "curator-hook", job.Labels["open-cluster-management"], "the labels are kept")
+	assert.Equal(t, []v1.OwnerReference{{
+		APIVersion: "cluster.open-cluster-management.io/v1beta1",
+		Kind:       "ClusterCurator",
+		Name:       ClusterName,
+		UID:        "curator-uid",
+	}}, job.OwnerReferences)
+
+	assert.Equal(t, ClusterName+"-curator-job-d8sk2", GetCurationRunName(curator, ClusterName))
+
+	curator.UID = ""
+	curator.Spec.CuratingJob = ""
+	job = &batchv1.Job{}
+	SetCurationRunOwner(job, curator)
+	assert.Nil(t, job.Labels, "no run label, without a curator Job")
+	assert.Nil(t, job.OwnerReferences, "no ownerReference, without the curator UID")
+	assert.Equal(t, ClusterName, GetCurationRunName(curator, ClusterName))
+}
+
+func getRunObjects(curator *clustercuratorv1.ClusterCurator, run string, age time.Duration) []clientv1.Object {
+	created := v1.NewTime(time.Now().Add(-age))
+	runCurator := curator.DeepCopy()
+	runCurator.Spec.CuratingJob = run
+
+	ansibleJob := &unstructured.Unstructured{}
+	ansibleJob.SetAPIVersion("tower.ansible.com/v1alpha1")
+	ansibleJob.SetKind("AnsibleJob")
+	ansibleJob.SetName("prehookjob-" + run)
+	mcv := &managedclusterviewv1beta1.ManagedClusterView{ObjectMeta: v1.ObjectMeta{
+		Name: GetCurationRunName(runCurator, ClusterName)}}
+	mca := &managedclusteractionv1beta1.ManagedClusterAction{ObjectMeta: v1.ObjectMeta{
+		Name: GetCurationRunName(runCurator, ClusterName)}}
+	job := &batchv1.Job{ObjectMeta: v1.ObjectMeta{Name: "posthook-" + run}}
+
+	objs := []clientv1.Object{ansibleJob, mcv, mca, job}
+	for _, obj := range objs {
+		obj.SetNamespace(ClusterName)
+		obj.SetCreationTimestamp(created)
+		SetCurationRunOwner(obj, runCurator)
+	}
+	return objs
+}
+
+func TestCleanupCurationRuns(t *testing.T) {
+	curator := &clustercuratorv1.ClusterCurator{
+		ObjectMeta: v1.ObjectMeta{Name: ClusterName, Namespace: ClusterName, UID: "curator-uid"},
+	}
+
+	objs := append(getRunObjects(curator, "curator-job-old", 3*time.Hour),
+		getRunObjects(curator, "curator-job-new", time.Hour)...)
+	otherCurator := curator.DeepCopy()
+	otherCurator.UID = "other-uid"
+	objs = append(objs, getRunObjects(otherCurator, "curator-job-other", 5*time.Hour)...)
+
+	s := runtime.NewScheme()
+	assert.Nil(t, clustercuratorv1.AddToScheme(s))
+	assert.Nil(t, batchv1.AddToScheme(s))
+	assert.Nil(t, managedclusterviewv1beta1.AddToScheme(s))
+	assert.Nil(t, managedclusteractionv1beta1.AddToScheme(s))
+	client := clientfake.NewClientBuilder().WithScheme(s).WithObjects(objs...).Build()
+
+	requeueAfter, err := CleanupCurationRuns(client, client, curator)
+	assert.Nil(t, err)
+	assert.Equal(t, time.Duration(0), requeueAfter, "nothing is removed without a cleanupPolicy")
+
+	exists := func(obj clientv1.Object) bool {
+		return client.Get(context.TODO(), clientv1.ObjectKeyFromObject(obj), obj) == nil
+	}
+
+	retainRuns := 1
+	curator.Spec.CleanupPolicy = &clustercuratorv1.CleanupPolicy{RetainRuns: &retainRuns, TTLMinutes: 150}
+	requeueAfter, err = CleanupCurationRuns(client, client, curator)
+	assert.Nil(t, err)
+	assert.True(t, requeueAfter > 89*time.Minute && requeueAfter <= 90*time.Minute,
+		"requeued when the newest run expires")
+	for i, obj := range objs {
+		assert.Equal(t, i >= 4, exists(obj), "only the objects of the oldest run of the curator are removed")
+	}
+
+	t.Log("The objects of the running curation are kept")
+	curator.Spec.CuratingJob = "curator-job-new"
+	curator.Spec.CleanupPolicy = &clustercuratorv1.CleanupPolicy{TTLMinutes: 30}
+	requeueAfter, err = CleanupCurationRuns(client, client, curator)
+	assert.Nil(t, err)
+	assert.Equal(t, time.Duration(0), requeueAfter)
+	assert.True(t, exists(objs[4]), "the running curation is not removed")
+
+	curator.Spec.CuratingJob = ""
+	_, err = CleanupCurationRuns(client, client, curator)
+	assert.Nil(t, err)
+	assert.False(t, exists(objs[4]), "the run is removed once it expired by ttlMinutes")
+	assert.True(t, exists(objs[8]), "the objects of another curator are kept")
+}